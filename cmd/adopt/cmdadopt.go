@@ -0,0 +1,85 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package adopt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewCmdAdopt creates the `adopt` command, which creates the inventory
+// for a package whose objects already exist in the cluster.
+func NewCmdAdopt(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	adopter := apply.NewAdopter(f, ioStreams)
+
+	cmd := &cobra.Command{
+		Use:                   "adopt DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Track existing cluster resources in a new inventory"),
+		Long: i18n.T(`Track existing cluster resources in a new inventory.
+
+The objects in the package are matched with the live objects in the cluster,
+and an inventory object tracking the ones that exist is created. The objects
+themselves are not changed. The fields where the live objects differ from the
+package are reported, since they will be changed by the next apply.`),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(adopter.Initialize(cmd, args))
+			report, err := adopter.Run()
+			cmdutil.CheckErr(err)
+			printReport(ioStreams.Out, report, adopter.DryRun)
+		},
+	}
+
+	cmdutil.CheckErr(adopter.SetFlags(cmd))
+	cmd.Flags().BoolVar(&adopter.DryRun, "dry-run", false, "Report what would be adopted without creating the inventory.")
+
+	// The following flags are added, but hidden because other code
+	// dependencies when parsing flags. These flags are hidden and unused.
+	cmdutil.AddValidateFlags(cmd)
+	_ = cmd.Flags().MarkHidden("validate")
+	cmdutil.AddServerSideApplyFlags(cmd)
+	_ = cmd.Flags().MarkHidden("server-side")
+	_ = cmd.Flags().MarkHidden("force-conflicts")
+	_ = cmd.Flags().MarkHidden("field-manager")
+
+	return cmd
+}
+
+func printReport(w io.Writer, report *apply.AdoptionReport, dryRun bool) {
+	for _, obj := range report.Adopted {
+		if len(obj.Drift) == 0 {
+			fmt.Fprintf(w, "%s adopted\n", resourceIDToString(obj.Identifier))
+			continue
+		}
+		fmt.Fprintf(w, "%s adopted, %d field(s) differ from the package:\n",
+			resourceIDToString(obj.Identifier), len(obj.Drift))
+		for _, d := range obj.Drift {
+			fmt.Fprintf(w, "    %s\n", d.String())
+		}
+	}
+	for _, id := range report.Missing {
+		fmt.Fprintf(w, "%s not found, will be created by apply\n", resourceIDToString(id))
+	}
+	created := "created"
+	if dryRun {
+		created = "not created (dry-run)"
+	}
+	fmt.Fprintf(w, "%d resource(s) adopted, %d not found. Inventory %s %s\n",
+		len(report.Adopted), len(report.Missing), resourceIDToString(report.Inventory), created)
+}
+
+// resourceIDToString returns the string representation of an
+// identifier in the same format as the other commands.
+func resourceIDToString(id object.ObjMetadata) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(id.GroupKind.String()), id.Name)
+}
@@ -5,6 +5,11 @@ package apply
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -12,21 +17,36 @@ import (
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
 )
 
 func GetApplyRunner(f util.Factory, ioStreams genericclioptions.IOStreams) *ApplyRunner {
 	r := &ApplyRunner{
 		applier:   apply.NewApplier(f, ioStreams),
+		factory:   f,
 		ioStreams: ioStreams,
 	}
 	cmd := &cobra.Command{
-		Use:                   "apply DIRECTORY",
+		Use:                   "apply (DIRECTORY | FILE | KUSTOMIZATION_DIR | -)...",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Apply a configuration to a resource by filename or stdin"),
 		Run:                   r.Run,
 	}
 
 	cmd.Flags().BoolVar(&r.applier.NoPrune, "no-prune", r.applier.NoPrune, "If true, do not prune previously applied objects.")
+	cmd.Flags().IntVar(&r.applier.Concurrency, "concurrency", 1,
+		"The maximum number of resources that will be applied at the same time.")
+	cmd.Flags().BoolVar(&r.watch, "watch", false,
+		"If true, keep running and apply the package again whenever it changes.")
+	cmd.Flags().DurationVar(&r.watchInterval, "watch-interval", 5*time.Second,
+		"How often to check the package for changes with --watch.")
+	cmd.Flags().DurationVar(&r.watchDebounce, "watch-debounce", 2*time.Second,
+		"How long the package must be unchanged before it is applied with --watch.")
+	cmd.Flags().DurationVar(&r.resyncInterval, "resync-interval", 0,
+		"How often to apply the package with --watch even if it is unchanged, reverting changes made to the live objects. Disabled if zero.")
+	cmd.Flags().StringVar(&r.planFile, "plan", "",
+		"Apply the plan created by preview --out, if the cluster hasn't changed since the plan was created. "+
+			"A resource that changes while the plan is applied fails to apply, but pruned resources are only checked before applying.")
 	cmdutil.CheckErr(r.applier.SetFlags(cmd))
 
 	// The following flags are added, but hidden because other code
@@ -52,21 +72,122 @@ func ApplyCommand(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.
 
 type ApplyRunner struct {
 	command   *cobra.Command
+	factory   util.Factory
 	ioStreams genericclioptions.IOStreams
 	applier   *apply.Applier
+
+	watch          bool
+	watchInterval  time.Duration
+	watchDebounce  time.Duration
+	resyncInterval time.Duration
+	planFile       string
 }
 
 func (r *ApplyRunner) Run(cmd *cobra.Command, args []string) {
+	if r.planFile != "" {
+		cmdutil.CheckErr(r.readPlan(args))
+	}
 	cmdutil.CheckErr(r.applier.Initialize(cmd, args))
 
+	// The first interrupt cancels the context, so the applier stops
+	// after the resource it is currently applying. Any further
+	// interrupts will terminate the process right away.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	printer := &apply.BasicPrinter{
+		IOStreams: r.ioStreams,
+	}
+	if r.watch {
+		cmdutil.CheckErr(r.runWatch(ctx, cmd, args, printer))
+		return
+	}
+
 	// Run the applier. It will return a channel where we can receive updates
 	// to keep track of progress and any issues.
-	ch := r.applier.Run(context.Background())
+	ch := r.applier.Run(ctx)
 
 	// The printer will print updates from the channel. It will block
 	// until the channel is closed.
-	printer := &apply.BasicPrinter{
-		IOStreams: r.ioStreams,
-	}
 	printer.Print(ch, false)
 }
+
+// readPlan reads the plan file, which is applied instead of the
+// resources from the command line.
+func (r *ApplyRunner) readPlan(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("paths can't be used with --plan")
+	}
+	if r.watch {
+		return fmt.Errorf("--watch can't be used with --plan")
+	}
+	f, err := os.Open(r.planFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r.applier.Plan, err = apply.ReadPlan(f)
+	return err
+}
+
+// runWatch applies the package every time it changes, until the
+// context is cancelled. The applier that was already initialized is
+// used for the first run, and every later run uses a copy of it.
+func (r *ApplyRunner) runWatch(ctx context.Context, cmd *cobra.Command, args []string,
+	printer *apply.BasicPrinter) error {
+	paths := r.applier.Paths()
+	for _, path := range paths {
+		if path == "-" {
+			return fmt.Errorf("--watch can't be used when reading from stdin")
+		}
+	}
+	first := true
+	watcher := &apply.Watcher{
+		Paths:          paths,
+		PollInterval:   r.watchInterval,
+		Debounce:       r.watchDebounce,
+		ResyncInterval: r.resyncInterval,
+		NewApplier: func() (*apply.Applier, error) {
+			if first {
+				first = false
+				return r.applier, nil
+			}
+			applier := r.applier.Copy()
+			return applier, applier.Initialize(cmd, args)
+		},
+		// A failed run must not stop the watcher, so errors are
+		// printed instead of being handled by the printer.
+		Handle: func(ch <-chan event.Event) {
+			printer.Print(printErrors(ch, r.ioStreams.ErrOut), false)
+		},
+		Out: r.ioStreams.Out,
+	}
+	return watcher.Run(ctx)
+}
+
+// printErrors prints the error events from the channel, and forwards
+// all other events to the returned channel.
+func printErrors(ch <-chan event.Event, w io.Writer) <-chan event.Event {
+	out := make(chan event.Event)
+	go func() {
+		defer close(out)
+		for e := range ch {
+			if e.Type == event.ErrorType {
+				fmt.Fprintf(w, "error: %v\n", e.ErrorEvent.Err)
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
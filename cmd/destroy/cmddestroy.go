@@ -4,6 +4,10 @@
 package destroy
 
 import (
+	"context"
+	"os"
+	"os/signal"
+
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/kubectl/pkg/cmd/util"
@@ -27,9 +31,25 @@ func NewCmdDestroy(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 			paths := args
 			cmdutil.CheckErr(destroyer.Initialize(cmd, paths))
 
+			// The first interrupt cancels the context, so the destroyer
+			// stops after the resource it is currently deleting. Any
+			// further interrupts will terminate the process right away.
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt)
+			go func() {
+				defer signal.Stop(signals)
+				select {
+				case <-signals:
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
 			// Run the destroyer. It will return a channel where we can receive updates
 			// to keep track of progress and any issues.
-			ch := destroyer.Run()
+			ch := destroyer.Run(ctx)
 
 			// The printer will print updates from the channel. It will block
 			// until the channel is closed.
@@ -4,12 +4,92 @@
 package diff
 
 import (
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
-	"k8s.io/kubectl/pkg/cmd/diff"
 	"k8s.io/kubectl/pkg/cmd/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+)
+
+const (
+	// exitCodeChanges is the exit code with --exit-code if
+	// applying the package would change the cluster.
+	exitCodeChanges = 1
+	// exitCodeError is the exit code with --exit-code if the
+	// changes could not be computed.
+	exitCodeError = 2
 )
 
+// NewCmdDiff creates the `diff` command, which shows what applying
+// a package would change in the cluster, including the objects that
+// would be pruned.
 func NewCmdDiff(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
-	return diff.NewCmdDiff(f, ioStreams)
+	differ := apply.NewDiffer(f, ioStreams)
+	var exitCode bool
+
+	cmd := &cobra.Command{
+		Use:                   "diff DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Show what applying a package would change in the cluster"),
+		Long: i18n.T(`Show what applying a package would change in the cluster.
+
+The objects in the package are applied with server-side dry-run, and compared
+with the live objects. The objects that would be created, changed and pruned
+are shown as unified diffs, followed by a summary.
+
+With --exit-code, the command exits with 1 if there are changes, 0 if there
+are none, and 2 if the changes could not be computed.`),
+		Run: func(cmd *cobra.Command, args []string) {
+			result, err := runDiff(cmd, args, differ)
+			if err != nil && exitCode {
+				fmt.Fprintf(ioStreams.ErrOut, "error: %v\n", err)
+				os.Exit(exitCodeError)
+			}
+			cmdutil.CheckErr(err)
+			printResult(ioStreams.Out, result)
+			if exitCode && result.HasChanges() {
+				os.Exit(exitCodeChanges)
+			}
+		},
+	}
+
+	cmdutil.CheckErr(differ.SetFlags(cmd))
+	cmd.Flags().BoolVar(&exitCode, "exit-code", false,
+		"Exit with 1 if applying the package would change the cluster, and 2 on errors.")
+	cmdutil.AddServerSideApplyFlags(cmd)
+	_ = cmd.Flags().MarkHidden("field-manager")
+
+	// The following flags are added, but hidden because other code
+	// dependencies when parsing flags. These flags are hidden and unused.
+	var unusedBool bool
+	cmd.Flags().BoolVar(&unusedBool, "dry-run", unusedBool, "NOT USED")
+	_ = cmd.Flags().MarkHidden("dry-run")
+	cmdutil.AddValidateFlags(cmd)
+	_ = cmd.Flags().MarkHidden("validate")
+
+	return cmd
+}
+
+func runDiff(cmd *cobra.Command, args []string, differ *apply.Differ) (*apply.DiffResult, error) {
+	if err := differ.Initialize(cmd, args); err != nil {
+		return nil, err
+	}
+	return differ.Run()
+}
+
+func printResult(w io.Writer, result *apply.DiffResult) {
+	for _, obj := range result.Objects {
+		if obj.Action == apply.DiffUnchanged {
+			continue
+		}
+		fmt.Fprint(w, obj.Diff)
+	}
+	fmt.Fprintf(w, "%d to create, %d to configure, %d unchanged, %d to prune\n",
+		result.Count(apply.DiffCreated), result.Count(apply.DiffConfigured),
+		result.Count(apply.DiffUnchanged), result.Count(apply.DiffPruned))
 }
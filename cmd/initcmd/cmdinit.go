@@ -19,11 +19,28 @@ func NewCmdInit(ioStreams genericclioptions.IOStreams) *cobra.Command {
 		Use:                   "init DIRECTORY",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Create a prune manifest ConfigMap as a grouping object"),
+		Long: i18n.T(`Create a grouping object template for a package.
+
+By default the template is a ConfigMap, stored in the namespace of the
+package. With --cluster-scoped the template is a ClusterInventory instead,
+which doesn't need a namespace. The ClusterInventory CRD must be installed
+in the cluster first, and it can be printed with --print-crd.
+
+The namespace is taken from the objects in the package unless --namespace
+is set. It must be set if the objects are in more than one namespace.
+
+With --upgrade an existing template is rewritten in the current format,
+keeping its inventory-id.`),
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(io.Complete(args))
 			cmdutil.CheckErr(io.Run())
 		},
 	}
 	cmd.Flags().StringVarP(&io.InventoryID, "inventory-id", "i", "", "Identifier for group of applied resources. Must be composed of valid label characters.")
+	cmd.Flags().StringVar(&io.Namespace, "namespace", "", "Namespace for the grouping object. Derived from the package if not set.")
+	cmd.Flags().StringVar(&io.Name, "name", "", "Name of the grouping object. Defaults to \"inventory\".")
+	cmd.Flags().BoolVar(&io.Upgrade, "upgrade", false, "Rewrite the existing grouping object template in the current format, keeping its inventory-id.")
+	cmd.Flags().BoolVar(&io.ClusterScoped, "cluster-scoped", false, "Create a cluster-scoped ClusterInventory as the grouping object instead of a ConfigMap.")
+	cmd.Flags().BoolVar(&io.PrintCRD, "print-crd", false, "Print the CustomResourceDefinition for the ClusterInventory and exit.")
 	return cmd
 }
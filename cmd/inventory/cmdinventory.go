@@ -0,0 +1,177 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/util"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"sigs.k8s.io/cli-utils/pkg/apply"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewCmdInventory creates the `inventory` command, which has
+// subcommands for inspecting the inventory of a package.
+func NewCmdInventory(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "inventory",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Inspect the inventory of a package in the cluster"),
+	}
+	cmd.AddCommand(
+		newCmdList(f, ioStreams),
+		newCmdDiff(f, ioStreams),
+		newCmdOrphans(f, ioStreams),
+		newCmdMove(f, ioStreams),
+	)
+	return cmd
+}
+
+func newCmdList(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	inspector := apply.NewInventoryInspector(f, ioStreams)
+	cmd := &cobra.Command{
+		Use:                   "list DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List the objects tracked by the inventory, with their status"),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(inspector.Initialize(cmd, args))
+			objs, err := inspector.List(context.Background())
+			cmdutil.CheckErr(err)
+			printTrackedObjects(ioStreams.Out, objs)
+		},
+	}
+	addFlags(cmd, inspector.SetFlags)
+	return cmd
+}
+
+func newCmdDiff(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	inspector := apply.NewInventoryInspector(f, ioStreams)
+	cmd := &cobra.Command{
+		Use:                   "diff DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Compare the package with the inventory, showing what would be pruned"),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(inspector.Initialize(cmd, args))
+			diff, err := inspector.Diff()
+			cmdutil.CheckErr(err)
+			printDiff(ioStreams.Out, diff)
+		},
+	}
+	addFlags(cmd, inspector.SetFlags)
+	return cmd
+}
+
+func newCmdOrphans(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	inspector := apply.NewInventoryInspector(f, ioStreams)
+	cmd := &cobra.Command{
+		Use:                   "orphans DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Find objects with the inventory label that are not tracked"),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(inspector.Initialize(cmd, args))
+			orphans, err := inspector.Orphans()
+			cmdutil.CheckErr(err)
+			for _, id := range orphans {
+				fmt.Fprintln(ioStreams.Out, identifierToString(id))
+			}
+			fmt.Fprintf(ioStreams.Out, "%d orphaned object(s)\n", len(orphans))
+		},
+	}
+	addFlags(cmd, inspector.SetFlags)
+	return cmd
+}
+
+func newCmdMove(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	mover := apply.NewInventoryMover(f, ioStreams)
+	var from, to string
+	cmd := &cobra.Command{
+		Use:                   "move --from DIRECTORY --to DIRECTORY OBJECT...",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Move objects from the inventory of one package to another"),
+		Long: i18n.T(`Move objects from the inventory of one package to another.
+
+Move the manifests of the objects to the new package first, then run this
+command before applying the old package. Otherwise the next apply of the old
+package prunes the objects. The objects are added to the inventory of the new
+package before they are removed from the inventory of the old one, so they are
+never left untracked. If the command is interrupted, run it again.
+
+An OBJECT has the form KIND[.GROUP]/NAME, for example deployment.apps/app. Use
+NAMESPACE/KIND[.GROUP]/NAME if the new package has objects with the same kind
+and name in several namespaces.`),
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(mover.Initialize(cmd, from, to))
+			ids, err := mover.Resolve(args)
+			cmdutil.CheckErr(err)
+			cmdutil.CheckErr(mover.Move(ids))
+			for _, id := range ids {
+				fmt.Fprintf(ioStreams.Out, "%s moved\n", identifierToString(id))
+			}
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Directory of the package the objects are moved from.")
+	cmd.Flags().StringVar(&to, "to", "", "Directory of the package the objects are moved to.")
+	_ = cmd.MarkFlagRequired("from")
+	_ = cmd.MarkFlagRequired("to")
+	addFlags(cmd, mover.SetFlags)
+	return cmd
+}
+
+// addFlags adds the flags needed for reading the package. Some of
+// them are added, but hidden and unused, because ApplyOptions reads
+// them when parsing flags.
+func addFlags(cmd *cobra.Command, setFlags func(*cobra.Command) error) {
+	cmdutil.CheckErr(setFlags(cmd))
+	var unusedBool bool
+	cmd.Flags().BoolVar(&unusedBool, "dry-run", unusedBool, "NOT USED")
+	_ = cmd.Flags().MarkHidden("dry-run")
+	cmdutil.AddValidateFlags(cmd)
+	_ = cmd.Flags().MarkHidden("validate")
+	cmdutil.AddServerSideApplyFlags(cmd)
+	_ = cmd.Flags().MarkHidden("server-side")
+	_ = cmd.Flags().MarkHidden("force-conflicts")
+	_ = cmd.Flags().MarkHidden("field-manager")
+}
+
+func printTrackedObjects(w io.Writer, objs []apply.TrackedObject) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tRESOURCE\tSTATUS\tMESSAGE")
+	for _, obj := range objs {
+		id := obj.Identifier
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", id.Namespace, resourceToString(id),
+			obj.Status, obj.Message)
+	}
+	_ = tw.Flush()
+}
+
+func printDiff(w io.Writer, diff *apply.InventoryDiff) {
+	for _, id := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", identifierToString(id))
+	}
+	for _, id := range diff.Pruned {
+		fmt.Fprintf(w, "- %s (will be pruned)\n", identifierToString(id))
+	}
+	fmt.Fprintf(w, "%d added, %d unchanged, %d to be pruned\n",
+		len(diff.Added), len(diff.Unchanged), len(diff.Pruned))
+}
+
+func resourceToString(id object.ObjMetadata) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(id.GroupKind.String()), id.Name)
+}
+
+func identifierToString(id object.ObjMetadata) string {
+	if id.Namespace == "" {
+		return resourceToString(id)
+	}
+	return fmt.Sprintf("%s (namespace %s)", resourceToString(id), id.Namespace)
+}
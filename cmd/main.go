@@ -11,10 +11,12 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/cmd/adopt"
 	"sigs.k8s.io/cli-utils/cmd/apply"
 	"sigs.k8s.io/cli-utils/cmd/destroy"
 	"sigs.k8s.io/cli-utils/cmd/diff"
 	"sigs.k8s.io/cli-utils/cmd/initcmd"
+	"sigs.k8s.io/cli-utils/cmd/inventory"
 	"sigs.k8s.io/cli-utils/cmd/preview"
 	"sigs.k8s.io/cli-utils/cmd/status"
 
@@ -45,7 +47,7 @@ func main() {
 		ErrOut: os.Stderr,
 	}
 
-	names := []string{"init", "apply", "preview", "diff", "destroy", "status"}
+	names := []string{"init", "apply", "preview", "diff", "destroy", "status", "inventory", "adopt"}
 	initCmd := initcmd.NewCmdInit(ioStreams)
 	updateHelp(names, initCmd)
 	applyCmd := apply.ApplyCommand(f, ioStreams)
@@ -58,8 +60,12 @@ func main() {
 	updateHelp(names, destroyCmd)
 	statusCmd := status.StatusCommand()
 	updateHelp(names, statusCmd)
+	inventoryCmd := inventory.NewCmdInventory(f, ioStreams)
+	updateHelp(names, inventoryCmd)
+	adoptCmd := adopt.NewCmdAdopt(f, ioStreams)
+	updateHelp(names, adoptCmd)
 
-	cmd.AddCommand(initCmd, applyCmd, diffCmd, destroyCmd, previewCmd, statusCmd)
+	cmd.AddCommand(initCmd, applyCmd, diffCmd, destroyCmd, previewCmd, statusCmd, inventoryCmd, adoptCmd)
 
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
@@ -5,6 +5,8 @@ package preview
 
 import (
 	"context"
+	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -23,6 +25,8 @@ func NewCmdPreview(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 	printer := &apply.BasicPrinter{
 		IOStreams: ioStreams,
 	}
+	var out string
+	var serverSide bool
 
 	cmd := &cobra.Command{
 		Use:                   "preview DIRECTORY",
@@ -31,15 +35,31 @@ func NewCmdPreview(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 		Args:                  cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			var ch <-chan event.Event
+			if destroyer.DryRun && out != "" {
+				cmdutil.CheckErr(fmt.Errorf("--out can't be used with --destroy"))
+			}
 			cmdutil.CheckErr(destroyer.Initialize(cmd, args))
 			// if destroy flag is set in preview, transmit it to destroyer DryRun flag
 			// and pivot execution to destroy with dry-run
 			if !destroyer.DryRun {
-				// Set DryRun option true before Initialize. DryRun is propagated to
-				// ApplyOptions and PruneOptions in Initialize.
-				applier.DryRun = true
+				// Set the dry-run option before Initialize. It is propagated to
+				// ApplyOptions and PruneOptions in Initialize. With --server-side
+				// the dry-run is done by the server, so validation and admission
+				// webhooks are run for every resource. The resources are still
+				// applied the same way as by the apply command.
+				if serverSide {
+					applier.ServerDryRun = true
+				} else {
+					applier.DryRun = true
+				}
 				cmdutil.CheckErr(applier.Initialize(cmd, args))
 
+				// The plan is previewed, so the preview shows exactly
+				// the changes that are saved.
+				if out != "" {
+					cmdutil.CheckErr(writePlan(applier, out))
+				}
+
 				// Create a context with the provided timout from the cobra parameter.
 				ctx, cancel := context.WithTimeout(context.Background(), applier.StatusOptions.Timeout)
 				defer cancel()
@@ -48,7 +68,7 @@ func NewCmdPreview(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 				// to keep track of progress and any issues.
 				ch = applier.Run(ctx)
 			} else {
-				ch = destroyer.Run()
+				ch = destroyer.Run(context.Background())
 			}
 
 			// The printer will print updates from the channel. It will block
@@ -57,6 +77,8 @@ func NewCmdPreview(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 		},
 	}
 
+	cmd.Flags().StringVar(&out, "out", "",
+		"Save the plan for the apply to this file, so it can be applied later with apply --plan.")
 	cmd.Flags().BoolVar(&applier.NoPrune, "no-prune", applier.NoPrune, "If true, do not prune previously applied objects.")
 	cmdutil.CheckErr(applier.SetFlags(cmd))
 
@@ -68,11 +90,34 @@ func NewCmdPreview(f util.Factory, ioStreams genericclioptions.IOStreams) *cobra
 	_ = cmd.Flags().MarkHidden("dry-run")
 	cmdutil.AddValidateFlags(cmd)
 	_ = cmd.Flags().MarkHidden("validate")
-	// Server-side flags are hidden for now.
-	cmdutil.AddServerSideApplyFlags(cmd)
-	_ = cmd.Flags().MarkHidden("server-side")
-	_ = cmd.Flags().MarkHidden("force-conflicts")
-	_ = cmd.Flags().MarkHidden("field-manager")
+	// This is not the server-side apply flag from kubectl, since the
+	// preview should apply the resources the same way as the apply
+	// command. It only makes the server do the dry-run.
+	cmd.Flags().BoolVar(&serverSide, "server-side", serverSide,
+		"If true, the preview is done with dry-run requests to the server, so the resources "+
+			"are validated by the server and admission webhooks.")
 
 	return cmd
 }
+
+// writePlan creates a plan for the apply and saves it to the file.
+// The plan is set on the applier, so it is previewed.
+func writePlan(applier *apply.Applier, path string) error {
+	plan, err := applier.CreatePlan()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := apply.WritePlan(f, plan); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	applier.Plan = plan
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-errors/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-utils/pkg/drift"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// computeDrift compares each of the manifests captured by the filter
+// with the live resource, restricted to the fields managed by apply.
+func computeDrift(ctx context.Context, c client.Reader, mapper meta.RESTMapper,
+	f *CaptureIdentifiersFilter) ([]drift.ResourceDrift, error) {
+	var results []drift.ResourceDrift
+	for i, id := range f.Identifiers {
+		local, err := manifestToUnstructured(f.Manifests[i])
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error reading manifest for "+id.Name, 1)
+		}
+		result, err := resourceDrift(ctx, c, mapper, id, local)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func resourceDrift(ctx context.Context, c client.Reader, mapper meta.RESTMapper,
+	id object.ObjMetadata, local *unstructured.Unstructured) (drift.ResourceDrift, error) {
+	result := drift.ResourceDrift{Identifier: id}
+	mapping, err := mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return result, err
+	}
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(mapping.GroupVersionKind)
+	err = c.Get(ctx, client.ObjectKey{Namespace: id.Namespace, Name: id.Name}, live)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			result.NotFound = true
+			return result, nil
+		}
+		return result, errors.WrapPrefix(err, fmt.Sprintf("error getting %s/%s", id.GroupKind.Kind, id.Name), 1)
+	}
+	result.Fields, err = drift.CompareManaged(local, live)
+	return result, err
+}
+
+// manifestToUnstructured converts the manifest to Unstructured. The
+// annotations added when reading the package are removed, since they
+// are never set on the live resource.
+func manifestToUnstructured(node *yaml.RNode) (*unstructured.Unstructured, error) {
+	s, err := node.String()
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	if err := sigsyaml.Unmarshal([]byte(s), &u.Object); err != nil {
+		return nil, err
+	}
+	annotations := u.GetAnnotations()
+	delete(annotations, kioutil.IndexAnnotation)
+	delete(annotations, kioutil.PathAnnotation)
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+	} else {
+		u.SetAnnotations(annotations)
+	}
+	return u, nil
+}
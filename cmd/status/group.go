@@ -0,0 +1,64 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package status
+
+import (
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+const (
+	// clusterScopedGroup is the group of cluster-scoped resources when
+	// grouping by namespace.
+	clusterScopedGroup = "<cluster>"
+	// noLabelGroup is the group of resources that don't have the label
+	// when grouping by a label.
+	noLabelGroup = "<none>"
+)
+
+// groupByFunc returns the function that gives the group of a resource
+// for the --group-by flag, which is one of namespace, kind or
+// label:KEY. Labels are read from the manifests, so resources that
+// don't exist in the cluster are still grouped. It returns nil if the
+// resources are not grouped.
+func groupByFunc(groupBy string, f *CaptureIdentifiersFilter) (func(*event.ResourceStatus) string, error) {
+	switch {
+	case groupBy == "":
+		return nil, nil
+	case groupBy == "namespace":
+		return func(r *event.ResourceStatus) string {
+			if r.Identifier.Namespace == "" {
+				return clusterScopedGroup
+			}
+			return r.Identifier.Namespace
+		}, nil
+	case groupBy == "kind":
+		return func(r *event.ResourceStatus) string {
+			return r.Identifier.GroupKind.String()
+		}, nil
+	case strings.HasPrefix(groupBy, "label:") && len(groupBy) > len("label:"):
+		key := strings.TrimPrefix(groupBy, "label:")
+		values := make(map[object.ObjMetadata]string)
+		for i, id := range f.Identifiers {
+			objectMeta, err := f.Manifests[i].GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			if value, found := objectMeta.Labels[key]; found {
+				values[id] = value
+			}
+		}
+		return func(r *event.ResourceStatus) string {
+			if value, found := values[r.Identifier]; found {
+				return value
+			}
+			return noLabelGroup
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown value %q for --group-by, must be one of namespace, kind or label:KEY", groupBy)
+	}
+}
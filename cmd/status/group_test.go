@@ -0,0 +1,133 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package status
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var (
+	deploymentID = object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Namespace: "default",
+		Name:      "frontend",
+	}
+	serviceID = object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Service"},
+		Namespace: "other",
+		Name:      "backend",
+	}
+	namespaceID = object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Namespace"},
+		Name:      "other",
+	}
+)
+
+var manifests = map[object.ObjMetadata]string{
+	deploymentID: `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: default
+  labels:
+    tier: web
+`,
+	serviceID: `
+apiVersion: v1
+kind: Service
+metadata:
+  name: backend
+  namespace: other
+  labels:
+    tier: api
+`,
+	namespaceID: `
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: other
+`,
+}
+
+func TestGroupByFunc(t *testing.T) {
+	ids := []object.ObjMetadata{deploymentID, serviceID, namespaceID}
+
+	testCases := map[string]struct {
+		groupBy        string
+		expectedGroups map[object.ObjMetadata]string
+		expectNil      bool
+		expectError    bool
+	}{
+		"not grouped": {
+			groupBy:   "",
+			expectNil: true,
+		},
+		"namespace": {
+			groupBy: "namespace",
+			expectedGroups: map[object.ObjMetadata]string{
+				deploymentID: "default",
+				serviceID:    "other",
+				namespaceID:  clusterScopedGroup,
+			},
+		},
+		"kind": {
+			groupBy: "kind",
+			expectedGroups: map[object.ObjMetadata]string{
+				deploymentID: "Deployment.apps",
+				serviceID:    "Service",
+				namespaceID:  "Namespace",
+			},
+		},
+		"label": {
+			groupBy: "label:tier",
+			expectedGroups: map[object.ObjMetadata]string{
+				deploymentID: "web",
+				serviceID:    "api",
+				namespaceID:  noLabelGroup,
+			},
+		},
+		"label without a key": {
+			groupBy:     "label:",
+			expectError: true,
+		},
+		"unknown": {
+			groupBy:     "name",
+			expectError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			f := &CaptureIdentifiersFilter{Identifiers: ids}
+			for _, id := range ids {
+				f.Manifests = append(f.Manifests, yaml.MustParse(manifests[id]))
+			}
+
+			groupBy, err := groupByFunc(tc.groupBy, f)
+			if tc.expectError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tc.expectNil {
+				assert.Nil(t, groupBy)
+				return
+			}
+			groups := make(map[object.ObjMetadata]string)
+			for _, id := range ids {
+				groups[id] = groupBy(&event.ResourceStatus{Identifier: id})
+			}
+			assert.Equal(t, tc.expectedGroups, groups)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package printers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/cli-utils/pkg/drift"
+)
+
+// DriftPrinter defines an interface for outputting the drift between
+// the manifests and the live resources.
+type DriftPrinter interface {
+	Print(results []drift.ResourceDrift) error
+}
+
+// CreateDriftPrinter returns an implementation of the DriftPrinter
+// interface based on the printerType requested.
+func CreateDriftPrinter(printerType string, w io.Writer) (DriftPrinter, error) {
+	switch printerType {
+	case "table":
+		return &driftTablePrinter{w: w}, nil
+	case "json":
+		return &driftJSONPrinter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("no drift printer available for output %q", printerType)
+	}
+}
+
+// driftTablePrinter prints a row for each resource, followed by a
+// line for each of the fields that have drifted.
+type driftTablePrinter struct {
+	w io.Writer
+}
+
+func (t *driftTablePrinter) Print(results []drift.ResourceDrift) error {
+	if _, err := fmt.Fprintf(t.w, "%-10s  %-40s  %s\n", "NAMESPACE", "RESOURCE", "DRIFT"); err != nil {
+		return err
+	}
+	for _, r := range results {
+		var text string
+		var c color
+		switch {
+		case r.NotFound:
+			text, c = "NotFound", RED
+		case len(r.Fields) > 0:
+			text, c = fmt.Sprintf("%d field(s)", len(r.Fields)), RED
+		default:
+			text, c = "None", GREEN
+		}
+		resource := fmt.Sprintf("%s/%s", r.Identifier.GroupKind.Kind, r.Identifier.Name)
+		_, err := fmt.Fprintf(t.w, "%-10s  %-40s  %s\n", r.Identifier.Namespace, resource,
+			sPrintWithColor(c, text))
+		if err != nil {
+			return err
+		}
+		for _, f := range r.Fields {
+			if _, err := fmt.Fprintf(t.w, "    %s\n", f.String()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// driftJSONPrinter prints the drift for all resources as a
+// JSON array.
+type driftJSONPrinter struct {
+	w io.Writer
+}
+
+type jsonResourceDrift struct {
+	Group     string          `json:"group"`
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace,omitempty"`
+	Name      string          `json:"name"`
+	Drifted   bool            `json:"drifted"`
+	NotFound  bool            `json:"notFound,omitempty"`
+	Fields    []jsonFieldDiff `json:"fields,omitempty"`
+}
+
+type jsonFieldDiff struct {
+	Path  string      `json:"path"`
+	Local interface{} `json:"local"`
+	Live  interface{} `json:"live"`
+}
+
+func (j *driftJSONPrinter) Print(results []drift.ResourceDrift) error {
+	out := make([]jsonResourceDrift, 0, len(results))
+	for _, r := range results {
+		rd := jsonResourceDrift{
+			Group:     r.Identifier.GroupKind.Group,
+			Kind:      r.Identifier.GroupKind.Kind,
+			Namespace: r.Identifier.Namespace,
+			Name:      r.Identifier.Name,
+			Drifted:   r.Drifted(),
+			NotFound:  r.NotFound,
+		}
+		for _, f := range r.Fields {
+			rd.Fields = append(rd.Fields, jsonFieldDiff{
+				Path:  f.Path,
+				Local: f.Local,
+				Live:  f.Live,
+			})
+		}
+		out = append(out, rd)
+	}
+	encoder := json.NewEncoder(j.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
@@ -0,0 +1,123 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package printers
+
+import (
+	"encoding/json"
+	"io"
+
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/aggregator"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+)
+
+// jsonPrinter is an implementation of the Printer interface that
+// outputs the status of the resources as a single JSON document once
+// polling has finished, so it can be consumed by other tools.
+type jsonPrinter struct {
+	collector    *collector.ResourceStatusCollector
+	w            io.Writer
+	groupOptions GroupOptions
+}
+
+// NewJSONPrinter returns a new instance of the jsonPrinter. If the
+// groupOptions have a GroupBy function, the resources are listed for
+// each group, together with the aggregate status of the group.
+func NewJSONPrinter(collector *collector.ResourceStatusCollector,
+	w io.Writer, groupOptions GroupOptions) *jsonPrinter {
+	return &jsonPrinter{
+		collector:    collector,
+		w:            w,
+		groupOptions: groupOptions,
+	}
+}
+
+type jsonStatus struct {
+	AggregateStatus string               `json:"aggregateStatus"`
+	Error           string               `json:"error,omitempty"`
+	Resources       []jsonResourceStatus `json:"resources,omitempty"`
+	Groups          []jsonGroup          `json:"groups,omitempty"`
+}
+
+type jsonGroup struct {
+	Name            string               `json:"name"`
+	AggregateStatus string               `json:"aggregateStatus"`
+	Counts          map[string]int       `json:"counts"`
+	Resources       []jsonResourceStatus `json:"resources"`
+}
+
+type jsonResourceStatus struct {
+	Group              string               `json:"group"`
+	Kind               string               `json:"kind"`
+	Namespace          string               `json:"namespace,omitempty"`
+	Name               string               `json:"name"`
+	Status             string               `json:"status"`
+	Message            string               `json:"message,omitempty"`
+	Error              string               `json:"error,omitempty"`
+	GeneratedResources []jsonResourceStatus `json:"generatedResources,omitempty"`
+}
+
+// Print waits until the provided stop channel is closed, and then
+// prints the latest status of the resources.
+func (j *jsonPrinter) Print(stop <-chan struct{}) <-chan struct{} {
+	completed := make(chan struct{})
+	go func() {
+		defer close(completed)
+		<-stop
+		if err := j.printStatus(j.collector.LatestObservation()); err != nil {
+			panic(err)
+		}
+	}()
+	return completed
+}
+
+func (j *jsonPrinter) printStatus(data *collector.Observation) error {
+	out := jsonStatus{
+		AggregateStatus: data.AggregateStatus.String(),
+	}
+	if data.Error != nil {
+		out.Error = data.Error.Error()
+	}
+	if j.groupOptions.GroupBy == nil {
+		out.Resources = toJSONResourceStatuses(data.ResourceStatuses)
+	} else {
+		groups := aggregator.GroupResources(data.ResourceStatuses, j.groupOptions.DesiredStatus,
+			j.groupOptions.GroupBy)
+		for _, group := range groups {
+			counts := make(map[string]int)
+			for s, count := range group.Counts {
+				counts[s.String()] = count
+			}
+			out.Groups = append(out.Groups, jsonGroup{
+				Name:            group.Name,
+				AggregateStatus: group.AggregateStatus.String(),
+				Counts:          counts,
+				Resources:       toJSONResourceStatuses(group.Resources),
+			})
+		}
+	}
+	encoder := json.NewEncoder(j.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(out)
+}
+
+func toJSONResourceStatuses(resources []*event.ResourceStatus) []jsonResourceStatus {
+	var out []jsonResourceStatus
+	for _, r := range resources {
+		rs := jsonResourceStatus{
+			Group:              r.Identifier.GroupKind.Group,
+			Kind:               r.Identifier.GroupKind.Kind,
+			Namespace:          r.Identifier.Namespace,
+			Name:               r.Identifier.Name,
+			Status:             r.Status.String(),
+			Message:            r.Message,
+			GeneratedResources: toJSONResourceStatuses(r.GeneratedResources),
+		}
+		if r.Error != nil {
+			rs.Error = r.Error.Error()
+		}
+		out = append(out, rs)
+	}
+	return out
+}
@@ -9,16 +9,33 @@ import (
 
 	"sigs.k8s.io/cli-utils/cmd/status/print"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 )
 
+// GroupOptions configures how the printers group the resources.
+type GroupOptions struct {
+	// GroupBy returns the name of the group of a resource. If it is
+	// nil, the resources are not grouped.
+	GroupBy func(*event.ResourceStatus) string
+	// DesiredStatus is the status the resources should reach. It is
+	// used to compute the aggregate status of each group.
+	DesiredStatus status.Status
+	// Expand are the names of the groups whose resources are listed
+	// by the table printer. The other groups are only summarized.
+	Expand []string
+}
+
 // CreatePrinter return an implementation of the Printer interface. The
 // actual implementation is based on the printerType requested.
 func CreatePrinter(printerType string,
-	collector *collector.ResourceStatusCollector, w io.Writer) (print.Printer,
-	error) {
+	collector *collector.ResourceStatusCollector, w io.Writer,
+	groupOptions GroupOptions) (print.Printer, error) {
 	switch printerType {
 	case "table":
-		return NewTablePrinter(collector, w), nil
+		return NewTablePrinter(collector, w, groupOptions), nil
+	case "json":
+		return NewJSONPrinter(collector, w, groupOptions), nil
 	default:
 		return nil, fmt.Errorf("no printer available for output %q", printerType)
 	}
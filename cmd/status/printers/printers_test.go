@@ -0,0 +1,192 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package printers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func newObservation() *collector.Observation {
+	return &collector.Observation{
+		AggregateStatus: status.InProgressStatus,
+		ResourceStatuses: []*event.ResourceStatus{
+			{
+				Identifier: object.ObjMetadata{
+					GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+					Namespace: "default",
+					Name:      "frontend",
+				},
+				Status:  status.CurrentStatus,
+				Message: "Deployment is available. Replicas: 1",
+			},
+			{
+				Identifier: object.ObjMetadata{
+					GroupKind: schema.GroupKind{Kind: "Service"},
+					Namespace: "other",
+					Name:      "backend",
+				},
+				Status: status.InProgressStatus,
+			},
+			{
+				Identifier: object.ObjMetadata{
+					GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+					Namespace: "other",
+					Name:      "settings",
+				},
+				Status: status.CurrentStatus,
+			},
+		},
+	}
+}
+
+func byNamespace(r *event.ResourceStatus) string {
+	return r.Identifier.Namespace
+}
+
+func TestTablePrinterGroups(t *testing.T) {
+	testCases := map[string]struct {
+		expand         []string
+		expectedRows   []string
+		unexpectedRows []string
+	}{
+		"groups are summarized": {
+			expectedRows:   []string{"default", "other"},
+			unexpectedRows: []string{"Deployment/frontend", "Service/backend", "ConfigMap/settings"},
+		},
+		"expanded groups list their resources": {
+			expand:         []string{"other"},
+			expectedRows:   []string{"default", "other", "Service/backend", "ConfigMap/settings"},
+			unexpectedRows: []string{"Deployment/frontend"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var b bytes.Buffer
+			printer := NewTablePrinter(nil, &b, GroupOptions{
+				GroupBy:       byNamespace,
+				DesiredStatus: status.CurrentStatus,
+				Expand:        tc.expand,
+			})
+
+			lines := printer.printTable(newObservation(), 0)
+
+			output := b.String()
+			assert.Equal(t, strings.Count(output, "\n"), lines)
+			assert.Contains(t, output, "GROUP")
+			assert.Contains(t, output, "Current: 1, InProgress: 1")
+			for _, row := range tc.expectedRows {
+				assert.Contains(t, output, row)
+			}
+			for _, row := range tc.unexpectedRows {
+				assert.NotContains(t, output, row)
+			}
+		})
+	}
+}
+
+func TestJSONPrinter(t *testing.T) {
+	testCases := map[string]struct {
+		groupBy  func(*event.ResourceStatus) string
+		expected string
+	}{
+		"without groups": {
+			expected: `{
+  "aggregateStatus": "InProgress",
+  "resources": [
+    {
+      "group": "apps",
+      "kind": "Deployment",
+      "namespace": "default",
+      "name": "frontend",
+      "status": "Current",
+      "message": "Deployment is available. Replicas: 1"
+    },
+    {
+      "group": "",
+      "kind": "Service",
+      "namespace": "other",
+      "name": "backend",
+      "status": "InProgress"
+    },
+    {
+      "group": "",
+      "kind": "ConfigMap",
+      "namespace": "other",
+      "name": "settings",
+      "status": "Current"
+    }
+  ]
+}`,
+		},
+		"with groups": {
+			groupBy: byNamespace,
+			expected: `{
+  "aggregateStatus": "InProgress",
+  "groups": [
+    {
+      "name": "default",
+      "aggregateStatus": "Current",
+      "counts": {"Current": 1},
+      "resources": [
+        {
+          "group": "apps",
+          "kind": "Deployment",
+          "namespace": "default",
+          "name": "frontend",
+          "status": "Current",
+          "message": "Deployment is available. Replicas: 1"
+        }
+      ]
+    },
+    {
+      "name": "other",
+      "aggregateStatus": "InProgress",
+      "counts": {"Current": 1, "InProgress": 1},
+      "resources": [
+        {
+          "group": "",
+          "kind": "Service",
+          "namespace": "other",
+          "name": "backend",
+          "status": "InProgress"
+        },
+        {
+          "group": "",
+          "kind": "ConfigMap",
+          "namespace": "other",
+          "name": "settings",
+          "status": "Current"
+        }
+      ]
+    }
+  ]
+}`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var b bytes.Buffer
+			printer := NewJSONPrinter(nil, &b, GroupOptions{
+				GroupBy:       tc.groupBy,
+				DesiredStatus: status.CurrentStatus,
+			})
+
+			if !assert.NoError(t, printer.printStatus(newObservation())) {
+				return
+			}
+			assert.JSONEq(t, tc.expected, b.String())
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/utils/integer"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/aggregator"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/collector"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
@@ -222,18 +223,21 @@ var (
 // tablePrinter is an implementation of the Printer interface that outputs
 // status information about resources in a table format with in-place updates.
 type tablePrinter struct {
-	collector *collector.ResourceStatusCollector
-	w         io.Writer
+	collector    *collector.ResourceStatusCollector
+	w            io.Writer
+	groupOptions GroupOptions
 }
 
 // NewTablePrinter returns a new instance of the tablePrinter. The passed in
 // collector is the source of data to be printed, and the writer is where the
-// printer will send the output.
+// printer will send the output. If the groupOptions have a GroupBy function,
+// a row is printed for each group instead of each resource.
 func NewTablePrinter(collector *collector.ResourceStatusCollector,
-	w io.Writer) *tablePrinter {
+	w io.Writer, groupOptions GroupOptions) *tablePrinter {
 	return &tablePrinter{
-		collector: collector,
-		w:         w,
+		collector:    collector,
+		w:            w,
+		groupOptions: groupOptions,
 	}
 }
 
@@ -296,6 +300,10 @@ func (t *tablePrinter) printTable(data *collector.Observation,
 	t.printOrDie("Aggregate status: %s\n", aggStatusText)
 	linePrintCount++
 
+	if t.groupOptions.GroupBy != nil {
+		return linePrintCount + t.printGroupTable(data)
+	}
+
 	for i, column := range columns {
 		format := fmt.Sprintf("%%-%ds", column.width)
 		t.printOrDie(format, column.header)
@@ -329,6 +337,65 @@ func (t *tablePrinter) printTable(data *collector.Observation,
 	return linePrintCount
 }
 
+// printGroupTable prints a row for each group of resources, with the
+// aggregate status of the group and the number of resources with each
+// status. The resources in the expanded groups are listed below the
+// row for the group. It returns the number of lines printed.
+func (t *tablePrinter) printGroupTable(data *collector.Observation) int {
+	linePrintCount := 0
+	t.printOrDie("%-40s  %-10s  %-9s  %s\n", "GROUP", "STATUS", "RESOURCES", "COUNTS")
+	linePrintCount++
+
+	expand := make(map[string]bool)
+	for _, name := range t.groupOptions.Expand {
+		expand[name] = true
+	}
+	groups := aggregator.GroupResources(data.ResourceStatuses, t.groupOptions.DesiredStatus,
+		t.groupOptions.GroupBy)
+	for _, group := range groups {
+		name := group.Name
+		if len(name) > 40 {
+			name = name[:40]
+		}
+		s := group.AggregateStatus.String()
+		statusText := s
+		if color, setColor := colorForTableStatus(group.AggregateStatus); setColor {
+			statusText = sPrintWithColor(color, s)
+		}
+		t.printOrDie("%-40s  %s%s  %-9d  %s\n", name, statusText,
+			strings.Repeat(" ", integer.IntMax(10-len(s), 0)), len(group.Resources),
+			formatCounts(group.Counts))
+		linePrintCount++
+		if expand[group.Name] {
+			linePrintCount += t.printSubTable(group.Resources, "")
+		}
+	}
+	return linePrintCount
+}
+
+// formatCounts returns the number of resources with each status, for
+// the statuses that any of the resources have.
+func formatCounts(counts map[status.Status]int) string {
+	var parts []string
+	for _, s := range countedStatuses {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %d", s, counts[s]))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countedStatuses are the statuses that are counted for each group,
+// in the order they are printed.
+var countedStatuses = []status.Status{
+	status.CurrentStatus,
+	status.InProgressStatus,
+	status.FailedStatus,
+	status.TerminatingStatus,
+	status.NotFoundStatus,
+	status.UnknownStatus,
+}
+
 // printSubTable prints out any generated resources that belong to the
 // top-level resources. This function takes care of printing the correct tree
 // structure and indentation.
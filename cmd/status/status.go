@@ -5,10 +5,12 @@ package status
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/cli-utils/cmd/status/printers"
@@ -44,9 +46,18 @@ func GetStatusRunner() *StatusRunner {
 		"give up after n seconds. Default is 60 seconds.")
 	c.Flags().BoolVar(&r.PollUntilCanceled, "poll-until-cancelled", false,
 		"exit when all resources have fully reconciled.")
-	c.Flags().StringVar(&r.Output, "output", "table", "output format.")
+	c.Flags().StringVar(&r.Output, "output", "table",
+		"output format. One of table or json.")
 	c.Flags().BoolVar(&r.WaitForDeletion, "wait-for-deletion", false,
 		"wait for all resources to be deleted instead of reconciled.")
+	c.Flags().IntVar(&r.ErrorBudget, "error-budget", 5,
+		"number of consecutive times reading resources from the cluster can fail before giving up.")
+	c.Flags().BoolVar(&r.Drift, "drift", false,
+		"compare the manifests with the live resources instead of reporting status, and exit with an error if the fields managed by apply have drifted.")
+	c.Flags().StringVar(&r.GroupBy, "group-by", "",
+		"group the resources and report the aggregate status of each group. One of namespace, kind or label:KEY.")
+	c.Flags().StringSliceVar(&r.Expand, "expand", nil,
+		"names of the groups whose resources are listed in the table output when using --group-by.")
 
 	r.Command = c
 	return r
@@ -64,7 +75,11 @@ type StatusRunner struct {
 	Timeout            time.Duration
 	PollUntilCanceled  bool
 	WaitForDeletion    bool
+	ErrorBudget        int
+	Drift              bool
 	Output             string
+	GroupBy            string
+	Expand             []string
 	Command            *cobra.Command
 }
 
@@ -112,13 +127,9 @@ func (r *StatusRunner) runE(c *cobra.Command, args []string) error {
 		return errors.WrapPrefix(err, "error reading manifests", 1)
 	}
 
-	coll := collector.NewResourceStatusCollector(captureFilter.Identifiers)
-	stop := make(chan struct{})
-	printer, err := printers.CreatePrinter(r.Output, coll, c.OutOrStdout())
-	if err != nil {
-		return errors.WrapPrefix(err, "error creating printer", 1)
+	if r.Drift {
+		return r.runDrift(ctx, c, k8sClient, mapper, captureFilter)
 	}
-	printingFinished := printer.Print(stop)
 
 	var desiredStatus status.Status
 	if r.WaitForDeletion {
@@ -127,11 +138,28 @@ func (r *StatusRunner) runE(c *cobra.Command, args []string) error {
 		desiredStatus = status.CurrentStatus
 	}
 
+	groupBy, err := groupByFunc(r.GroupBy, captureFilter)
+	if err != nil {
+		return err
+	}
+	coll := collector.NewResourceStatusCollector(captureFilter.Identifiers)
+	stop := make(chan struct{})
+	printer, err := printers.CreatePrinter(r.Output, coll, c.OutOrStdout(), printers.GroupOptions{
+		GroupBy:       groupBy,
+		DesiredStatus: desiredStatus,
+		Expand:        r.Expand,
+	})
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating printer", 1)
+	}
+	printingFinished := printer.Print(stop)
+
 	eventChannel := poller.Poll(ctx, captureFilter.Identifiers, polling.Options{
 		PollUntilCancelled: r.PollUntilCanceled,
 		PollInterval:       r.Interval,
 		UseCache:           true,
 		DesiredStatus:      desiredStatus,
+		ErrorBudget:        r.ErrorBudget,
 	})
 	completed := coll.Listen(eventChannel, stop)
 
@@ -144,3 +172,33 @@ func (r *StatusRunner) runE(c *cobra.Command, args []string) error {
 	<-printingFinished
 	return nil
 }
+
+// runDrift compares the manifests with the live resources and prints
+// the fields that have drifted. It returns an error if any resource
+// has drifted, so it can be used to alert on changes made outside of
+// apply.
+func (r *StatusRunner) runDrift(ctx context.Context, c *cobra.Command, k8sClient client.Reader,
+	mapper meta.RESTMapper, captureFilter *CaptureIdentifiersFilter) error {
+	printer, err := printers.CreateDriftPrinter(r.Output, c.OutOrStdout())
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating printer", 1)
+	}
+	results, err := computeDrift(ctx, k8sClient, mapper, captureFilter)
+	if err != nil {
+		return errors.WrapPrefix(err, "error computing drift", 1)
+	}
+	if err := printer.Print(results); err != nil {
+		return err
+	}
+	drifted := 0
+	for _, result := range results {
+		if result.Drifted() {
+			drifted++
+		}
+	}
+	if drifted > 0 {
+		c.SilenceUsage = true
+		return fmt.Errorf("drift detected in %d resource(s)", drifted)
+	}
+	return nil
+}
@@ -15,10 +15,13 @@ import (
 
 // CaptureIdentifiersFilter implements the Filter interface in the kio
 // package. It captures the identifiers for all resources passed through
-// the pipeline.
+// the pipeline, and the manifests they were read from.
 type CaptureIdentifiersFilter struct {
 	Identifiers []object.ObjMetadata
-	Mapper      meta.RESTMapper
+	// Manifests contains the manifest of each of the Identifiers,
+	// at the same index.
+	Manifests []*yaml.RNode
+	Mapper    meta.RESTMapper
 }
 
 var _ kio.Filter = &CaptureIdentifiersFilter{}
@@ -39,6 +42,13 @@ func (f *CaptureIdentifiersFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode,
 			Group: gv.Group,
 			Kind:  id.Kind,
 		}
+		// We need to filter out grouping object templates, since there will
+		// never be an actual resource with that name and namespace. This is
+		// done before looking up the mapping, since the ClusterInventory
+		// kind might not be known to the cluster.
+		if isGroupingObject(gk, objectMeta.Labels) {
+			continue
+		}
 		mapping, err := f.Mapper.RESTMapping(gk)
 		if err != nil {
 			return nil, err
@@ -51,9 +61,7 @@ func (f *CaptureIdentifiersFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode,
 			namespace = id.Namespace
 		}
 		// We only want to add yaml that actually represents Kubernetes resources.
-		// We also need to filter out grouping object templates, since there will
-		// never be an actual resource with that name and namespace.
-		if isValidKubernetesResource(id) && !isGroupingObject(objectMeta.Labels) {
+		if isValidKubernetesResource(id) {
 			f.Identifiers = append(f.Identifiers, object.ObjMetadata{
 				Name:      id.Name,
 				Namespace: namespace,
@@ -62,6 +70,7 @@ func (f *CaptureIdentifiersFilter) Filter(slice []*yaml.RNode) ([]*yaml.RNode,
 					Kind:  id.Kind,
 				},
 			})
+			f.Manifests = append(f.Manifests, slice[i])
 		}
 	}
 	return slice, nil
@@ -73,9 +82,13 @@ func isValidKubernetesResource(id yaml.ResourceIdentifier) bool {
 	return id.GetKind() != "" && id.GetAPIVersion() != "" && id.GetName() != ""
 }
 
-// isGroupingObject checks if the provided map of labels contain the
-// inventory object label key.
-func isGroupingObject(labels map[string]string) bool {
+// isGroupingObject checks if the provided GroupKind is one of the kinds
+// used for inventory objects, and the map of labels contain the inventory
+// object label key.
+func isGroupingObject(gk schema.GroupKind, labels map[string]string) bool {
+	if gk != (schema.GroupKind{Kind: "ConfigMap"}) && gk != prune.ClusterInventoryGroupKind {
+		return false
+	}
 	for key := range labels {
 		if key == prune.GroupingLabel {
 			return true
@@ -0,0 +1,190 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"k8s.io/kubectl/pkg/cmd/util"
+	kubectlutil "k8s.io/kubectl/pkg/util"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/drift"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewAdopter returns a new Adopter. It will set up the ApplyOptions and
+// PruneOptions which are responsible for capturing any command line flags.
+func NewAdopter(factory util.Factory, ioStreams genericclioptions.IOStreams) *Adopter {
+	return &Adopter{
+		ApplyOptions: apply.NewApplyOptions(ioStreams),
+		PruneOptions: prune.NewPruneOptions(),
+		factory:      factory,
+		ioStreams:    ioStreams,
+	}
+}
+
+// Adopter creates the inventory for a package whose objects already
+// exist in the cluster, for example because they were created with
+// kubectl apply. The objects themselves are never modified, only the
+// grouping object is created. After that, the package can be applied
+// and pruned as usual.
+type Adopter struct {
+	factory      util.Factory
+	ioStreams    genericclioptions.IOStreams
+	ApplyOptions *apply.ApplyOptions
+	PruneOptions *prune.PruneOptions
+	client       dynamic.Interface
+
+	// DryRun computes the report without creating the grouping object.
+	DryRun bool
+}
+
+// AdoptedObject is an object from the package that exists in the
+// cluster and is tracked by the new inventory.
+type AdoptedObject struct {
+	Identifier object.ObjMetadata
+	// Drift are the fields where the live object differs from
+	// the local manifest. These fields will be changed by the
+	// next apply.
+	Drift []drift.FieldDiff
+}
+
+// AdoptionReport describes the result of adopting a package.
+type AdoptionReport struct {
+	// Adopted are the objects that are tracked by the new inventory.
+	Adopted []AdoptedObject
+	// Missing are the objects in the package that do not exist in
+	// the cluster. They are not tracked, and will be created by the
+	// next apply.
+	Missing []object.ObjMetadata
+	// Inventory identifies the grouping object that was created.
+	Inventory object.ObjMetadata
+}
+
+// Initialize sets up the Adopter for reading the package and the live
+// objects from the cluster. This involves validating command line
+// inputs and configuring clients for communicating with the cluster.
+func (a *Adopter) Initialize(cmd *cobra.Command, paths []string) error {
+	fileNameFlags, err := demandOneDirectory(paths)
+	if err != nil {
+		return err
+	}
+	a.ApplyOptions.DeleteFlags.FileNameFlags = &fileNameFlags
+	err = a.ApplyOptions.Complete(a.factory, cmd)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
+	}
+	err = a.PruneOptions.Initialize(a.factory)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+	a.client, err = a.factory.DynamicClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating dynamic client", 1)
+	}
+	return nil
+}
+
+// SetFlags configures the command line flags needed for adopt.
+// This is a temporary solution as we should separate the configuration
+// of cobra flags from the Adopter.
+func (a *Adopter) SetFlags(cmd *cobra.Command) error {
+	return addPackageFlags(cmd, a.ApplyOptions.DeleteFlags)
+}
+
+// Run looks up the live object for every object in the package, and
+// creates a grouping object that tracks the ones that exist. It refuses
+// to adopt a package that already has an inventory in the cluster.
+func (a *Adopter) Run() (*AdoptionReport, error) {
+	infos, err := a.ApplyOptions.GetObjects()
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error reading resource manifests", 1)
+	}
+	resources, gots := splitInfos(infos)
+	sort.Sort(ResourceInfos(resources))
+	prepared, err := prepareObjects(infos)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := a.PruneOptions.RetrieveGroupingObjects(prepared)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error retrieving inventory", 1)
+	}
+	if len(existing) > 0 {
+		return nil, errors.Errorf("package already has an inventory in the cluster: %s",
+			existing[0].Name)
+	}
+
+	report := &AdoptionReport{}
+	adopted := []*resource.Info{}
+	for _, info := range resources {
+		local := info.Object.(*unstructured.Unstructured)
+		id := object.ObjMetadata{
+			GroupKind: local.GroupVersionKind().GroupKind(),
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		}
+		live, err := a.client.Resource(info.Mapping.Resource).Namespace(info.Namespace).
+			Get(info.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			report.Missing = append(report.Missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error fetching "+info.Name, 1)
+		}
+		report.Adopted = append(report.Adopted, AdoptedObject{
+			Identifier: id,
+			Drift:      drift.Compare(local, live),
+		})
+		adopted = append(adopted, info)
+	}
+
+	if len(adopted) == 0 {
+		return nil, errors.Errorf("none of the objects in the package exist in the cluster")
+	}
+
+	// The grouping object created by prepareObjects tracks all the
+	// objects in the package, so create a new one from the template
+	// that only tracks the adopted objects.
+	template := gots[0]
+	groupingObj := template.Object.(*unstructured.Unstructured).DeepCopy()
+	inventoryInfo := &resource.Info{
+		Mapping:   template.Mapping,
+		Namespace: template.Namespace,
+		Name:      groupingObj.GetName(),
+		Object:    groupingObj,
+	}
+	if err := prune.AddInventoryToGroupingObj(append([]*resource.Info{inventoryInfo}, adopted...)); err != nil {
+		return nil, err
+	}
+	report.Inventory = object.ObjMetadata{
+		GroupKind: groupingObj.GroupVersionKind().GroupKind(),
+		Namespace: inventoryInfo.Namespace,
+		Name:      groupingObj.GetName(),
+	}
+	if a.DryRun {
+		return report, nil
+	}
+	// Store the configuration the same way as kubectl apply does, so the
+	// next apply can update the grouping object without warnings.
+	if err := kubectlutil.CreateApplyAnnotation(groupingObj, unstructured.UnstructuredJSONScheme); err != nil {
+		return nil, err
+	}
+	_, err = a.client.Resource(inventoryInfo.Mapping.Resource).Namespace(inventoryInfo.Namespace).
+		Create(groupingObj, metav1.CreateOptions{})
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error creating inventory", 1)
+	}
+	return report, nil
+}
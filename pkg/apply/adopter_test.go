@@ -0,0 +1,151 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/drift"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+var (
+	podsGVR       = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	configMapsGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+)
+
+func podInfo(name, image string) *resource.Info {
+	return &resource.Info{
+		Namespace: namespace,
+		Name:      name,
+		Mapping: &meta.RESTMapping{
+			Resource: podsGVR,
+			Scope:    meta.RESTScopeNamespace,
+		},
+		Object: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Pod",
+				"metadata": map[string]interface{}{
+					"name":      name,
+					"namespace": namespace,
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": image,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestAdopterRun(t *testing.T) {
+	template := &resource.Info{
+		Namespace: namespace,
+		Name:      "inventory",
+		Mapping: &meta.RESTMapping{
+			Resource: configMapsGVR,
+			Scope:    meta.RESTScopeNamespace,
+		},
+		Object: &unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata": map[string]interface{}{
+					"name":      "inventory",
+					"namespace": namespace,
+					"labels": map[string]interface{}{
+						prune.GroupingLabel: "test-app-label",
+					},
+				},
+			},
+		},
+	}
+	// pod-1 exists with a different image, pod-2 exists unchanged,
+	// and pod-3 does not exist yet.
+	pod1, pod2, pod3 := podInfo("pod-1", "app:v2"), podInfo("pod-2", "app:v1"), podInfo("pod-3", "app:v1")
+	livePods := []runtime.Object{
+		podInfo("pod-1", "app:v1").Object,
+		podInfo("pod-2", "app:v1").Object,
+	}
+
+	testCases := map[string]struct {
+		dryRun bool
+	}{
+		"adopt": {
+			dryRun: false,
+		},
+		"adopt with dry-run": {
+			dryRun: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			tf.UnstructuredClient = newFakeRESTClient(t, []handler{&groupingObjectHandler{}})
+			dynamicClient := fakedynamic.NewSimpleDynamicClient(scheme.Scheme, livePods...)
+			tf.FakeDynamicClient = dynamicClient
+
+			adopter := NewAdopter(tf, genericclioptions.NewTestIOStreamsDiscard())
+			adopter.DryRun = tc.dryRun
+			assert.NoError(t, adopter.PruneOptions.Initialize(tf))
+			adopter.client = dynamicClient
+			adopter.ApplyOptions.SetObjects([]*resource.Info{pod3, template, pod2, pod1})
+
+			report, err := adopter.Run()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			podGK := schema.GroupKind{Kind: "Pod"}
+			assert.Equal(t, []AdoptedObject{
+				{
+					Identifier: object.ObjMetadata{GroupKind: podGK, Namespace: namespace, Name: "pod-1"},
+					Drift: []drift.FieldDiff{
+						{Path: ".spec.containers[0].image", Local: "app:v2", Live: "app:v1"},
+					},
+				},
+				{
+					Identifier: object.ObjMetadata{GroupKind: podGK, Namespace: namespace, Name: "pod-2"},
+				},
+			}, report.Adopted)
+			assert.Equal(t, []object.ObjMetadata{
+				{GroupKind: podGK, Namespace: namespace, Name: "pod-3"},
+			}, report.Missing)
+
+			created, err := dynamicClient.Resource(configMapsGVR).Namespace(namespace).
+				Get(report.Inventory.Name, metav1.GetOptions{})
+			if tc.dryRun {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			inv, err := prune.RetrieveInventoryFromGroupingObj([]*resource.Info{{Object: created}})
+			assert.NoError(t, err)
+			assert.Len(t, inv, 2)
+			assert.Contains(t, created.GetAnnotations(), v1.LastAppliedConfigAnnotation)
+		})
+	}
+}
@@ -5,12 +5,10 @@ package apply
 
 import (
 	"context"
-	"fmt"
 	"sort"
 
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -25,6 +23,7 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
 	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -39,6 +38,8 @@ func NewApplier(factory util.Factory, ioStreams genericclioptions.IOStreams) *Ap
 		ApplyOptions:  apply.NewApplyOptions(ioStreams),
 		StatusOptions: NewStatusOptions(),
 		PruneOptions:  prune.NewPruneOptions(),
+		RetryPolicy:   retry.DefaultPolicy(),
+		GracePeriod:   -1,
 		factory:       factory,
 		ioStreams:     ioStreams,
 	}
@@ -58,25 +59,99 @@ type Applier struct {
 	factory   util.Factory
 	ioStreams genericclioptions.IOStreams
 
+	// kustomizations are the kustomization roots that are built and
+	// applied together with the files and directories in ApplyOptions.
+	kustomizations []string
+
 	ApplyOptions  *apply.ApplyOptions
 	StatusOptions *StatusOptions
 	PruneOptions  *prune.PruneOptions
 	statusPoller  poller.Poller
 
+	// Source provides the manifests to apply. If it is nil, they are
+	// read from the paths given to Initialize.
+	Source ManifestSource
+	// Plan is a plan created by CreatePlan. If it is set, the resources
+	// in the plan are applied instead of the ones from the Source or the
+	// paths, but only if the cluster hasn't changed since the plan was
+	// created.
+	Plan *Plan
+
 	NoPrune bool
 	DryRun  bool
+	// ServerDryRun applies and prunes the resources with dry-run
+	// requests to the server, so they go through validation and
+	// admission webhooks without being persisted. Every resource the
+	// server rejects is reported with an event, and the fields set by
+	// the server are reported for the others.
+	ServerDryRun bool
+	// Concurrency is the maximum number of resources that will be
+	// applied at the same time. Resources are still applied in
+	// order, so only resources of kinds that don't need to be
+	// applied before one another are applied concurrently.
+	Concurrency int
+	// RetryPolicy decides which errors from the cluster will be
+	// retried when applying and pruning resources, and when polling
+	// for status.
+	RetryPolicy *retry.Policy
+	// PropagationPolicy is the propagation policy for the pruned
+	// resources: Foreground, Background or Orphan. If it is empty,
+	// the default policy of each resource is used.
+	PropagationPolicy string
+	// GracePeriod is the grace period in seconds for the pruned
+	// resources. If it is negative, the default grace period of each
+	// resource is used. A grace period of 0 is changed to 1 unless
+	// Force is set.
+	GracePeriod int
+	// Force removes the pruned resources from the API immediately when
+	// the GracePeriod is 0, bypassing graceful deletion.
+	Force bool
 }
 
 // Initialize sets up the Applier for actually doing an apply against
 // a cluster. This involves validating command line inputs and configuring
 // clients for communicating with the cluster.
+//
+// The paths can be any number of files, directories and kustomization
+// roots, or "-" to read from StdIn. They are combined with the
+// --filename and --kustomize flags, and the grouping object template
+// can be in any of them. The paths are ignored if the Applier has a
+// Source or a Plan.
 func (a *Applier) Initialize(cmd *cobra.Command, paths []string) error {
-	fileNameFlags, err := demandOneDirectory(paths)
+	fileNameFlags, kustomizations := processInputs(paths, a.ApplyOptions.DeleteFlags.FileNameFlags)
+	a.kustomizations = kustomizations
+	a.ApplyOptions.RecordFlags.Complete(cmd)
+	opts, err := commandOptionsFromFlags(a.factory, cmd)
 	if err != nil {
-		return err
+		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
 	}
-	a.ApplyOptions.DeleteFlags.FileNameFlags = &fileNameFlags
-	err = a.ApplyOptions.Complete(a.factory, cmd)
+	opts.fileNameFlags = &fileNameFlags
+	return a.complete(opts)
+}
+
+// Copy returns a new Applier with the same settings as this one, and
+// bound to the same command line flags, but without any of the state
+// from initializing or running it. It must be initialized before it
+// is run. This allows applying the resources again, since the
+// ApplyOptions and PruneOptions can only be used for a single run.
+func (a *Applier) Copy() *Applier {
+	c := *a
+	c.ApplyOptions = apply.NewApplyOptions(a.ioStreams)
+	c.ApplyOptions.DeleteFlags = a.ApplyOptions.DeleteFlags
+	c.ApplyOptions.RecordFlags = a.ApplyOptions.RecordFlags
+	c.ApplyOptions.PrintFlags = a.ApplyOptions.PrintFlags
+	c.ApplyOptions.Overwrite = a.ApplyOptions.Overwrite
+	c.PruneOptions = prune.NewPruneOptions()
+	c.statusPoller = nil
+	c.kustomizations = nil
+	return &c
+}
+
+// complete sets up the ApplyOptions with the commandOptions, and the
+// rest of the Applier from its settings. It is shared by Initialize
+// and NewApplierFromOptions.
+func (a *Applier) complete(opts commandOptions) error {
+	err := completeApplyOptions(a.ApplyOptions, a.factory, opts)
 	if err != nil {
 		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
 	}
@@ -87,10 +162,19 @@ func (a *Applier) Initialize(cmd *cobra.Command, paths []string) error {
 	}
 
 	// Propagate dry-run flags.
+	if a.DryRun && a.ServerDryRun {
+		return errors.New("client and server dry-run can't be used together")
+	}
 	a.ApplyOptions.DryRun = a.DryRun
+	a.ApplyOptions.ServerDryRun = a.ServerDryRun
 	a.PruneOptions.DryRun = a.DryRun
+	a.PruneOptions.ServerDryRun = a.ServerDryRun
+	a.PruneOptions.RetryPolicy = a.RetryPolicy
+	if err := setDeletionOptions(a.PruneOptions, a.PropagationPolicy, a.GracePeriod, a.Force); err != nil {
+		return err
+	}
 
-	statusPoller, err := a.newStatusPoller()
+	statusPoller, err := newStatusPoller(a.factory)
 	if err != nil {
 		return errors.WrapPrefix(err, "error creating resolver", 1)
 	}
@@ -98,38 +182,76 @@ func (a *Applier) Initialize(cmd *cobra.Command, paths []string) error {
 	return nil
 }
 
+// Paths returns the files, directories and kustomization roots the
+// resources are read from, once the Applier has been initialized. A
+// path of "-" means StdIn.
+func (a *Applier) Paths() []string {
+	paths := append([]string{}, a.ApplyOptions.DeleteOptions.FilenameOptions.Filenames...)
+	return append(paths, a.kustomizations...)
+}
+
 // SetFlags configures the command line flags needed for apply and
 // status. This is a temporary solution as we should separate the configuration
 // of cobra flags from the Applier.
 func (a *Applier) SetFlags(cmd *cobra.Command) error {
-	a.ApplyOptions.DeleteFlags.AddFlags(cmd)
-	for _, flag := range []string{"kustomize", "filename", "recursive"} {
-		err := cmd.Flags().MarkHidden(flag)
-		if err != nil {
-			return err
-		}
+	// Directories are read recursively unless --recursive=false is given.
+	if fileNameFlags := a.ApplyOptions.DeleteFlags.FileNameFlags; fileNameFlags != nil && fileNameFlags.Recursive != nil {
+		*fileNameFlags.Recursive = true
 	}
+	// The --grace-period and --force flags of kubectl set the grace
+	// period for pruning.
+	a.ApplyOptions.DeleteFlags.GracePeriod = &a.GracePeriod
+	a.ApplyOptions.DeleteFlags.Force = &a.Force
+	a.ApplyOptions.DeleteFlags.AddFlags(cmd)
 	a.ApplyOptions.RecordFlags.AddFlags(cmd)
 	_ = cmd.Flags().MarkHidden("record")
 	_ = cmd.Flags().MarkHidden("cascade")
-	_ = cmd.Flags().MarkHidden("force")
-	_ = cmd.Flags().MarkHidden("grace-period")
+	cmd.Flags().StringVar(&a.PropagationPolicy, "propagation-policy", a.PropagationPolicy,
+		"Propagation policy for pruned resources: Foreground, Background or Orphan. "+
+			"Defaults to the policy of each resource.")
 	_ = cmd.Flags().MarkHidden("timeout")
 	_ = cmd.Flags().MarkHidden("wait")
 	a.StatusOptions.AddFlags(cmd)
+	if a.RetryPolicy != nil {
+		cmd.Flags().IntVar(&a.RetryPolicy.Attempts, "retry-attempts", a.RetryPolicy.Attempts,
+			"The number of times a request that fails with a transient error will be attempted.")
+		cmd.Flags().DurationVar(&a.RetryPolicy.InitialBackoff, "retry-backoff", a.RetryPolicy.InitialBackoff,
+			"How long to wait before the first retry of a failed request. Doubles for every retry.")
+	}
 	a.ApplyOptions.Overwrite = true
 	return nil
 }
 
+// setDeletionOptions validates the propagation policy and sets it and
+// the grace period on the PruneOptions. Like kubectl, a grace period
+// of 0 is changed to 1 unless force is set, to prevent accidental
+// data loss.
+func setDeletionOptions(po *prune.PruneOptions, propagationPolicy string, gracePeriod int, force bool) error {
+	policy, err := prune.ParsePropagationPolicy(propagationPolicy)
+	if err != nil {
+		return err
+	}
+	po.PropagationPolicy = policy
+	po.GracePeriodSeconds = nil
+	if gracePeriod == 0 && !force {
+		gracePeriod = 1
+	}
+	if gracePeriod >= 0 {
+		seconds := int64(gracePeriod)
+		po.GracePeriodSeconds = &seconds
+	}
+	return nil
+}
+
 // newStatusPoller sets up a new StatusPoller for computing status. The configuration
 // needed for the poller is taken from the Factory.
-func (a *Applier) newStatusPoller() (poller.Poller, error) {
-	config, err := a.factory.ToRESTConfig()
+func newStatusPoller(factory util.Factory) (poller.Poller, error) {
+	config, err := factory.ToRESTConfig()
 	if err != nil {
 		return nil, errors.WrapPrefix(err, "error getting RESTConfig", 1)
 	}
 
-	mapper, err := a.factory.ToRESTMapper()
+	mapper, err := factory.ToRESTMapper()
 	if err != nil {
 		return nil, errors.WrapPrefix(err, "error getting RESTMapper", 1)
 	}
@@ -145,11 +267,76 @@ func (a *Applier) newStatusPoller() (poller.Poller, error) {
 // readAndPrepareObjects reads the resources that should be applied,
 // handles ordering of resources and sets up the grouping object
 // based on the provided grouping object template.
+//
+// The resources can span several namespaces and include cluster-scoped
+// objects. The grouping object is stored in the namespace of its
+// template, or the default namespace if the template doesn't set one.
+// It is ordered right after any Namespace objects, so a package can
+// create the namespace that holds its own grouping object.
 func (a *Applier) readAndPrepareObjects() ([]*resource.Info, error) {
-	infos, err := a.ApplyOptions.GetObjects()
+	// The resources in a plan are already prepared.
+	if a.Plan != nil {
+		return a.readPlannedObjects()
+	}
+	infos, err := a.readObjects()
 	if err != nil {
 		return nil, err
 	}
+	return prepareObjects(infos)
+}
+
+// readObjects reads the resources from the Source if there is one.
+// Otherwise they are read from the files and directories in the
+// ApplyOptions, and from the output of each of the kustomization roots.
+// The kubectl builder only handles a single kustomization root, and not
+// together with files, so each input is read separately. The combined
+// resources are set on the ApplyOptions.
+func (a *Applier) readObjects() ([]*resource.Info, error) {
+	if a.Source != nil {
+		objs, err := a.Source.Objects()
+		if err != nil {
+			return nil, err
+		}
+		infos, err := objectsToInfos(a.factory, objs, a.ApplyOptions.Namespace, a.ApplyOptions.EnforceNamespace)
+		if err != nil {
+			return nil, err
+		}
+		a.ApplyOptions.SetObjects(infos)
+		return infos, nil
+	}
+	var infos []*resource.Info
+	if len(a.kustomizations) == 0 || len(a.ApplyOptions.DeleteOptions.FilenameOptions.Filenames) > 0 {
+		objs, err := a.ApplyOptions.GetObjects()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, objs...)
+	}
+	for _, k := range a.kustomizations {
+		r := a.factory.NewBuilder().
+			Unstructured().
+			Schema(a.ApplyOptions.Validator).
+			ContinueOnError().
+			NamespaceParam(a.ApplyOptions.Namespace).DefaultNamespace().
+			FilenameParam(a.ApplyOptions.EnforceNamespace, &resource.FilenameOptions{Kustomize: k}).
+			LabelSelectorParam(a.ApplyOptions.Selector).
+			Flatten().
+			Do()
+		objs, err := r.Infos()
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error reading kustomization "+k, 1)
+		}
+		infos = append(infos, objs...)
+	}
+	if len(a.kustomizations) > 0 {
+		a.ApplyOptions.SetObjects(infos)
+	}
+	return infos, nil
+}
+
+// prepareObjects handles ordering of the resources and sets up the
+// grouping object based on the grouping object template among them.
+func prepareObjects(infos []*resource.Info) ([]*resource.Info, error) {
 	resources, gots := splitInfos(infos)
 
 	if len(gots) == 0 {
@@ -168,11 +355,14 @@ func (a *Applier) readAndPrepareObjects() ([]*resource.Info, error) {
 
 	sort.Sort(ResourceInfos(resources))
 
-	if !validateNamespace(resources) {
-		return nil, fmt.Errorf("objects have differing namespaces")
+	i := 0
+	for i < len(resources) && resources[i].Object.GetObjectKind().GroupVersionKind().Kind == "Namespace" {
+		i++
 	}
-
-	return append([]*resource.Info{groupingObject}, resources...), nil
+	infos = make([]*resource.Info, 0, len(resources)+1)
+	infos = append(infos, resources[:i]...)
+	infos = append(infos, groupingObject)
+	return append(infos, resources[i:]...), nil
 }
 
 // splitInfos takes a slice of resource.Info objects and splits it
@@ -192,95 +382,98 @@ func splitInfos(infos []*resource.Info) ([]*resource.Info, []*resource.Info) {
 	return resources, groupingObjectTemplates
 }
 
-// buildTaskQueue takes the slice of infos and object identifiers, and
-// builds a queue of tasks that needs to be executed.
-func (a *Applier) buildTaskQueue(infos []*resource.Info, identifiers []object.ObjMetadata,
-	eventChannel chan event.Event) chan taskrunner.Task {
-	tasks := []taskrunner.Task{
-		// This taks is responsible for applying all the resources
-		// in the infos slice.
-		&task.ApplyTask{
-			Objects:      infos,
-			ApplyOptions: a.ApplyOptions,
-		},
+// buildTaskGraph takes the slice of infos, and builds a graph of
+// tasks that needs to be executed.
+func (a *Applier) buildTaskGraph(ctx context.Context, infos []*resource.Info,
+	eventChannel chan event.Event) (*taskrunner.TaskGraph, error) {
+	return a.newTaskQueueBuilder(ctx, infos, eventChannel).BuildGraph()
+}
+
+// newTaskQueueBuilder returns a TaskQueueBuilder that has been populated
+// with the tasks needed to apply the infos, and depending on the
+// settings of the Applier, wait for the resources to be reconciled
+// and prune any resources that are no longer part of the set.
+// The apply and prune tasks will stop if the ctx is cancelled.
+func (a *Applier) newTaskQueueBuilder(ctx context.Context, infos []*resource.Info,
+	eventChannel chan event.Event) *task.TaskQueueBuilder {
+	b := task.NewTaskQueueBuilder(eventChannel).
+		WithContext(ctx).
+		WithApplyConcurrency(a.Concurrency).
+		WithRetryPolicy(a.RetryPolicy).
+		WithReportApplyErrors(a.ServerDryRun)
+	// The resources in a plan are checked again right before they
+	// are applied, in case they changed after the plan was verified.
+	if a.Plan != nil {
+		b.WithApplyPrecondition(a.checkPlannedVersion)
+	}
+	// These tasks are responsible for applying all the resources
+	// in the infos slice. The resources in different namespaces
+	// are applied at the same time, once the cluster-scoped ones
+	// and the grouping object have been applied.
+	b.AppendApplyBranches(infos, a.ApplyOptions).
 		// When all resources have been applied, we need to send
 		// an event that notifies the client that the apply phase
 		// is complete.
-		&task.SendEventTask{
-			Event: event.Event{
-				Type: event.ApplyType,
-				ApplyEvent: event.ApplyEvent{
-					Type: event.ApplyEventCompleted,
-				},
+		AppendSendEventTask(event.Event{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type: event.ApplyEventCompleted,
 			},
-			EventChannel: eventChannel,
-		},
-	}
+		})
 
-	if a.StatusOptions.wait {
-		tasks = append(tasks,
-			// The wait task declares that after applying the resources,
-			// we should wait for all of them to reach the Current status
-			// before continuing.
-			taskrunner.NewWaitTask(identifiers, taskrunner.AllCurrent,
-				a.StatusOptions.Timeout),
+	// Nothing is persisted in a server-side dry-run, so there is
+	// nothing to wait for.
+	if a.StatusOptions.wait && !a.ServerDryRun {
+		// The wait tasks declare that after applying the resources,
+		// we should wait for all of them to reach the Current status
+		// before continuing. There is one wait task for each of the
+		// apply tasks, which starts as soon as its resources have
+		// been applied.
+		b.AppendBranchWaitTasks(taskrunner.AllCurrent, a.StatusOptions.Timeout).
 			// When all resources have reached the desired status, we
 			// send an event to notify the client.
-			&task.SendEventTask{
-				Event: event.Event{
-					Type: event.StatusType,
-					StatusEvent: pollevent.Event{
-						EventType: pollevent.CompletedEvent,
-					},
+			AppendSendEventTask(event.Event{
+				Type: event.StatusType,
+				StatusEvent: pollevent.Event{
+					EventType: pollevent.CompletedEvent,
 				},
-				EventChannel: eventChannel,
 			})
 	}
 
-	if !a.NoPrune {
-		tasks = append(tasks,
-			// The prune task is responsible for doing the pruning
-			// of any deleted resources.
-			&task.PruneTask{
-				Objects:      infos,
-				PruneOptions: a.PruneOptions,
-				EventChannel: eventChannel,
-			},
+	if !a.NoPrune && (a.Plan == nil || !a.Plan.NoPrune) {
+		// The prune task is responsible for doing the pruning
+		// of any deleted resources.
+		b.AppendPruneTask(infos, a.PruneOptions).
 			// Once prune is completed, we send an event to notify
 			// the client.
-			&task.SendEventTask{
-				Event: event.Event{
-					Type: event.PruneType,
-					PruneEvent: event.PruneEvent{
-						Type: event.PruneEventCompleted,
-					},
+			AppendSendEventTask(event.Event{
+				Type: event.PruneType,
+				PruneEvent: event.PruneEvent{
+					Type: event.PruneEventCompleted,
 				},
-				EventChannel: eventChannel,
 			})
 	}
-
-	taskQueue := make(chan taskrunner.Task, len(tasks))
-	for _, t := range tasks {
-		taskQueue <- t
-	}
-	return taskQueue
+	return b
 }
 
 // Run performs the Apply step. This happens asynchronously with updates
 // on progress and any errors are reported back on the event channel.
 // Cancelling the operation or setting timeout on how long to wait
 // for it complete can be done with the passed in context.
-// Note: There sn't currently any way to interrupt the operation
-// before all the given resources have been applied to the cluster. Any
-// cancellation or timeout will only affect how long we wait for the
-// resources to become current.
+// If the context is cancelled, the apply and prune steps stop before
+// the next resource is processed, and an event is sent for each of
+// the resources that were not applied or pruned. The previous
+// grouping objects are only deleted once all resources have been
+// pruned, so a later apply will prune any resources that were
+// skipped.
 func (a *Applier) Run(ctx context.Context) <-chan event.Event {
 	eventChannel := make(chan event.Event)
 
 	go func() {
 		defer close(eventChannel)
 		adapter := &KubectlPrinterAdapter{
-			ch: eventChannel,
+			ch:              eventChannel,
+			reportDefaulted: a.ServerDryRun,
 		}
 		// The adapter is used to intercept what is meant to be printing
 		// in the ApplyOptions, and instead turn those into events.
@@ -300,6 +493,35 @@ func (a *Applier) Run(ctx context.Context) <-chan event.Event {
 			return
 		}
 
+		// A plan is only applied if nothing it changes has been
+		// changed in the cluster since it was created.
+		if a.Plan != nil {
+			if err := a.verifyPlan(infos); err != nil {
+				eventChannel <- event.Event{
+					Type: event.ErrorType,
+					ErrorEvent: event.ErrorEvent{
+						Err: errors.WrapPrefix(err, "error verifying plan", 1),
+					},
+				}
+				return
+			}
+		}
+
+		// For a preview, the live resources are fetched up front so
+		// the events can report what applying each of them would do.
+		if a.DryRun || a.ServerDryRun {
+			adapter.preview, err = previewStates(infos)
+			if err != nil {
+				eventChannel <- event.Event{
+					Type: event.ErrorType,
+					ErrorEvent: event.ErrorEvent{
+						Err: errors.WrapPrefix(err, "error reading live resources", 1),
+					},
+				}
+				return
+			}
+		}
+
 		// Extract the object metadata needed to identify each
 		// of the resources. This is just a lightweight representation
 		// of the resources in the infos struct. The status library
@@ -307,8 +529,17 @@ func (a *Applier) Run(ctx context.Context) <-chan event.Event {
 		// both.
 		identifiers := infosToObjMetas(infos)
 
-		// Fetch the queue (channel) of tasks that should be executed.
-		taskQueue := a.buildTaskQueue(infos, identifiers, eventChannel)
+		// Fetch the graph of tasks that should be executed.
+		taskGraph, err := a.buildTaskGraph(ctx, infos, eventChannel)
+		if err != nil {
+			eventChannel <- event.Event{
+				Type: event.ErrorType,
+				ErrorEvent: event.ErrorEvent{
+					Err: err,
+				},
+			}
+			return
+		}
 
 		// Send event to inform the caller about the resources that
 		// will be applied/pruned.
@@ -324,11 +555,13 @@ func (a *Applier) Run(ctx context.Context) <-chan event.Event {
 			},
 		}
 
-		// Create a new TaskStatusRunner to execute the taskQueue.
+		// Create a new TaskStatusRunner to execute the taskGraph.
 		runner := taskrunner.NewTaskStatusRunner(identifiers, a.statusPoller)
-		err = runner.Run(ctx, taskQueue, eventChannel, taskrunner.PollingOptions{
+		err = runner.RunGraph(ctx, taskGraph, eventChannel, taskrunner.PollingOptions{
 			PollInterval: a.StatusOptions.period,
 			UseCache:     true,
+			RetryPolicy:  a.RetryPolicy,
+			ErrorBudget:  a.StatusOptions.ErrorBudget,
 		})
 		if err != nil {
 			eventChannel <- event.Event{
@@ -357,23 +590,3 @@ func infosToObjMetas(infos []*resource.Info) []object.ObjMetadata {
 	}
 	return objMetas
 }
-
-// validateNamespace returns true if all the objects in the passed
-// infos parameter have the same namespace; false otherwise. Ignores
-// cluster-scoped resources.
-func validateNamespace(infos []*resource.Info) bool {
-	currentNamespace := metav1.NamespaceNone
-	for _, info := range infos {
-		// Ignore cluster-scoped resources.
-		if info.Namespaced() {
-			// If the current namespace has not been set--then set it.
-			if currentNamespace == metav1.NamespaceNone {
-				currentNamespace = info.Namespace
-			}
-			if currentNamespace != info.Namespace {
-				return false
-			}
-		}
-	}
-	return true
-}
@@ -20,6 +20,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -96,6 +97,7 @@ func TestApplier(t *testing.T) {
 		handlers           []handler
 		status             bool
 		prune              bool
+		concurrency        int
 		statusEvents       []pollevent.Event
 		expectedEventTypes []expectedEvent
 	}{
@@ -133,6 +135,79 @@ func TestApplier(t *testing.T) {
 				},
 			},
 		},
+		"apply with retry": {
+			namespace: "apply-test",
+			resources: []resourceInfo{
+				resources["deployment"],
+				resources["groupingObject"],
+			},
+			handlers: []handler{
+				&nsHandler{},
+				&groupingObjectHandler{},
+				&genericHandler{
+					resourceInfo: resources["deployment"],
+					namespace:    "apply-test",
+					failPatches:  1,
+				},
+			},
+			status: false,
+			prune:  false,
+			expectedEventTypes: []expectedEvent{
+				{
+					eventType: event.InitType,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType: event.RetryType,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventCompleted,
+				},
+			},
+		},
+		"apply with concurrency": {
+			namespace: "apply-test",
+			resources: []resourceInfo{
+				resources["deployment"],
+				resources["groupingObject"],
+			},
+			handlers: []handler{
+				&nsHandler{},
+				&groupingObjectHandler{},
+				&genericHandler{
+					resourceInfo: resources["deployment"],
+					namespace:    "apply-test",
+				},
+			},
+			status:      false,
+			prune:       false,
+			concurrency: 4,
+			expectedEventTypes: []expectedEvent{
+				{
+					eventType: event.InitType,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventResourceUpdate,
+				},
+				{
+					eventType:      event.ApplyType,
+					applyEventType: event.ApplyEventCompleted,
+				},
+			},
+		},
 		"first apply with grouping object": {
 			namespace: "apply-test",
 			resources: []resourceInfo{
@@ -236,6 +311,8 @@ func TestApplier(t *testing.T) {
 			applier.StatusOptions.period = 2 * time.Second
 			applier.StatusOptions.wait = tc.status
 			applier.NoPrune = !tc.prune
+			applier.Concurrency = tc.concurrency
+			applier.RetryPolicy.InitialBackoff = time.Millisecond
 
 			cmd := &cobra.Command{}
 			_ = applier.SetFlags(cmd)
@@ -280,6 +357,8 @@ func TestApplier(t *testing.T) {
 					assert.Equal(t, expected.pruneEventType.String(), e.PruneEvent.Type.String())
 				case event.DeleteType:
 					assert.Equal(t, expected.deleteEventType.String(), e.DeleteEvent.Type.String())
+				case event.RetryType:
+					assert.Equal(t, event.ApplyAction, e.RetryEvent.Action)
 				default:
 					assert.Fail(t, "unexpected event type %s", expected.eventType.String())
 				}
@@ -396,6 +475,22 @@ var clusterScopedObjInfo = &resource.Info{
 	},
 }
 
+var namespaceObjInfo = &resource.Info{
+	Name: namespace,
+	Mapping: &meta.RESTMapping{
+		Scope: meta.RESTScopeRoot,
+	},
+	Object: &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Namespace",
+			"metadata": map[string]interface{}{
+				"name": namespace,
+			},
+		},
+	},
+}
+
 var clusterScopedObj2Info = &resource.Info{
 	Name: "cluster-scoped-2",
 	Mapping: &meta.RESTMapping{
@@ -412,67 +507,11 @@ var clusterScopedObj2Info = &resource.Info{
 	},
 }
 
-func TestValidateNamespace(t *testing.T) {
-	tests := map[string]struct {
-		objects []*resource.Info
-		isValid bool
-	}{
-		"No resources is valid": {
-			objects: []*resource.Info{},
-			isValid: true,
-		},
-		"One resource is valid": {
-			objects: []*resource.Info{obj1Info},
-			isValid: true,
-		},
-		"Two resources with same namespace is valid": {
-			objects: []*resource.Info{obj1Info, obj2Info},
-			isValid: true,
-		},
-		"Two resources with same namespace and cluster-scoped obj is valid": {
-			objects: []*resource.Info{obj1Info, clusterScopedObjInfo, obj2Info},
-			isValid: true,
-		},
-		"Single cluster-scoped obj is valid": {
-			objects: []*resource.Info{clusterScopedObjInfo},
-			isValid: true,
-		},
-		"Multiple cluster-scoped objs is valid": {
-			objects: []*resource.Info{clusterScopedObjInfo, clusterScopedObj2Info},
-			isValid: true,
-		},
-		"Two resources with differing namespaces is not valid": {
-			objects: []*resource.Info{obj1Info, obj3Info},
-			isValid: false,
-		},
-		"Two resources with differing namespaces and cluster-scoped obj is not valid": {
-			objects: []*resource.Info{clusterScopedObjInfo, obj1Info, obj3Info},
-			isValid: false,
-		},
-		"Three resources, one with differing namespace is not valid": {
-			objects: []*resource.Info{obj1Info, obj2Info, obj3Info},
-			isValid: false,
-		},
-		"Default namespace not equal to other namespaces": {
-			objects: []*resource.Info{obj3Info, defaultObjInfo},
-			isValid: false,
-		},
-	}
-
-	for name, tc := range tests {
-		t.Run(name, func(t *testing.T) {
-			actualValid := validateNamespace(tc.objects)
-			if tc.isValid != actualValid {
-				t.Errorf("Expected valid namespace (%t), got (%t)", tc.isValid, actualValid)
-			}
-		})
-	}
-}
-
 func TestReadAndPrepareObjects(t *testing.T) {
 	testCases := map[string]struct {
-		resources     []*resource.Info
-		expectedError bool
+		resources        []*resource.Info
+		groupingObjIndex int
+		expectedError    bool
 	}{
 		"no grouping object": {
 			resources:     []*resource.Info{obj1Info},
@@ -496,10 +535,21 @@ func TestReadAndPrepareObjects(t *testing.T) {
 				clusterScopedObjInfo},
 			expectedError: false,
 		},
-		"objects can not be in different namespaces": {
+		"objects in different namespaces": {
 			resources: []*resource.Info{obj1Info, obj2Info,
 				groupingObjInfo, obj3Info},
-			expectedError: true,
+			expectedError: false,
+		},
+		"objects in different namespaces and cluster-scoped objects": {
+			resources: []*resource.Info{clusterScopedObjInfo, obj1Info,
+				groupingObjInfo, defaultObjInfo, clusterScopedObj2Info, obj3Info},
+			expectedError: false,
+		},
+		"grouping object after namespaces": {
+			resources: []*resource.Info{obj1Info, groupingObjInfo,
+				namespaceObjInfo, obj3Info},
+			groupingObjIndex: 1,
+			expectedError:    false,
 		},
 	}
 
@@ -527,10 +577,10 @@ func TestReadAndPrepareObjects(t *testing.T) {
 				return
 			}
 
-			groupingObj := objects[0]
+			groupingObj := objects[tc.groupingObjIndex]
 			if !prune.IsGroupingObject(groupingObj.Object) {
-				t.Errorf(
-					"expected first item to be grouping object, but it wasn't")
+				t.Errorf("expected item %d to be grouping object, but it wasn't",
+					tc.groupingObjIndex)
 			}
 
 			inventory, err := prune.RetrieveInventoryFromGroupingObj(
@@ -546,6 +596,80 @@ func TestReadAndPrepareObjects(t *testing.T) {
 	}
 }
 
+func TestSetDeletionOptions(t *testing.T) {
+	gracePeriod := func(seconds int64) *int64 {
+		return &seconds
+	}
+
+	testCases := map[string]struct {
+		gracePeriod         int
+		force               bool
+		expectedGracePeriod *int64
+	}{
+		"negative grace period uses the default": {
+			gracePeriod: -1,
+		},
+		"grace period is used": {
+			gracePeriod:         30,
+			expectedGracePeriod: gracePeriod(30),
+		},
+		"grace period of 0 without force is changed to 1": {
+			gracePeriod:         0,
+			expectedGracePeriod: gracePeriod(1),
+		},
+		"grace period of 0 with force is used": {
+			gracePeriod:         0,
+			force:               true,
+			expectedGracePeriod: gracePeriod(0),
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			po := prune.NewPruneOptions()
+			err := setDeletionOptions(po, "Foreground", tc.gracePeriod, tc.force)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, metav1.DeletePropagationForeground, po.PropagationPolicy)
+			assert.Equal(t, tc.expectedGracePeriod, po.GracePeriodSeconds)
+		})
+	}
+}
+
+func TestApplierCopy(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+	defer tf.Cleanup()
+
+	applier := NewApplier(tf, genericclioptions.NewTestIOStreamsDiscard())
+	cmd := &cobra.Command{}
+	assert.NoError(t, applier.SetFlags(cmd))
+	cmdutil.AddValidateFlags(cmd)
+	cmdutil.AddServerSideApplyFlags(cmd)
+	assert.NoError(t, cmd.Flags().Parse([]string{"--grace-period=30", "--filename=extra.yaml"}))
+	applier.Source = KioSource{}
+	applier.ServerDryRun = true
+	applier.NoPrune = true
+	applier.Concurrency = 4
+	assert.NoError(t, applier.Initialize(cmd, []string{"deployment.yaml"}))
+
+	c := applier.Copy()
+	assert.NoError(t, c.Initialize(cmd, []string{"deployment.yaml"}))
+
+	assert.Equal(t, applier.Source, c.Source)
+	assert.True(t, c.ServerDryRun)
+	assert.True(t, c.ApplyOptions.ServerDryRun)
+	assert.True(t, c.PruneOptions.ServerDryRun)
+	assert.True(t, c.NoPrune)
+	assert.Equal(t, 4, c.Concurrency)
+	assert.Equal(t, 30, c.GracePeriod)
+	assert.True(t, c.ApplyOptions.Overwrite)
+	assert.False(t, applier.ApplyOptions == c.ApplyOptions)
+	assert.False(t, applier.PruneOptions == c.PruneOptions)
+	assert.Equal(t, []string{"extra.yaml", "deployment.yaml"}, applier.Paths())
+	assert.Equal(t, applier.Paths(), c.Paths())
+}
+
 func toJSONBytes(t *testing.T, obj runtime.Object) []byte {
 	objBytes, err := runtime.Encode(unstructured.NewJSONFallbackEncoder(codec), obj)
 	if !assert.NoError(t, err) {
@@ -625,10 +749,14 @@ type handler interface {
 
 // genericHandler provides a simple handler for resources that can
 // be fetched and updated. It will simply return the given resource
-// when asked for and accept patch requests.
+// when asked for and accept patch requests. The first failPatches
+// patch requests will be rejected with a TooManyRequests error.
 type genericHandler struct {
 	resourceInfo resourceInfo
 	namespace    string
+	failPatches  int
+	// patches are the bodies of the patch requests that were accepted.
+	patches [][]byte
 }
 
 func (g *genericHandler) handle(t *testing.T, req *http.Request) (*http.Response, bool, error) {
@@ -652,6 +780,17 @@ func (g *genericHandler) handle(t *testing.T, req *http.Request) (*http.Response
 	}
 
 	if req.URL.Path == resourcePath && req.Method == http.MethodPatch {
+		if g.failPatches > 0 {
+			g.failPatches--
+			status := apierrors.NewTooManyRequests("slow down", 0).ErrStatus
+			bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, &status)))
+			return &http.Response{StatusCode: http.StatusTooManyRequests, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
+		}
+		patch, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		g.patches = append(g.patches, patch)
 		bodyRC := ioutil.NopCloser(bytes.NewReader(toJSONBytes(t, obj)))
 		return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: bodyRC}, true, nil
 	}
@@ -666,6 +805,7 @@ type groupingObjectHandler struct {
 
 var (
 	cmPathRegex       = regexp.MustCompile(`^/namespaces/([^/]+)/configmaps$`)
+	cmListPathRegex   = regexp.MustCompile(`^(?:/namespaces/([^/]+))?/configmaps$`)
 	groupObjNameRegex = regexp.MustCompile(`^[a-zA-Z]+-[a-z0-9]+$`)
 	groupObjPathRegex = regexp.MustCompile(`^/namespaces/([^/]+)/configmaps/[a-zA-Z]+-[a-z0-9]+$`)
 )
@@ -689,7 +829,7 @@ func (g *groupingObjectHandler) handle(t *testing.T, req *http.Request) (*http.R
 		return nil, false, nil
 	}
 
-	if req.Method == http.MethodGet && cmPathRegex.Match([]byte(req.URL.Path)) {
+	if req.Method == http.MethodGet && cmListPathRegex.Match([]byte(req.URL.Path)) {
 		cmList := v1.ConfigMapList{
 			TypeMeta: metav1.TypeMeta{
 				APIVersion: "v1",
@@ -29,6 +29,7 @@ type applyStats struct {
 	created           int
 	unchanged         int
 	configured        int
+	failed            int
 }
 
 func (a *applyStats) inc(op event.ApplyEventOperation) {
@@ -51,7 +52,8 @@ func (a *applyStats) sum() int {
 }
 
 type pruneStats struct {
-	count int
+	count   int
+	skipped int
 }
 
 func (p *pruneStats) inc() {
@@ -88,20 +90,104 @@ func (b *BasicPrinter) Print(ch <-chan event.Event, preview bool) {
 	}
 	pruneStats := &pruneStats{}
 	deleteStats := &deleteStats{}
+	planned := false
 	for e := range ch {
 		switch e.Type {
 		case event.ErrorType:
 			cmdutil.CheckErr(e.ErrorEvent.Err)
 		case event.ApplyType:
+			if preview {
+				planned = true
+				b.processPlannedApplyEvent(e.ApplyEvent, applyStats)
+				continue
+			}
 			b.processApplyEvent(e.ApplyEvent, applyStats, statusCollector, printFunc)
 		case event.StatusType:
 			b.processStatusEvent(e.StatusEvent, statusCollector, printFunc)
 		case event.PruneType:
+			if preview {
+				planned = true
+				b.processPlannedPruneEvent(e.PruneEvent, pruneStats)
+				continue
+			}
 			b.processPruneEvent(e.PruneEvent, pruneStats, printFunc)
 		case event.DeleteType:
 			b.processDeleteEvent(e.DeleteEvent, deleteStats, printFunc)
+		case event.RetryType:
+			b.processRetryEvent(e.RetryEvent, printFunc)
 		}
 	}
+	if planned {
+		b.printPlanSummary(applyStats, pruneStats)
+	}
+}
+
+// processPlannedApplyEvent prints an apply event from a preview as a
+// line in the plan, with a symbol for what applying the resource would
+// do, similar to the output of terraform plan.
+func (b *BasicPrinter) processPlannedApplyEvent(ae event.ApplyEvent, as *applyStats) {
+	switch ae.Type {
+	case event.ApplyEventResourceUpdate:
+		id := resourceIDToString(ae.Object.GetObjectKind().GroupVersionKind().GroupKind(), getName(ae.Object))
+		as.inc(ae.Operation)
+		switch ae.Operation {
+		case event.Created:
+			b.printf("+ %s created", id)
+		case event.Unchanged:
+			b.printf("= %s unchanged", id)
+		default:
+			b.printf("~ %s configured", id)
+		}
+		for _, path := range ae.ChangedFields {
+			b.printf("    ~ %s", path)
+		}
+		for _, path := range ae.DefaultedFields {
+			b.printf("    %s defaulted by the server", path)
+		}
+	case event.ApplyEventResourceCancelled:
+		b.printf("  %s not applied (cancelled)", resourceIDToString(
+			ae.Object.GetObjectKind().GroupVersionKind().GroupKind(), getName(ae.Object)))
+	case event.ApplyEventResourceFailed:
+		as.failed++
+		b.printf("! %s failed: %s", resourceIDToString(
+			ae.Object.GetObjectKind().GroupVersionKind().GroupKind(), getName(ae.Object)), ae.Error.Error())
+	}
+}
+
+// processPlannedPruneEvent prints a prune event from a preview as a
+// line in the plan.
+func (b *BasicPrinter) processPlannedPruneEvent(pe event.PruneEvent, ps *pruneStats) {
+	if pe.Type == event.PruneEventCompleted {
+		return
+	}
+	id := resourceIDToString(pe.Object.GetObjectKind().GroupVersionKind().GroupKind(), getName(pe.Object))
+	switch pe.Type {
+	case event.PruneEventResourceUpdate:
+		ps.inc()
+		b.printf("- %s pruned", id)
+	case event.PruneEventResourceSkipped:
+		ps.skipped++
+		b.printf("  %s prune skipped (not found)", id)
+	case event.PruneEventResourceCancelled:
+		b.printf("  %s not pruned (cancelled)", id)
+	}
+}
+
+// printPlanSummary prints the totals for a preview.
+func (b *BasicPrinter) printPlanSummary(as *applyStats, ps *pruneStats) {
+	output := fmt.Sprintf("Plan: %d to create, %d to update, %d unchanged, %d to prune",
+		as.created, as.configured+as.serversideApplied, as.unchanged, ps.count)
+	if ps.skipped > 0 {
+		output += fmt.Sprintf(", %d prune skipped", ps.skipped)
+	}
+	if as.failed > 0 {
+		output += fmt.Sprintf(", %d failed", as.failed)
+	}
+	b.printf("\n%s", output)
+}
+
+func (b *BasicPrinter) printf(format string, a ...interface{}) {
+	fmt.Fprintf(b.IOStreams.Out, format+"\n", a...)
 }
 
 func (b *BasicPrinter) processApplyEvent(ae event.ApplyEvent, as *applyStats,
@@ -115,6 +201,9 @@ func (b *BasicPrinter) processApplyEvent(ae event.ApplyEvent, as *applyStats,
 		if as.serversideApplied > 0 {
 			output += fmt.Sprintf(", %d serverside applied", as.serversideApplied)
 		}
+		if as.failed > 0 {
+			output += fmt.Sprintf(", %d failed", as.failed)
+		}
 		p(output)
 		c.printStatus = true
 		for id, se := range c.latestStatus {
@@ -127,6 +216,18 @@ func (b *BasicPrinter) processApplyEvent(ae event.ApplyEvent, as *applyStats,
 		as.inc(ae.Operation)
 		p("%s %s", resourceIDToString(gvk.GroupKind(), name),
 			strings.ToLower(ae.Operation.String()))
+		for _, path := range ae.DefaultedFields {
+			p("    %s defaulted by the server", path)
+		}
+	case event.ApplyEventResourceCancelled:
+		obj := ae.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		p("%s %s", resourceIDToString(gvk.GroupKind(), getName(obj)), "not applied (cancelled)")
+	case event.ApplyEventResourceFailed:
+		obj := ae.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		as.failed++
+		p("%s failed: %s", resourceIDToString(gvk.GroupKind(), getName(obj)), ae.Error.Error())
 	}
 }
 
@@ -143,6 +244,8 @@ func (b *BasicPrinter) processStatusEvent(se pollevent.Event, sc *statusCollecto
 		gk := id.GroupKind
 		p("%s error: %s\n", resourceIDToString(gk, id.Name),
 			se.Error.Error())
+	case pollevent.WarningEvent:
+		p("warning: unable to poll for status: %s", se.Error.Error())
 	case pollevent.CompletedEvent:
 		sc.printStatus = false
 		p("all resources has reached the Current status")
@@ -160,13 +263,26 @@ func printResourceStatus(id object.ObjMetadata, se pollevent.Event, p printFunc)
 func (b *BasicPrinter) processPruneEvent(pe event.PruneEvent, ps *pruneStats, p printFunc) {
 	switch pe.Type {
 	case event.PruneEventCompleted:
-		p("%d resource(s) pruned", ps.count)
+		output := fmt.Sprintf("%d resource(s) pruned", ps.count)
+		if ps.skipped > 0 {
+			output += fmt.Sprintf(", %d skipped", ps.skipped)
+		}
+		p(output)
 	case event.PruneEventResourceUpdate:
 		obj := pe.Object
 		gvk := obj.GetObjectKind().GroupVersionKind()
 		name := getName(obj)
 		ps.inc()
 		p("%s %s", resourceIDToString(gvk.GroupKind(), name), "pruned")
+	case event.PruneEventResourceCancelled:
+		obj := pe.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		p("%s %s", resourceIDToString(gvk.GroupKind(), getName(obj)), "not pruned (cancelled)")
+	case event.PruneEventResourceSkipped:
+		obj := pe.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		ps.skipped++
+		p("%s %s", resourceIDToString(gvk.GroupKind(), getName(obj)), "prune skipped (not found)")
 	}
 }
 
@@ -180,7 +296,41 @@ func (b *BasicPrinter) processDeleteEvent(de event.DeleteEvent, ds *deleteStats,
 		name := getName(obj)
 		ds.inc()
 		p("%s %s", resourceIDToString(gvk.GroupKind(), name), "deleted")
+	case event.DeleteEventResourceCancelled:
+		obj := de.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		p("%s %s", resourceIDToString(gvk.GroupKind(), getName(obj)), "not deleted (cancelled)")
+	case event.DeleteEventResourceSkipped:
+		obj := de.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		p("%s %s", resourceIDToString(gvk.GroupKind(), getName(obj)), "not deleted (not found)")
+	case event.DeleteEventResourceTerminating:
+		obj := de.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		output := fmt.Sprintf("%s still terminating", resourceIDToString(gvk.GroupKind(), getName(obj)))
+		if acc, err := meta.Accessor(obj); err == nil && len(acc.GetFinalizers()) > 0 {
+			output += fmt.Sprintf(", waiting for finalizers: %s", strings.Join(acc.GetFinalizers(), ", "))
+		}
+		p(output)
+	case event.DeleteEventFinalizersRemoved:
+		obj := de.Object
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		output := fmt.Sprintf("%s finalizers forcibly removed", resourceIDToString(gvk.GroupKind(), getName(obj)))
+		if acc, err := meta.Accessor(obj); err == nil && len(acc.GetFinalizers()) > 0 {
+			output += fmt.Sprintf(": %s", strings.Join(acc.GetFinalizers(), ", "))
+		}
+		p(output)
+	}
+}
+
+func (b *BasicPrinter) processRetryEvent(re event.RetryEvent, p printFunc) {
+	action := "apply"
+	if re.Action == event.PruneAction {
+		action = "prune"
 	}
+	id := re.Identifier
+	p("%s %s failed (attempt %d), retrying in %s: %s", resourceIDToString(id.GroupKind, id.Name),
+		action, re.Attempt, re.Backoff, re.Err.Error())
 }
 
 func getName(obj runtime.Object) string {
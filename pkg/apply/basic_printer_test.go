@@ -0,0 +1,139 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+func TestBasicPrinterPlan(t *testing.T) {
+	testCases := map[string]struct {
+		events   []event.Event
+		preview  bool
+		expected string
+	}{
+		"preview prints a plan": {
+			events: []event.Event{
+				applyEvent(event.Created, "a", nil),
+				applyEvent(event.Configured, "b", []string{".data.a", ".data.old"}),
+				applyEvent(event.Unchanged, "c", nil),
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventCompleted}},
+				pruneEvent(event.PruneEventResourceUpdate, "d"),
+				pruneEvent(event.PruneEventResourceSkipped, "e"),
+				{Type: event.PruneType, PruneEvent: event.PruneEvent{Type: event.PruneEventCompleted}},
+			},
+			preview: true,
+			expected: `+ configmap/a created
+~ configmap/b configured
+    ~ .data.a
+    ~ .data.old
+= configmap/c unchanged
+- configmap/d pruned
+  configmap/e prune skipped (not found)
+
+Plan: 1 to create, 1 to update, 1 unchanged, 1 to prune, 1 prune skipped
+`,
+		},
+		"apply reports skipped prunes": {
+			events: []event.Event{
+				applyEvent(event.Created, "a", nil),
+				{Type: event.ApplyType, ApplyEvent: event.ApplyEvent{Type: event.ApplyEventCompleted}},
+				pruneEvent(event.PruneEventResourceSkipped, "e"),
+				{Type: event.PruneType, PruneEvent: event.PruneEvent{Type: event.PruneEventCompleted}},
+			},
+			expected: `configmap/a created
+1 resource(s) applied. 1 created, 0 unchanged, 0 configured
+configmap/e prune skipped (not found)
+0 resource(s) pruned, 1 skipped
+`,
+		},
+		"destroy preview is not a plan": {
+			events: []event.Event{
+				{Type: event.DeleteType, DeleteEvent: event.DeleteEvent{
+					Type:   event.DeleteEventResourceUpdate,
+					Object: configMap("a"),
+				}},
+				{Type: event.DeleteType, DeleteEvent: event.DeleteEvent{
+					Type:   event.DeleteEventResourceSkipped,
+					Object: configMap("b"),
+				}},
+			},
+			preview: true,
+			expected: `configmap/a deleted (preview)
+configmap/b not deleted (not found) (preview)
+`,
+		},
+		"destroy reports blocking finalizers": {
+			events: []event.Event{
+				{Type: event.DeleteType, DeleteEvent: event.DeleteEvent{
+					Type:   event.DeleteEventResourceTerminating,
+					Object: withFinalizers(configMap("a"), "example.com/a", "example.com/b"),
+				}},
+				{Type: event.DeleteType, DeleteEvent: event.DeleteEvent{
+					Type:   event.DeleteEventFinalizersRemoved,
+					Object: withFinalizers(configMap("b"), "example.com/a"),
+				}},
+			},
+			expected: `configmap/a still terminating, waiting for finalizers: example.com/a, example.com/b
+configmap/b finalizers forcibly removed: example.com/a
+`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, _ := genericclioptions.NewTestIOStreams()
+			ch := make(chan event.Event, len(tc.events))
+			for _, e := range tc.events {
+				ch <- e
+			}
+			close(ch)
+
+			printer := &BasicPrinter{IOStreams: ioStreams}
+			printer.Print(ch, tc.preview)
+
+			assert.Equal(t, tc.expected, out.String())
+		})
+	}
+}
+
+func configMap(name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind("ConfigMap")
+	u.SetName(name)
+	return u
+}
+
+func withFinalizers(u *unstructured.Unstructured, finalizers ...string) *unstructured.Unstructured {
+	u.SetFinalizers(finalizers)
+	return u
+}
+
+func applyEvent(op event.ApplyEventOperation, name string, changed []string) event.Event {
+	return event.Event{
+		Type: event.ApplyType,
+		ApplyEvent: event.ApplyEvent{
+			Type:          event.ApplyEventResourceUpdate,
+			Operation:     op,
+			Object:        configMap(name),
+			ChangedFields: changed,
+		},
+	}
+}
+
+func pruneEvent(t event.PruneEventType, name string) event.Event {
+	return event.Event{
+		Type: event.PruneType,
+		PruneEvent: event.PruneEvent{
+			Type:   t,
+			Object: configMap(name),
+		},
+	}
+}
@@ -4,13 +4,23 @@
 package apply
 
 import (
+	"context"
+	"fmt"
+	"time"
+
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/apply"
 	"k8s.io/kubectl/pkg/cmd/util"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/poller"
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/apply/task"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 // NewDestroyer returns a new destroyer. It will set up the ApplyOptions and
@@ -21,10 +31,14 @@ import (
 // between the two.
 func NewDestroyer(factory util.Factory, ioStreams genericclioptions.IOStreams) *Destroyer {
 	return &Destroyer{
-		ApplyOptions: apply.NewApplyOptions(ioStreams),
-		PruneOptions: prune.NewPruneOptions(),
-		factory:      factory,
-		ioStreams:    ioStreams,
+		ApplyOptions:          apply.NewApplyOptions(ioStreams),
+		PruneOptions:          prune.NewPruneOptions(),
+		WaitTimeout:           time.Minute,
+		PollInterval:          2 * time.Second,
+		RemoveFinalizersAfter: 30 * time.Second,
+		GracePeriod:           -1,
+		factory:               factory,
+		ioStreams:             ioStreams,
 	}
 }
 
@@ -35,8 +49,48 @@ type Destroyer struct {
 	ioStreams    genericclioptions.IOStreams
 	ApplyOptions *apply.ApplyOptions
 	PruneOptions *prune.PruneOptions
+	statusPoller poller.Poller
+
+	// Source provides the manifests of the package to destroy. If it
+	// is nil, they are read from the directory given to Initialize.
+	Source ManifestSource
 
 	DryRun bool
+
+	// WaitForDeletion makes the Destroyer delete the resources in
+	// phases, in the reverse of the order they are applied in, and wait
+	// for each phase to be gone from the cluster before the next one is
+	// deleted. The inventory is only deleted once all the resources are
+	// gone.
+	WaitForDeletion bool
+	// WaitTimeout is how long to wait for all the resources to be
+	// deleted. The resources that still exist after the timeout are
+	// reported with their finalizers.
+	WaitTimeout time.Duration
+	// PollInterval is how often the resources are polled while waiting
+	// for them to be deleted.
+	PollInterval time.Duration
+	// PropagationPolicy is the propagation policy for the deleted
+	// resources: Foreground, Background or Orphan. If it is empty,
+	// the default policy of each resource is used.
+	PropagationPolicy string
+	// GracePeriod is the grace period in seconds for the deleted
+	// resources. If it is negative, the default grace period of each
+	// resource is used. A grace period of 0 is changed to 1 unless
+	// Force is set.
+	GracePeriod int
+	// Force removes the deleted resources from the API immediately
+	// when the GracePeriod is 0, bypassing graceful deletion.
+	Force bool
+	// RemoveFinalizers makes the Destroyer remove the finalizers from
+	// the resources that are still terminating after
+	// RemoveFinalizersAfter, so they are deleted even if the
+	// controllers that should remove the finalizers are gone. It can
+	// only be used together with WaitForDeletion.
+	RemoveFinalizers bool
+	// RemoveFinalizersAfter is how long the resources in each phase
+	// are given to be deleted before their finalizers are removed.
+	RemoveFinalizersAfter time.Duration
 }
 
 // Initialize sets up the Destroyer for actually doing an destroy against
@@ -47,8 +101,20 @@ func (d *Destroyer) Initialize(cmd *cobra.Command, paths []string) error {
 	if err != nil {
 		return err
 	}
-	d.ApplyOptions.DeleteFlags.FileNameFlags = &fileNameFlags
-	err = d.ApplyOptions.Complete(d.factory, cmd)
+	d.ApplyOptions.RecordFlags.Complete(cmd)
+	opts, err := commandOptionsFromFlags(d.factory, cmd)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
+	}
+	opts.fileNameFlags = &fileNameFlags
+	return d.complete(opts)
+}
+
+// complete sets up the ApplyOptions with the commandOptions, and the
+// rest of the Destroyer from its settings. It is shared by Initialize
+// and NewDestroyerFromOptions.
+func (d *Destroyer) complete(opts commandOptions) error {
+	err := completeApplyOptions(d.ApplyOptions, d.factory, opts)
 	if err != nil {
 		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
 	}
@@ -60,17 +126,33 @@ func (d *Destroyer) Initialize(cmd *cobra.Command, paths []string) error {
 	// Propagate dry-run flags.
 	d.ApplyOptions.DryRun = d.DryRun
 	d.PruneOptions.DryRun = d.DryRun
+	if err := setDeletionOptions(d.PruneOptions, d.PropagationPolicy, d.GracePeriod, d.Force); err != nil {
+		return err
+	}
+
+	if d.RemoveFinalizers && !d.WaitForDeletion {
+		return errors.New("finalizers can only be removed when waiting for deletion")
+	}
+	if d.WaitForDeletion && !d.DryRun {
+		statusPoller, err := newStatusPoller(d.factory)
+		if err != nil {
+			return errors.WrapPrefix(err, "error creating resolver", 1)
+		}
+		d.statusPoller = statusPoller
+	}
 	return nil
 }
 
 // Run performs the destroy step. This happens asynchronously
 // on progress and any errors are reported back on the event channel.
-func (d *Destroyer) Run() <-chan event.Event {
+// If the context is cancelled, the remaining resources are not deleted
+// and an event is sent for each of them.
+func (d *Destroyer) Run(ctx context.Context) <-chan event.Event {
 	ch := make(chan event.Event)
 
 	go func() {
 		defer close(ch)
-		infos, err := d.ApplyOptions.GetObjects()
+		infos, err := d.readObjects()
 		if err != nil {
 			ch <- event.Event{
 				Type: event.ErrorType,
@@ -91,7 +173,12 @@ func (d *Destroyer) Run() <-chan event.Event {
 		// Events. That we use Prune to implement destroy is an
 		// implementation detail and the events should not be Prune events.
 		tempChannel, completedChannel := runPruneEventTransformer(ch)
-		err = d.PruneOptions.Prune(infos, tempChannel)
+		// Nothing is deleted in a dry-run, so there is nothing to wait for.
+		if d.WaitForDeletion && !d.DryRun {
+			err = d.deleteAndWait(ctx, infos, tempChannel)
+		} else {
+			err = d.PruneOptions.Prune(ctx, infos, tempChannel)
+		}
 		// Close the tempChannel to signal to the event transformer that
 		// it should terminate.
 		close(tempChannel)
@@ -110,6 +197,9 @@ func (d *Destroyer) Run() <-chan event.Event {
 			}
 			return
 		}
+		if ctx.Err() != nil {
+			return
+		}
 		ch <- event.Event{
 			Type: event.DeleteType,
 			DeleteEvent: event.DeleteEvent{
@@ -120,10 +210,129 @@ func (d *Destroyer) Run() <-chan event.Event {
 	return ch
 }
 
+// deleteAndWait deletes the resources in phases, and waits for each
+// phase to be gone before the next one is deleted. If the WaitTimeout
+// is reached, the resources that are still terminating and the ones
+// that were not deleted are reported, and an error is returned.
+func (d *Destroyer) deleteAndWait(ctx context.Context, infos []*resource.Info, eventChannel chan event.Event) error {
+	pruneObjs, pastGroupingInfos, err := d.PruneOptions.RetrievePruneSet(infos)
+	if err != nil {
+		return err
+	}
+	phases := prune.DeletionPhases(pruneObjs)
+
+	// The timeout only stops the waiting, so the resources in a phase
+	// are always either all deleted or not deleted at all.
+	waitCtx, cancel := context.WithTimeout(ctx, d.WaitTimeout)
+	defer cancel()
+	var identifiers []object.ObjMetadata
+	b := task.NewTaskQueueBuilder(eventChannel).WithContext(ctx)
+	for _, phase := range phases {
+		var ids []object.ObjMetadata
+		for _, obj := range phase {
+			ids = append(ids, *obj)
+		}
+		identifiers = append(identifiers, ids...)
+		b.AppendDeleteTask(phase, nil, d.PruneOptions)
+		if d.RemoveFinalizers {
+			b.AppendRemoveFinalizersTask(phase, d.RemoveFinalizersAfter, d.PruneOptions)
+		}
+		b.AppendWaitTask(ids, taskrunner.AllNotFound, d.WaitTimeout)
+	}
+	// The inventory is deleted last, so the resources can still be
+	// found if they are not all deleted.
+	b.AppendDeleteTask(nil, pastGroupingInfos, d.PruneOptions)
+	done := &doneTask{done: make(chan struct{})}
+	b.AppendTask(done)
+
+	taskGraph, err := b.BuildGraph()
+	if err != nil {
+		return err
+	}
+	runner := taskrunner.NewTaskStatusRunner(identifiers, d.statusPoller)
+	err = runner.RunGraph(waitCtx, taskGraph, eventChannel, taskrunner.PollingOptions{
+		PollInterval: d.PollInterval,
+		UseCache:     true,
+		RetryPolicy:  d.PruneOptions.RetryPolicy,
+	})
+	select {
+	case <-done.done:
+		return err
+	default:
+	}
+	if ctx.Err() != nil || (err != nil && !taskrunner.IsTimeoutError(err)) {
+		return err
+	}
+	terminating, err := d.reportRemaining(identifiers, eventChannel)
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("timeout after %s waiting for resources to be deleted, %d still terminating",
+		d.WaitTimeout, terminating)
+}
+
+// reportRemaining sends an event for each of the resources that still
+// exist. The ones that have been deleted are still terminating, while
+// the others were not deleted since the earlier phases were not
+// deleted in time. It returns the number of terminating resources.
+func (d *Destroyer) reportRemaining(ids []object.ObjMetadata, eventChannel chan event.Event) (int, error) {
+	terminating := 0
+	err := getLiveObjects(d.factory, ids, func(_ object.ObjMetadata, obj *unstructured.Unstructured) {
+		if obj == nil {
+			return
+		}
+		deleteEventType := event.DeleteEventResourceCancelled
+		if obj.GetDeletionTimestamp() != nil {
+			deleteEventType = event.DeleteEventResourceTerminating
+			terminating++
+		}
+		eventChannel <- event.Event{
+			Type: event.DeleteType,
+			DeleteEvent: event.DeleteEvent{
+				Type:   deleteEventType,
+				Object: obj,
+			},
+		}
+	})
+	return terminating, err
+}
+
+// doneTask is added as the last task, to tell whether all the other
+// tasks completed before the timeout.
+type doneTask struct {
+	done chan struct{}
+}
+
+func (t *doneTask) Start(taskChannel chan taskrunner.TaskResult) {
+	close(t.done)
+	go func() {
+		taskChannel <- taskrunner.TaskResult{}
+	}()
+}
+
+func (t *doneTask) ClearTimeout() {}
+
+// readObjects reads the manifests of the package from the Source if
+// there is one, or otherwise with the ApplyOptions.
+func (d *Destroyer) readObjects() ([]*resource.Info, error) {
+	if d.Source == nil {
+		return d.ApplyOptions.GetObjects()
+	}
+	objs, err := d.Source.Objects()
+	if err != nil {
+		return nil, err
+	}
+	return objectsToInfos(d.factory, objs, d.ApplyOptions.Namespace, d.ApplyOptions.EnforceNamespace)
+}
+
 // SetFlags configures the command line flags needed for destroy
 // This is a temporary solution as we should separate the configuration
 // of cobra flags from the Destroyer.
 func (d *Destroyer) SetFlags(cmd *cobra.Command) error {
+	// The --grace-period and --force flags of kubectl set the grace
+	// period for the deleted resources.
+	d.ApplyOptions.DeleteFlags.GracePeriod = &d.GracePeriod
+	d.ApplyOptions.DeleteFlags.Force = &d.Force
 	d.ApplyOptions.DeleteFlags.AddFlags(cmd)
 	for _, flag := range []string{"kustomize", "filename", "recursive"} {
 		err := cmd.Flags().MarkHidden(flag)
@@ -134,30 +343,55 @@ func (d *Destroyer) SetFlags(cmd *cobra.Command) error {
 	d.ApplyOptions.RecordFlags.AddFlags(cmd)
 	_ = cmd.Flags().MarkHidden("record")
 	_ = cmd.Flags().MarkHidden("cascade")
-	_ = cmd.Flags().MarkHidden("force")
-	_ = cmd.Flags().MarkHidden("grace-period")
 	_ = cmd.Flags().MarkHidden("timeout")
 	_ = cmd.Flags().MarkHidden("wait")
+	cmd.Flags().StringVar(&d.PropagationPolicy, "propagation-policy", d.PropagationPolicy,
+		"Propagation policy for deleted resources: Foreground, Background or Orphan. "+
+			"Defaults to the policy of each resource.")
+	cmd.Flags().BoolVar(&d.WaitForDeletion, "wait-for-deletion", d.WaitForDeletion,
+		"Delete the resources in the reverse of the order they are applied in, and wait for them to be deleted.")
+	cmd.Flags().DurationVar(&d.WaitTimeout, "wait-timeout", d.WaitTimeout,
+		"Timeout threshold for waiting for all resources to be deleted.")
+	cmd.Flags().DurationVar(&d.PollInterval, "wait-polling-period", d.PollInterval,
+		"Polling period for resource statuses.")
+	cmd.Flags().BoolVar(&d.RemoveFinalizers, "remove-finalizers", d.RemoveFinalizers,
+		"Remove the finalizers from resources that are still terminating after --remove-finalizers-after. "+
+			"Requires --wait-for-deletion. Use with care, since the cleanup done by the finalizers is skipped.")
+	cmd.Flags().DurationVar(&d.RemoveFinalizersAfter, "remove-finalizers-after", d.RemoveFinalizersAfter,
+		"How long to wait for resources to be deleted before their finalizers are removed.")
 	d.ApplyOptions.Overwrite = true
 	return nil
 }
 
 // runPruneEventTransformer creates a channel for events and
 // starts a goroutine that will read from the channel until it
-// is closed. All events will be republished as Delete events
-// on the provided eventChannel. The function will also return
-// a channel that it will close once the goroutine is shutting
-// down.
+// is closed. All Prune events will be republished as Delete events
+// on the provided eventChannel, while other events are passed on
+// unchanged. The function will also return a channel that it will
+// close once the goroutine is shutting down.
 func runPruneEventTransformer(eventChannel chan event.Event) (chan event.Event, <-chan struct{}) {
 	completedChannel := make(chan struct{})
 	tempEventChannel := make(chan event.Event)
 	go func() {
 		defer close(completedChannel)
 		for msg := range tempEventChannel {
+			if msg.Type != event.PruneType {
+				eventChannel <- msg
+				continue
+			}
+			deleteEventType := event.DeleteEventResourceUpdate
+			switch msg.PruneEvent.Type {
+			case event.PruneEventResourceCancelled:
+				deleteEventType = event.DeleteEventResourceCancelled
+			case event.PruneEventResourceSkipped:
+				deleteEventType = event.DeleteEventResourceSkipped
+			case event.PruneEventFinalizersRemoved:
+				deleteEventType = event.DeleteEventFinalizersRemoved
+			}
 			eventChannel <- event.Event{
 				Type: event.DeleteType,
 				DeleteEvent: event.DeleteEvent{
-					Type:   event.DeleteEventResourceUpdate,
+					Type:   deleteEventType,
 					Object: msg.PruneEvent.Object,
 				},
 			}
@@ -0,0 +1,235 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
+)
+
+func TestDestroyerWaitForDeletion(t *testing.T) {
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	podID := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: namespace, Name: "pod"}
+	deploymentID := object.ObjMetadata{GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Namespace: namespace, Name: "dep"}
+
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace(namespace)
+	pod.SetName("pod")
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace(namespace)
+	deployment.SetName("dep")
+
+	inventory := &v1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "inventory-1234",
+			Namespace: namespace,
+			Labels:    map[string]string{prune.GroupingLabel: "test"},
+		},
+		Data: map[string]string{
+			podID.String():        "",
+			deploymentID.String(): "",
+		},
+	}
+	template := func() *resource.Info {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		u.SetNamespace(namespace)
+		u.SetName("inventory")
+		u.SetLabels(map[string]string{prune.GroupingLabel: "test"})
+		return &resource.Info{
+			Namespace: namespace,
+			Name:      "inventory",
+			Mapping: &meta.RESTMapping{
+				Resource: configMapsGVR,
+				Scope:    meta.RESTScopeNamespace,
+			},
+			Object: u,
+		}
+	}
+	notFound := func(id object.ObjMetadata) pollevent.Event {
+		return pollevent.Event{
+			EventType: pollevent.ResourceUpdateEvent,
+			Resource: &pollevent.ResourceStatus{
+				Identifier: id,
+				Status:     status.NotFoundStatus,
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		statusEvents     []pollevent.Event
+		stuckPod         bool
+		removeFinalizers bool
+		patchConflicts   int
+		expected         []event.DeleteEventType
+		expectedIDs      []string
+		expectedRetries  int
+		expectError      bool
+	}{
+		"resources are deleted in reverse order": {
+			statusEvents: []pollevent.Event{notFound(podID), notFound(deploymentID)},
+			expected: []event.DeleteEventType{
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventCompleted,
+			},
+			expectedIDs: []string{"pod", "dep", "inventory-1234", ""},
+		},
+		"terminating resources are reported after the timeout": {
+			stuckPod: true,
+			expected: []event.DeleteEventType{
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventResourceTerminating,
+				event.DeleteEventResourceCancelled,
+			},
+			expectedIDs: []string{"pod", "pod", "dep"},
+			expectError: true,
+		},
+		"finalizers are removed from terminating resources": {
+			statusEvents:     []pollevent.Event{notFound(podID), notFound(deploymentID)},
+			stuckPod:         true,
+			removeFinalizers: true,
+			expected: []event.DeleteEventType{
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventFinalizersRemoved,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventCompleted,
+			},
+			expectedIDs: []string{"pod", "pod", "dep", "inventory-1234", ""},
+		},
+		"finalizers are removed after a conflict": {
+			statusEvents:     []pollevent.Event{notFound(podID), notFound(deploymentID)},
+			stuckPod:         true,
+			removeFinalizers: true,
+			patchConflicts:   1,
+			expected: []event.DeleteEventType{
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventFinalizersRemoved,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventResourceUpdate,
+				event.DeleteEventCompleted,
+			},
+			expectedIDs:     []string{"pod", "pod", "dep", "inventory-1234", ""},
+			expectedRetries: 1,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			livePod := pod.DeepCopy()
+			if tc.stuckPod {
+				now := metav1.Now()
+				livePod.SetDeletionTimestamp(&now)
+				livePod.SetFinalizers([]string{"example.com/cleanup"})
+			}
+			client := fakedynamic.NewSimpleDynamicClient(scheme.Scheme,
+				[]runtime.Object{livePod, deployment.DeepCopy()}...)
+			if tc.stuckPod {
+				client.PrependReactor("delete", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, nil
+				})
+			}
+			conflicts := tc.patchConflicts
+			client.PrependReactor("patch", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+				if conflicts == 0 {
+					return false, nil, nil
+				}
+				conflicts--
+				return true, nil, apierrors.NewConflict(podsGVR.GroupResource(), "pod",
+					fmt.Errorf("the object has been modified"))
+			})
+
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+				&groupingObjectHandler{groupingObj: inventory},
+			})
+			tf.FakeDynamicClient = client
+
+			destroyer := NewDestroyer(tf, genericclioptions.NewTestIOStreamsDiscard())
+			destroyer.WaitForDeletion = true
+			destroyer.WaitTimeout = 500 * time.Millisecond
+			destroyer.RemoveFinalizers = tc.removeFinalizers
+			destroyer.RemoveFinalizersAfter = 100 * time.Millisecond
+			assert.NoError(t, destroyer.PruneOptions.Initialize(tf))
+			destroyer.PruneOptions.RetryPolicy = &retry.Policy{
+				Attempts:       3,
+				InitialBackoff: time.Millisecond,
+				Retryable:      retry.AllClasses,
+			}
+			start := make(chan struct{})
+			close(start)
+			destroyer.statusPoller = &fakePoller{start: start, events: tc.statusEvents}
+			destroyer.ApplyOptions.SetObjects([]*resource.Info{template()})
+
+			var deleteEvents []event.DeleteEventType
+			var names []string
+			var errorEvents, retries int
+			for e := range destroyer.Run(context.Background()) {
+				switch e.Type {
+				case event.DeleteType:
+					deleteEvents = append(deleteEvents, e.DeleteEvent.Type)
+					name := ""
+					if e.DeleteEvent.Object != nil {
+						name = getName(e.DeleteEvent.Object)
+					}
+					names = append(names, name)
+				case event.ErrorType:
+					errorEvents++
+				case event.RetryType:
+					retries++
+				}
+			}
+
+			assert.Equal(t, tc.expected, deleteEvents)
+			assert.Equal(t, tc.expectedIDs, names)
+			assert.Equal(t, tc.expectedRetries, retries)
+			if tc.expectError {
+				assert.Equal(t, 1, errorEvents)
+			} else {
+				assert.Equal(t, 0, errorEvents)
+				_, err := client.Resource(deploymentsGVR).Namespace(namespace).Get("dep", metav1.GetOptions{})
+				assert.Error(t, err)
+			}
+			if tc.removeFinalizers {
+				obj, err := client.Resource(podsGVR).Namespace(namespace).Get("pod", metav1.GetOptions{})
+				if assert.NoError(t, err) {
+					assert.Empty(t, obj.GetFinalizers())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	kubectldiff "k8s.io/kubectl/pkg/cmd/diff"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/yaml"
+)
+
+// maxDiffRetries is the number of times the merged version of an
+// object is computed if the live object keeps changing. The last
+// attempt ignores the resourceVersion of the live object.
+const maxDiffRetries = 4
+
+//go:generate stringer -type=DiffAction
+type DiffAction int
+
+const (
+	// DiffCreated means the object does not exist and would be created.
+	DiffCreated DiffAction = iota
+	// DiffConfigured means the object exists and would be changed.
+	DiffConfigured
+	// DiffUnchanged means the object exists and would not be changed.
+	DiffUnchanged
+	// DiffPruned means the object is no longer in the package and
+	// would be pruned.
+	DiffPruned
+)
+
+// NewDiffer returns a new Differ. It will set up the ApplyOptions and
+// PruneOptions which are responsible for capturing any command line flags.
+func NewDiffer(factory util.Factory, ioStreams genericclioptions.IOStreams) *Differ {
+	return &Differ{
+		ApplyOptions: apply.NewApplyOptions(ioStreams),
+		PruneOptions: prune.NewPruneOptions(),
+		factory:      factory,
+		ioStreams:    ioStreams,
+	}
+}
+
+// Differ computes what applying a package would change in the cluster.
+// The package is read and prepared the same way as by the Applier, the
+// objects are applied with server-side dry-run to find how they would
+// look in the cluster, and the objects that would be pruned are found
+// from the inventory.
+type Differ struct {
+	factory        util.Factory
+	ioStreams      genericclioptions.IOStreams
+	ApplyOptions   *apply.ApplyOptions
+	PruneOptions   *prune.PruneOptions
+	mapper         meta.RESTMapper
+	client         dynamic.Interface
+	dryRunVerifier *apply.DryRunVerifier
+}
+
+// ObjectDiff describes how applying the package would change an object.
+type ObjectDiff struct {
+	Identifier object.ObjMetadata
+	Action     DiffAction
+	// Diff is the unified diff between the YAML of the live object
+	// and the object as it would be after the apply. It is empty if
+	// the object would not be changed.
+	Diff string
+}
+
+// DiffResult is the result of comparing a package with the cluster.
+type DiffResult struct {
+	// Objects are the objects in the package in the order they would
+	// be applied, followed by the objects that would be pruned.
+	Objects []ObjectDiff
+}
+
+// Count returns the number of objects with the given action.
+func (r *DiffResult) Count(action DiffAction) int {
+	count := 0
+	for _, obj := range r.Objects {
+		if obj.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// HasChanges returns true if applying the package would change
+// any object in the cluster.
+func (r *DiffResult) HasChanges() bool {
+	return r.Count(DiffUnchanged) != len(r.Objects)
+}
+
+// Initialize sets up the Differ for reading the package and computing
+// the changes against the cluster. This involves validating command
+// line inputs and configuring clients for communicating with the cluster.
+func (d *Differ) Initialize(cmd *cobra.Command, paths []string) error {
+	fileNameFlags, err := demandOneDirectory(paths)
+	if err != nil {
+		return err
+	}
+	d.ApplyOptions.DeleteFlags.FileNameFlags = &fileNameFlags
+	err = d.ApplyOptions.Complete(d.factory, cmd)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
+	}
+	err = d.PruneOptions.Initialize(d.factory)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+	d.mapper, err = d.factory.ToRESTMapper()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating RESTMapper", 1)
+	}
+	d.client, err = d.factory.DynamicClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating dynamic client", 1)
+	}
+	discoveryClient, err := d.factory.ToDiscoveryClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating discovery client", 1)
+	}
+	d.dryRunVerifier = &apply.DryRunVerifier{
+		Finder:        util.NewCRDFinder(util.CRDFromDynamic(d.client)),
+		OpenAPIGetter: discoveryClient,
+	}
+	return nil
+}
+
+// SetFlags configures the command line flags needed for diff.
+// This is a temporary solution as we should separate the configuration
+// of cobra flags from the Differ.
+func (d *Differ) SetFlags(cmd *cobra.Command) error {
+	return addPackageFlags(cmd, d.ApplyOptions.DeleteFlags)
+}
+
+// Run compares the package with the cluster. Nothing in the cluster
+// is changed.
+func (d *Differ) Run() (*DiffResult, error) {
+	infos, err := readPackage(d.ApplyOptions)
+	if err != nil {
+		return nil, err
+	}
+	result := &DiffResult{}
+	for _, info := range infos {
+		// The grouping object is changed by every apply that
+		// changes the package, so it is not interesting.
+		if prune.IsGroupingObject(info.Object) {
+			continue
+		}
+		objDiff, err := d.diffInfo(info)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error computing diff for "+info.Name, 1)
+		}
+		result.Objects = append(result.Objects, *objDiff)
+	}
+
+	pruneSet, _, err := d.PruneOptions.RetrievePruneSet(infos)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error computing prune set", 1)
+	}
+	for _, id := range sortedIdentifiers(pruneSet) {
+		mapping, err := d.mapper.RESTMapping(id.GroupKind)
+		if err != nil {
+			return nil, err
+		}
+		live, err := d.client.Resource(mapping.Resource).Namespace(id.Namespace).
+			Get(id.Name, metav1.GetOptions{})
+		if err != nil {
+			// Objects that are already gone will not be pruned.
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		diff, err := unifiedDiff(id, live, nil)
+		if err != nil {
+			return nil, err
+		}
+		result.Objects = append(result.Objects, ObjectDiff{
+			Identifier: id,
+			Action:     DiffPruned,
+			Diff:       diff,
+		})
+	}
+	return result, nil
+}
+
+// diffInfo computes the merged version of the object with a dry-run
+// apply, retrying if the live object changes in the meantime.
+func (d *Differ) diffInfo(info *resource.Info) (*ObjectDiff, error) {
+	gvk := info.Object.GetObjectKind().GroupVersionKind()
+	if err := d.dryRunVerifier.HasSupport(gvk); err != nil {
+		return nil, err
+	}
+	id := object.ObjMetadata{
+		GroupKind: gvk.GroupKind(),
+		Namespace: info.Namespace,
+		Name:      info.Name,
+	}
+	local := info.Object.DeepCopyObject()
+	var merged runtime.Object
+	var err error
+	for i := 1; i <= maxDiffRetries; i++ {
+		if err = info.Get(); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, err
+			}
+			info.Object = nil
+		}
+		obj := kubectldiff.InfoObject{
+			LocalObj:        local,
+			Info:            info,
+			Encoder:         scheme.DefaultJSONEncoder(),
+			OpenAPI:         d.ApplyOptions.OpenAPISchema,
+			Force:           i == maxDiffRetries,
+			ServerSideApply: d.ApplyOptions.ServerSideApply,
+			ForceConflicts:  d.ApplyOptions.ForceConflicts,
+			IOStreams:       d.ioStreams,
+		}
+		merged, err = obj.Merged()
+		if !apierrors.IsConflict(err) {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return diffObjects(id, info.Object, merged)
+}
+
+// diffObjects compares the live object with the merged object. The live
+// object is nil if the object does not exist.
+func diffObjects(id object.ObjMetadata, live, merged runtime.Object) (*ObjectDiff, error) {
+	diff, err := unifiedDiff(id, live, merged)
+	if err != nil {
+		return nil, err
+	}
+	objDiff := &ObjectDiff{
+		Identifier: id,
+		Action:     DiffConfigured,
+		Diff:       diff,
+	}
+	switch {
+	case live == nil:
+		objDiff.Action = DiffCreated
+	case diff == "":
+		objDiff.Action = DiffUnchanged
+	}
+	return objDiff, nil
+}
+
+// unifiedDiff returns the unified diff between the YAML of the two
+// objects. Either of them can be nil.
+func unifiedDiff(id object.ObjMetadata, from, to runtime.Object) (string, error) {
+	fromYAML, err := objectYAML(from)
+	if err != nil {
+		return "", err
+	}
+	toYAML, err := objectYAML(to)
+	if err != nil {
+		return "", err
+	}
+	if fromYAML == toYAML {
+		return "", nil
+	}
+	name := identifierString(id)
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(fromYAML),
+		B:        splitLines(toYAML),
+		FromFile: "live/" + name,
+		ToFile:   "merged/" + name,
+		Context:  3,
+	})
+}
+
+// splitLines splits the text into lines, keeping the line endings.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return difflib.SplitLines(strings.TrimSuffix(text, "\n"))
+}
+
+// objectYAML returns the object as YAML. The managed fields are left
+// out, since they change with every apply and are hard to read.
+func objectYAML(obj runtime.Object) (string, error) {
+	if obj == nil {
+		return "", nil
+	}
+	obj = obj.DeepCopyObject()
+	if accessor, err := meta.Accessor(obj); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestDiffObjects(t *testing.T) {
+	id := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Pod"},
+		Namespace: namespace,
+		Name:      "pod-1",
+	}
+	withManagedFields := podInfo("pod-1", "app:v1").Object.(*unstructured.Unstructured)
+	withManagedFields.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationUpdate},
+	})
+
+	testCases := map[string]struct {
+		live           runtime.Object
+		merged         runtime.Object
+		expectedAction DiffAction
+		expectedDiff   string
+	}{
+		"object is created": {
+			live:           nil,
+			merged:         podInfo("pod-1", "app:v1").Object,
+			expectedAction: DiffCreated,
+			expectedDiff: `--- live/test-namespace/pod/pod-1
++++ merged/test-namespace/pod/pod-1
+@@ -0,0 +1,9 @@
++apiVersion: v1
++kind: Pod
++metadata:
++  name: pod-1
++  namespace: test-namespace
++spec:
++  containers:
++  - image: app:v1
++    name: app
+`,
+		},
+		"object is changed": {
+			live:           podInfo("pod-1", "app:v1").Object,
+			merged:         podInfo("pod-1", "app:v2").Object,
+			expectedAction: DiffConfigured,
+			expectedDiff: `--- live/test-namespace/pod/pod-1
++++ merged/test-namespace/pod/pod-1
+@@ -5,5 +5,5 @@
+   namespace: test-namespace
+ spec:
+   containers:
+-  - image: app:v1
++  - image: app:v2
+     name: app
+`,
+		},
+		"managed fields are ignored": {
+			live:           podInfo("pod-1", "app:v1").Object,
+			merged:         withManagedFields,
+			expectedAction: DiffUnchanged,
+			expectedDiff:   "",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			objDiff, err := diffObjects(id, tc.live, tc.merged)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expectedAction, objDiff.Action)
+			assert.Equal(t, tc.expectedDiff, objDiff.Diff)
+		})
+	}
+}
+
+func TestDiffResultHasChanges(t *testing.T) {
+	unchanged := ObjectDiff{Action: DiffUnchanged}
+	pruned := ObjectDiff{Action: DiffPruned}
+
+	assert.False(t, (&DiffResult{}).HasChanges())
+	assert.False(t, (&DiffResult{Objects: []ObjectDiff{unchanged}}).HasChanges())
+	assert.True(t, (&DiffResult{Objects: []ObjectDiff{unchanged, pruned}}).HasChanges())
+}
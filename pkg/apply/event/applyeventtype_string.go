@@ -13,11 +13,13 @@ func _() {
 	var x [1]struct{}
 	_ = x[ApplyEventResourceUpdate-0]
 	_ = x[ApplyEventCompleted-1]
+	_ = x[ApplyEventResourceCancelled-2]
+	_ = x[ApplyEventResourceFailed-3]
 }
 
-const _ApplyEventType_name = "ApplyEventResourceUpdateApplyEventCompleted"
+const _ApplyEventType_name = "ApplyEventResourceUpdateApplyEventCompletedApplyEventResourceCancelledApplyEventResourceFailed"
 
-var _ApplyEventType_index = [...]uint8{0, 24, 43}
+var _ApplyEventType_index = [...]uint8{0, 24, 43, 70, 94}
 
 func (i ApplyEventType) String() string {
 	if i < 0 || i >= ApplyEventType(len(_ApplyEventType_index)-1) {
@@ -13,11 +13,15 @@ func _() {
 	var x [1]struct{}
 	_ = x[DeleteEventResourceUpdate-0]
 	_ = x[DeleteEventCompleted-1]
+	_ = x[DeleteEventResourceCancelled-2]
+	_ = x[DeleteEventResourceSkipped-3]
+	_ = x[DeleteEventResourceTerminating-4]
+	_ = x[DeleteEventFinalizersRemoved-5]
 }
 
-const _DeleteEventType_name = "DeleteEventResourceUpdateDeleteEventCompleted"
+const _DeleteEventType_name = "DeleteEventResourceUpdateDeleteEventCompletedDeleteEventResourceCancelledDeleteEventResourceSkippedDeleteEventResourceTerminatingDeleteEventFinalizersRemoved"
 
-var _DeleteEventType_index = [...]uint8{0, 25, 45}
+var _DeleteEventType_index = [...]uint8{0, 25, 45, 73, 99, 129, 157}
 
 func (i DeleteEventType) String() string {
 	if i < 0 || i >= DeleteEventType(len(_DeleteEventType_index)-1) {
@@ -4,6 +4,8 @@
 package event
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
@@ -20,6 +22,7 @@ const (
 	StatusType
 	PruneType
 	DeleteType
+	RetryType
 )
 
 // Event is the type of the objects that will be returned through
@@ -52,6 +55,10 @@ type Event struct {
 	// DeleteEvent contains information about object that have been
 	// deleted.
 	DeleteEvent DeleteEvent
+
+	// RetryEvent contains information about a call to the cluster
+	// that failed and will be retried.
+	RetryEvent RetryEvent
 }
 
 type InitEvent struct {
@@ -81,6 +88,13 @@ type ApplyEventType int
 const (
 	ApplyEventResourceUpdate ApplyEventType = iota
 	ApplyEventCompleted
+	// ApplyEventResourceCancelled is sent for every resource that
+	// was not applied because the operation was cancelled.
+	ApplyEventResourceCancelled
+	// ApplyEventResourceFailed is sent for every resource that was
+	// rejected by the server, when errors are reported per resource
+	// rather than stopping the apply, as for a server-side dry-run.
+	ApplyEventResourceFailed
 )
 
 //go:generate stringer -type=ApplyEventOperation
@@ -97,6 +111,18 @@ type ApplyEvent struct {
 	Type      ApplyEventType
 	Operation ApplyEventOperation
 	Object    runtime.Object
+	// Error is the error from the server for an ApplyEventResourceFailed
+	// event, for example a rejection by an admission webhook or a change
+	// to an immutable field.
+	Error error
+	// DefaultedFields are the paths of the fields that were not in the
+	// manifest, but were set by the server. They are only reported for
+	// a server-side dry-run.
+	DefaultedFields []string
+	// ChangedFields are the paths of the fields that applying the
+	// resource changes in the live resource. They are only reported
+	// for a preview, for resources that are configured.
+	ChangedFields []string
 }
 
 //go:generate stringer -type=PruneEventType
@@ -105,6 +131,17 @@ type PruneEventType int
 const (
 	PruneEventResourceUpdate PruneEventType = iota
 	PruneEventCompleted
+	// PruneEventResourceCancelled is sent for every resource that
+	// was not pruned because the operation was cancelled.
+	PruneEventResourceCancelled
+	// PruneEventResourceSkipped is sent for every resource in the
+	// inventory that was not pruned because it no longer exists.
+	PruneEventResourceSkipped
+	// PruneEventFinalizersRemoved is sent for every resource whose
+	// finalizers were removed, since it was still terminating after
+	// it was deleted. The Object is the live resource before the
+	// finalizers were removed.
+	PruneEventFinalizersRemoved
 )
 
 type PruneEvent struct {
@@ -118,9 +155,40 @@ type DeleteEventType int
 const (
 	DeleteEventResourceUpdate DeleteEventType = iota
 	DeleteEventCompleted
+	// DeleteEventResourceCancelled is sent for every resource that
+	// was not deleted because the operation was cancelled.
+	DeleteEventResourceCancelled
+	// DeleteEventResourceSkipped is sent for every resource in the
+	// inventory that was not deleted because it no longer exists.
+	DeleteEventResourceSkipped
+	// DeleteEventResourceTerminating is sent for every resource that
+	// was deleted, but still existed when waiting for the deletion
+	// timed out. The Object is the live resource, so the finalizers
+	// that block the deletion can be found.
+	DeleteEventResourceTerminating
+	// DeleteEventFinalizersRemoved is sent for every resource whose
+	// finalizers were forcibly removed, since it was still
+	// terminating after it was deleted. The Object is the live
+	// resource before the finalizers were removed.
+	DeleteEventFinalizersRemoved
 )
 
 type DeleteEvent struct {
 	Type   DeleteEventType
 	Object runtime.Object
 }
+
+// RetryEvent is sent every time applying or pruning a resource
+// fails with an error that will be retried.
+type RetryEvent struct {
+	// Action is the action that failed.
+	Action ResourceAction
+	// Identifier identifies the resource the action was performed on.
+	Identifier object.ObjMetadata
+	// Attempt is the number of the attempt that failed, starting at 1.
+	Attempt int
+	// Backoff is how long it will wait before the next attempt.
+	Backoff time.Duration
+	// Err is the error from the failed attempt.
+	Err error
+}
@@ -13,11 +13,14 @@ func _() {
 	var x [1]struct{}
 	_ = x[PruneEventResourceUpdate-0]
 	_ = x[PruneEventCompleted-1]
+	_ = x[PruneEventResourceCancelled-2]
+	_ = x[PruneEventResourceSkipped-3]
+	_ = x[PruneEventFinalizersRemoved-4]
 }
 
-const _PruneEventType_name = "PruneEventResourceUpdatePruneEventCompleted"
+const _PruneEventType_name = "PruneEventResourceUpdatePruneEventCompletedPruneEventResourceCancelledPruneEventResourceSkippedPruneEventFinalizersRemoved"
 
-var _PruneEventType_index = [...]uint8{0, 24, 43}
+var _PruneEventType_index = [...]uint8{0, 24, 43, 70, 95, 122}
 
 func (i PruneEventType) String() string {
 	if i < 0 || i >= PruneEventType(len(_PruneEventType_index)-1) {
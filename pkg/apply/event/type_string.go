@@ -17,11 +17,12 @@ func _() {
 	_ = x[StatusType-3]
 	_ = x[PruneType-4]
 	_ = x[DeleteType-5]
+	_ = x[RetryType-6]
 }
 
-const _Type_name = "InitTypeErrorTypeApplyTypeStatusTypePruneTypeDeleteType"
+const _Type_name = "InitTypeErrorTypeApplyTypeStatusTypePruneTypeDeleteTypeRetryType"
 
-var _Type_index = [...]uint8{0, 8, 17, 26, 36, 45, 55}
+var _Type_index = [...]uint8{0, 8, 17, 26, 36, 45, 55, 64}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
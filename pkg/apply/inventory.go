@@ -0,0 +1,345 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-errors/errors"
+	"github.com/spf13/cobra"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/apply/poller"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling"
+	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewInventoryInspector returns a new InventoryInspector. It will set up
+// the ApplyOptions and PruneOptions which are responsible for capturing
+// any command line flags.
+func NewInventoryInspector(factory util.Factory, ioStreams genericclioptions.IOStreams) *InventoryInspector {
+	return &InventoryInspector{
+		ApplyOptions: apply.NewApplyOptions(ioStreams),
+		PruneOptions: prune.NewPruneOptions(),
+		factory:      factory,
+		ioStreams:    ioStreams,
+	}
+}
+
+// InventoryInspector reads the inventory of a package from the cluster,
+// so it can be compared with the local package and the live objects.
+type InventoryInspector struct {
+	factory      util.Factory
+	ioStreams    genericclioptions.IOStreams
+	ApplyOptions *apply.ApplyOptions
+	PruneOptions *prune.PruneOptions
+	statusPoller poller.Poller
+	client       dynamic.Interface
+	discovery    discovery.DiscoveryInterface
+}
+
+// TrackedObject is an object tracked by the inventory in the cluster,
+// together with its live status.
+type TrackedObject struct {
+	Identifier object.ObjMetadata
+	Status     status.Status
+	Message    string
+}
+
+// InventoryDiff is the difference between the objects in the local
+// package and the objects tracked by the inventory in the cluster.
+type InventoryDiff struct {
+	// Added are the objects in the package that are not tracked yet.
+	Added []object.ObjMetadata
+	// Unchanged are the objects both in the package and tracked.
+	Unchanged []object.ObjMetadata
+	// Pruned are the tracked objects that are no longer in the
+	// package, and which will be pruned by the next apply.
+	Pruned []object.ObjMetadata
+}
+
+// Initialize sets up the InventoryInspector for reading the inventory
+// from a cluster. This involves validating command line inputs and
+// configuring clients for communicating with the cluster.
+func (i *InventoryInspector) Initialize(cmd *cobra.Command, paths []string) error {
+	fileNameFlags, err := demandOneDirectory(paths)
+	if err != nil {
+		return err
+	}
+	i.ApplyOptions.DeleteFlags.FileNameFlags = &fileNameFlags
+	err = i.ApplyOptions.Complete(i.factory, cmd)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
+	}
+	err = i.PruneOptions.Initialize(i.factory)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+	i.statusPoller, err = newStatusPoller(i.factory)
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating resolver", 1)
+	}
+	i.client, err = i.factory.DynamicClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating dynamic client", 1)
+	}
+	i.discovery, err = i.factory.ToDiscoveryClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating discovery client", 1)
+	}
+	return nil
+}
+
+// SetFlags configures the command line flags needed for reading
+// the package. This is a temporary solution as we should separate
+// the configuration of cobra flags from the InventoryInspector.
+func (i *InventoryInspector) SetFlags(cmd *cobra.Command) error {
+	return addPackageFlags(cmd, i.ApplyOptions.DeleteFlags)
+}
+
+// List returns the objects tracked by the inventory in the cluster,
+// with the status they have right now.
+func (i *InventoryInspector) List(ctx context.Context) ([]TrackedObject, error) {
+	_, tracked, err := i.readInventory()
+	if err != nil {
+		return nil, err
+	}
+	ids := sortedIdentifiers(tracked)
+	statuses := i.pollOnce(ctx, ids)
+	var objs []TrackedObject
+	for _, id := range ids {
+		obj := TrackedObject{
+			Identifier: id,
+			Status:     status.UnknownStatus,
+		}
+		if rs, found := statuses[id]; found {
+			obj.Status = rs.Status
+			obj.Message = rs.Message
+			if rs.Error != nil {
+				obj.Message = rs.Error.Error()
+			}
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// Diff compares the objects in the local package with the objects
+// tracked by the inventory in the cluster.
+func (i *InventoryInspector) Diff() (*InventoryDiff, error) {
+	infos, tracked, err := i.readInventory()
+	if err != nil {
+		return nil, err
+	}
+	local, err := prune.RetrieveInventoryFromGroupingObj(infos)
+	if err != nil {
+		return nil, err
+	}
+	return diffInventories(local, tracked), nil
+}
+
+// Orphans returns the objects in the cluster that have the inventory
+// label of the package, but are not tracked by any of its inventories
+// in the cluster. These are objects that were labelled in their
+// manifests, since the Applier doesn't add the label to the objects it
+// applies, and grouping objects that are not found when looking up the
+// inventory, such as a ClusterInventory left behind when the package
+// switched to a ConfigMap. This looks through every resource type that
+// can be listed, so it makes a request for each of them.
+func (i *InventoryInspector) Orphans() ([]object.ObjMetadata, error) {
+	infos, err := readPackage(i.ApplyOptions)
+	if err != nil {
+		return nil, err
+	}
+	groupingObjects, err := i.PruneOptions.RetrieveGroupingObjects(infos)
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error retrieving inventory", 1)
+	}
+	trackedInv, err := prune.UnionInventory(groupingObjects)
+	if err != nil {
+		return nil, err
+	}
+	tracked := make(map[object.ObjMetadata]bool)
+	for _, id := range trackedInv.GetItems() {
+		tracked[*id] = true
+	}
+	for _, info := range groupingObjects {
+		gk := info.Object.GetObjectKind().GroupVersionKind().GroupKind()
+		id, err := object.CreateObjMetadata(info.Namespace, info.Name, gk)
+		if err != nil {
+			return nil, err
+		}
+		tracked[*id] = true
+	}
+	groupingObject, _ := prune.FindGroupingObject(infos)
+	groupingLabel, err := prune.RetrieveGroupingLabel(groupingObject.Object)
+	if err != nil {
+		return nil, err
+	}
+	labelled, err := listLabelled(i.discovery, i.client, prune.GroupingLabel+"="+groupingLabel)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []object.ObjMetadata
+	for _, id := range labelled {
+		if tracked[id] {
+			continue
+		}
+		orphans = append(orphans, id)
+	}
+	sortIdentifiers(orphans)
+	return orphans, nil
+}
+
+// readPackage reads the local package, including the grouping object
+// with the inventory of the package.
+func readPackage(ao *apply.ApplyOptions) ([]*resource.Info, error) {
+	infos, err := ao.GetObjects()
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error reading resource manifests", 1)
+	}
+	return prepareObjects(infos)
+}
+
+// readInventory reads the local package, and returns it together with
+// the union of the inventories in the cluster for the package.
+func (i *InventoryInspector) readInventory() ([]*resource.Info, []*object.ObjMetadata, error) {
+	infos, err := readPackage(i.ApplyOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+	groupingObjects, err := i.PruneOptions.RetrieveGroupingObjects(infos)
+	if err != nil {
+		return nil, nil, errors.WrapPrefix(err, "error retrieving inventory", 1)
+	}
+	tracked, err := prune.UnionInventory(groupingObjects)
+	if err != nil {
+		return nil, nil, err
+	}
+	return infos, tracked.GetItems(), nil
+}
+
+// pollOnce computes the status of all the identified resources a single
+// time. Resources whose status could not be computed before the context
+// is cancelled are left out.
+func (i *InventoryInspector) pollOnce(ctx context.Context, ids []object.ObjMetadata) map[object.ObjMetadata]*pollevent.ResourceStatus {
+	statuses := make(map[object.ObjMetadata]*pollevent.ResourceStatus)
+	if len(ids) == 0 {
+		return statuses
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	eventChannel := i.statusPoller.Poll(ctx, ids, polling.Options{
+		PollUntilCancelled: true,
+		UseCache:           true,
+		DesiredStatus:      status.CurrentStatus,
+	})
+	for e := range eventChannel {
+		if e.EventType != pollevent.ResourceUpdateEvent {
+			continue
+		}
+		statuses[e.Resource.Identifier] = e.Resource
+		if len(statuses) == len(ids) {
+			cancel()
+		}
+	}
+	return statuses
+}
+
+// listLabelled returns the identifiers of all objects in the cluster
+// that match the label selector, in the preferred version of every
+// resource type that can be listed. Resource types that can not be
+// listed, for example because of RBAC, are skipped.
+func listLabelled(d discovery.DiscoveryInterface, client dynamic.Interface,
+	labelSelector string) ([]object.ObjMetadata, error) {
+	resourceLists, err := discovery.ServerPreferredResources(d)
+	// Some API groups might not be available. Use the ones that are.
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, errors.WrapPrefix(err, "error discovering resources", 1)
+	}
+	resourceLists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, resourceLists)
+	var ids []object.ObjMetadata
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range resourceList.APIResources {
+			list, err := client.Resource(gv.WithResource(r.Name)).Namespace(metav1.NamespaceAll).List(metav1.ListOptions{
+				LabelSelector: labelSelector,
+			})
+			if err != nil {
+				if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+					continue
+				}
+				return nil, errors.WrapPrefix(err, "error listing "+r.Name, 1)
+			}
+			for _, item := range list.Items {
+				ids = append(ids, object.ObjMetadata{
+					GroupKind: schema.GroupKind{Group: gv.Group, Kind: r.Kind},
+					Namespace: item.GetNamespace(),
+					Name:      item.GetName(),
+				})
+			}
+		}
+	}
+	return ids, nil
+}
+
+// diffInventories compares the local inventory with the inventory
+// tracked in the cluster.
+func diffInventories(local, tracked []*object.ObjMetadata) *InventoryDiff {
+	localSet := sets.NewString()
+	for _, id := range local {
+		localSet.Insert(id.String())
+	}
+	trackedSet := sets.NewString()
+	for _, id := range tracked {
+		trackedSet.Insert(id.String())
+	}
+	diff := &InventoryDiff{}
+	for _, id := range local {
+		if trackedSet.Has(id.String()) {
+			diff.Unchanged = append(diff.Unchanged, *id)
+		} else {
+			diff.Added = append(diff.Added, *id)
+		}
+	}
+	for _, id := range tracked {
+		if !localSet.Has(id.String()) {
+			diff.Pruned = append(diff.Pruned, *id)
+		}
+	}
+	sortIdentifiers(diff.Added)
+	sortIdentifiers(diff.Unchanged)
+	sortIdentifiers(diff.Pruned)
+	return diff
+}
+
+func sortedIdentifiers(ids []*object.ObjMetadata) []object.ObjMetadata {
+	result := make([]object.ObjMetadata, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, *id)
+	}
+	sortIdentifiers(result)
+	return result
+}
+
+func sortIdentifiers(ids []object.ObjMetadata) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestDiffInventories(t *testing.T) {
+	deployment := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Namespace: "foo",
+		Name:      "deployment",
+	}
+	service := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Service"},
+		Namespace: "bar",
+		Name:      "service",
+	}
+	clusterRole := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"},
+		Name:      "cluster-role",
+	}
+
+	testCases := map[string]struct {
+		local     []object.ObjMetadata
+		tracked   []object.ObjMetadata
+		added     []object.ObjMetadata
+		unchanged []object.ObjMetadata
+		pruned    []object.ObjMetadata
+	}{
+		"nothing tracked yet": {
+			local: []object.ObjMetadata{deployment, service},
+			added: []object.ObjMetadata{service, deployment},
+		},
+		"same objects": {
+			local:     []object.ObjMetadata{deployment, clusterRole},
+			tracked:   []object.ObjMetadata{clusterRole, deployment},
+			unchanged: []object.ObjMetadata{clusterRole, deployment},
+		},
+		"objects added and removed": {
+			local:     []object.ObjMetadata{deployment, service},
+			tracked:   []object.ObjMetadata{clusterRole, deployment},
+			added:     []object.ObjMetadata{service},
+			unchanged: []object.ObjMetadata{deployment},
+			pruned:    []object.ObjMetadata{clusterRole},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			diff := diffInventories(toPointers(tc.local), toPointers(tc.tracked))
+			assert.Equal(t, tc.added, diff.Added)
+			assert.Equal(t, tc.unchanged, diff.Unchanged)
+			assert.Equal(t, tc.pruned, diff.Pruned)
+		})
+	}
+}
+
+func TestListLabelled(t *testing.T) {
+	labelled := func(apiVersion, kind, namespace, name, label string) runtime.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		u.SetLabels(map[string]string{prune.GroupingLabel: label})
+		return u
+	}
+	client := fakedynamic.NewSimpleDynamicClient(scheme.Scheme,
+		labelled("v1", "ConfigMap", "foo", "inventory", "app"),
+		labelled("v1", "ConfigMap", "foo", "other-inventory", "other"),
+		labelled("cli-utils.sigs.k8s.io/v1alpha1", "ClusterInventory", "", "cluster-inventory", "app"),
+		labelled("v1", "Pod", "bar", "pod", "app"),
+		labelled("v1", "Pod", "bar", "other-pod", "other"),
+	)
+	listVerbs := metav1.Verbs{"get", "list"}
+	discoveryClient := &fakediscovery.FakeDiscovery{
+		Fake: &clienttesting.Fake{
+			Resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{
+						{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: listVerbs},
+						{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: listVerbs},
+						{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get"}},
+					},
+				},
+				{
+					GroupVersion: "cli-utils.sigs.k8s.io/v1alpha1",
+					APIResources: []metav1.APIResource{
+						{Name: "clusterinventories", Kind: "ClusterInventory", Verbs: listVerbs},
+					},
+				},
+			},
+		},
+	}
+
+	ids, err := listLabelled(discoveryClient, client, prune.GroupingLabel+"=app")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sortIdentifiers(ids)
+	assert.Equal(t, []object.ObjMetadata{
+		{GroupKind: prune.ClusterInventoryGroupKind, Name: "cluster-inventory"},
+		{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "bar", Name: "pod"},
+		{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Namespace: "foo", Name: "inventory"},
+	}, ids)
+}
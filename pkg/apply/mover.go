@@ -0,0 +1,395 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-errors/errors"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"k8s.io/kubectl/pkg/cmd/util"
+	kubectlutil "k8s.io/kubectl/pkg/util"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// NewInventoryMover returns a new InventoryMover. It will set up the
+// ApplyOptions for both packages and the PruneOptions which are
+// responsible for capturing any command line flags.
+func NewInventoryMover(factory util.Factory, ioStreams genericclioptions.IOStreams) *InventoryMover {
+	return &InventoryMover{
+		FromOptions:  apply.NewApplyOptions(ioStreams),
+		ToOptions:    apply.NewApplyOptions(ioStreams),
+		PruneOptions: prune.NewPruneOptions(),
+		factory:      factory,
+		ioStreams:    ioStreams,
+	}
+}
+
+// InventoryMover moves objects from the inventory of one package to
+// the inventory of another one, for example when a package is split
+// in two. Without this, the next apply of the package the objects were
+// moved from would prune them.
+//
+// The inventories can not be updated in a single request, so the
+// objects are first added to the inventory of the package they are
+// moved to, and then removed from the inventory of the package they
+// are moved from. That way the objects are always tracked by at least
+// one of the inventories, so they are never pruned even if the move
+// is interrupted. Moving the same objects again completes the move.
+type InventoryMover struct {
+	factory      util.Factory
+	ioStreams    genericclioptions.IOStreams
+	FromOptions  *apply.ApplyOptions
+	ToOptions    *apply.ApplyOptions
+	PruneOptions *prune.PruneOptions
+	client       dynamic.Interface
+
+	// The packages are read when they are first needed.
+	fromInfos []*resource.Info
+	toInfos   []*resource.Info
+}
+
+// Initialize sets up the InventoryMover for reading both packages and
+// updating their inventories in the cluster. This involves validating
+// command line inputs and configuring clients for communicating with
+// the cluster.
+func (m *InventoryMover) Initialize(cmd *cobra.Command, fromPath, toPath string) error {
+	for _, p := range []struct {
+		path string
+		ao   *apply.ApplyOptions
+	}{
+		{path: fromPath, ao: m.FromOptions},
+		{path: toPath, ao: m.ToOptions},
+	} {
+		fileNameFlags, err := demandOneDirectory([]string{p.path})
+		if err != nil {
+			return err
+		}
+		p.ao.DeleteFlags.FileNameFlags = &fileNameFlags
+		err = p.ao.Complete(m.factory, cmd)
+		if err != nil {
+			return errors.WrapPrefix(err, "error setting up ApplyOptions", 1)
+		}
+	}
+	err := m.PruneOptions.Initialize(m.factory)
+	if err != nil {
+		return errors.WrapPrefix(err, "error setting up PruneOptions", 1)
+	}
+	m.client, err = m.factory.DynamicClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating dynamic client", 1)
+	}
+	return nil
+}
+
+// SetFlags configures the command line flags needed for reading the
+// packages. The directories are passed to Initialize, so the flags for
+// selecting files are hidden. This is a temporary solution as we should
+// separate the configuration of cobra flags from the InventoryMover.
+func (m *InventoryMover) SetFlags(cmd *cobra.Command) error {
+	return addPackageFlags(cmd, m.FromOptions.DeleteFlags)
+}
+
+// Resolve returns the identifiers of the objects in the package they
+// are moved to that match the references. A reference has the form
+// KIND[.GROUP]/NAME, or NAMESPACE/KIND[.GROUP]/NAME if objects with the
+// same kind and name exist in several namespaces. The kind is not case
+// sensitive.
+func (m *InventoryMover) Resolve(refs []string) ([]object.ObjMetadata, error) {
+	infos, err := m.readTo()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]object.ObjMetadata, 0, len(refs))
+	for _, ref := range refs {
+		id, err := resolveReference(ref, infos)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Move moves the identified objects to the inventory of the package
+// they are moved to. The manifests of the objects must already have
+// been moved, so they are expected to be in that package and not in
+// the package they are moved from. Returns an error without changing
+// any inventory if that is not the case, or if any of the objects is
+// not tracked by either of the inventories in the cluster.
+func (m *InventoryMover) Move(ids []object.ObjMetadata) error {
+	fromInfos, err := m.readFrom()
+	if err != nil {
+		return err
+	}
+	toInfos, err := m.readTo()
+	if err != nil {
+		return err
+	}
+	fromLabel, err := groupingLabelOf(fromInfos)
+	if err != nil {
+		return err
+	}
+	toLabel, err := groupingLabelOf(toInfos)
+	if err != nil {
+		return err
+	}
+	if fromLabel == toLabel {
+		return errors.Errorf("both packages have the same inventory-id: %s", fromLabel)
+	}
+
+	fromLocal, err := prune.RetrieveInventoryFromGroupingObj(fromInfos)
+	if err != nil {
+		return err
+	}
+	toLocal, err := prune.RetrieveInventoryFromGroupingObj(toInfos)
+	if err != nil {
+		return err
+	}
+	fromGroupingObjects, err := m.PruneOptions.RetrieveGroupingObjects(fromInfos)
+	if err != nil {
+		return errors.WrapPrefix(err, "error retrieving inventory", 1)
+	}
+	fromTracked, err := prune.UnionInventory(fromGroupingObjects)
+	if err != nil {
+		return err
+	}
+	toGroupingObjects, err := m.PruneOptions.RetrieveGroupingObjects(toInfos)
+	if err != nil {
+		return errors.WrapPrefix(err, "error retrieving inventory", 1)
+	}
+	toTracked, err := prune.UnionInventory(toGroupingObjects)
+	if err != nil {
+		return err
+	}
+	err = checkMove(ids, fromLocal, toLocal, fromTracked.GetItems(), toTracked.GetItems())
+	if err != nil {
+		return err
+	}
+
+	// Add the objects to the new inventory before removing them from
+	// the old one, so they are always tracked by one of them.
+	if err := m.addToInventory(toInfos, toGroupingObjects, ids); err != nil {
+		return errors.WrapPrefix(err, "error adding objects to inventory", 1)
+	}
+	if err := m.removeFromInventory(fromGroupingObjects, ids); err != nil {
+		return errors.WrapPrefix(err, "error removing objects from inventory", 1)
+	}
+	return nil
+}
+
+// addToInventory adds the objects to the grouping object in the cluster
+// with the same name as the current grouping object of the package, or
+// to any other one if it has not been applied. If the package has no
+// inventory in the cluster yet, a grouping object that only tracks the
+// moved objects is created.
+func (m *InventoryMover) addToInventory(infos, groupingObjects []*resource.Info, ids []object.ObjMetadata) error {
+	current, _ := prune.FindGroupingObject(infos)
+	if len(groupingObjects) == 0 {
+		groupingObj := current.Object.(*unstructured.Unstructured).DeepCopy()
+		groupingInfo := &resource.Info{
+			Mapping:   current.Mapping,
+			Namespace: current.Namespace,
+			Name:      current.Name,
+			Object:    groupingObj,
+		}
+		if err := prune.SetGroupingObjInventory(groupingInfo, toPointers(ids)); err != nil {
+			return err
+		}
+		// Store the configuration the same way as kubectl apply does, so
+		// the next apply can update the grouping object without warnings.
+		if err := kubectlutil.CreateApplyAnnotation(groupingObj, unstructured.UnstructuredJSONScheme); err != nil {
+			return err
+		}
+		_, err := m.client.Resource(groupingInfo.Mapping.Resource).Namespace(groupingInfo.Namespace).
+			Create(groupingObj, metav1.CreateOptions{})
+		return err
+	}
+	target := groupingObjects[0]
+	for _, info := range groupingObjects {
+		if info.Name == current.Name && info.Namespace == current.Namespace {
+			target = info
+		}
+	}
+	inv, err := prune.RetrieveInventoryFromGroupingObj([]*resource.Info{target})
+	if err != nil {
+		return err
+	}
+	updated := prune.NewInventory(inv)
+	updated.AddItems(toPointers(ids))
+	if updated.Size() == len(inv) {
+		return nil
+	}
+	return m.updateGroupingObject(target, updated.GetItems())
+}
+
+// removeFromInventory removes the objects from every grouping object
+// that tracks them.
+func (m *InventoryMover) removeFromInventory(groupingObjects []*resource.Info, ids []object.ObjMetadata) error {
+	for _, info := range groupingObjects {
+		inv, err := prune.RetrieveInventoryFromGroupingObj([]*resource.Info{info})
+		if err != nil {
+			return err
+		}
+		updated := prune.NewInventory(inv)
+		removed := false
+		for _, id := range toPointers(ids) {
+			if updated.DeleteItem(id) {
+				removed = true
+			}
+		}
+		if !removed {
+			continue
+		}
+		if err := m.updateGroupingObject(info, updated.GetItems()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateGroupingObject stores the inventory in the grouping object in
+// the cluster. The update fails if the grouping object has been changed
+// since it was retrieved.
+func (m *InventoryMover) updateGroupingObject(info *resource.Info, inv []*object.ObjMetadata) error {
+	if err := prune.SetGroupingObjInventory(info, inv); err != nil {
+		return err
+	}
+	groupingObj := info.Object.(*unstructured.Unstructured)
+	_, err := m.client.Resource(info.Mapping.Resource).Namespace(info.Namespace).
+		Update(groupingObj, metav1.UpdateOptions{})
+	return err
+}
+
+func (m *InventoryMover) readFrom() ([]*resource.Info, error) {
+	if m.fromInfos == nil {
+		infos, err := readPackage(m.FromOptions)
+		if err != nil {
+			return nil, err
+		}
+		m.fromInfos = infos
+	}
+	return m.fromInfos, nil
+}
+
+func (m *InventoryMover) readTo() ([]*resource.Info, error) {
+	if m.toInfos == nil {
+		infos, err := readPackage(m.ToOptions)
+		if err != nil {
+			return nil, err
+		}
+		m.toInfos = infos
+	}
+	return m.toInfos, nil
+}
+
+// groupingLabelOf returns the inventory-id of the grouping object
+// among the infos.
+func groupingLabelOf(infos []*resource.Info) (string, error) {
+	groupingObject, found := prune.FindGroupingObject(infos)
+	if !found {
+		return "", prune.NoGroupingObjError{}
+	}
+	return prune.RetrieveGroupingLabel(groupingObject.Object)
+}
+
+// checkMove verifies that the objects can be moved: their manifests
+// must have been moved to the new package, and they must be tracked by
+// the old inventory in the cluster. Objects that are already tracked by
+// the new inventory only are accepted, so an interrupted move can be
+// completed.
+func checkMove(ids []object.ObjMetadata, fromLocal, toLocal, fromTracked, toTracked []*object.ObjMetadata) error {
+	fromLocalSet := identifierSet(fromLocal)
+	toLocalSet := identifierSet(toLocal)
+	fromTrackedSet := identifierSet(fromTracked)
+	toTrackedSet := identifierSet(toTracked)
+	for _, id := range ids {
+		if fromLocalSet.Has(id.String()) {
+			return errors.Errorf("%s is still in the package it is moved from", identifierString(id))
+		}
+		if !toLocalSet.Has(id.String()) {
+			return errors.Errorf("%s is not in the package it is moved to", identifierString(id))
+		}
+		if !fromTrackedSet.Has(id.String()) && !toTrackedSet.Has(id.String()) {
+			return errors.Errorf("%s is not tracked by the inventory it is moved from", identifierString(id))
+		}
+	}
+	return nil
+}
+
+// resolveReference returns the identifier of the object among the infos
+// that matches the reference.
+func resolveReference(ref string, infos []*resource.Info) (object.ObjMetadata, error) {
+	parts := strings.Split(ref, "/")
+	var namespace, kind, name string
+	switch len(parts) {
+	case 2:
+		kind, name = parts[0], parts[1]
+	case 3:
+		namespace, kind, name = parts[0], parts[1], parts[2]
+	default:
+		return object.ObjMetadata{}, errors.Errorf(
+			"invalid object %q: must be KIND[.GROUP]/NAME or NAMESPACE/KIND[.GROUP]/NAME", ref)
+	}
+	var matches []object.ObjMetadata
+	for _, info := range infos {
+		if prune.IsGroupingObject(info.Object) || info.Name != name {
+			continue
+		}
+		if len(parts) == 3 && info.Namespace != namespace {
+			continue
+		}
+		gk := info.Object.GetObjectKind().GroupVersionKind().GroupKind()
+		if !strings.EqualFold(kind, gk.Kind) && !strings.EqualFold(kind, gk.String()) {
+			continue
+		}
+		matches = append(matches, object.ObjMetadata{
+			GroupKind: gk,
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		})
+	}
+	switch len(matches) {
+	case 0:
+		return object.ObjMetadata{}, errors.Errorf("object %q not found in the package it is moved to", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return object.ObjMetadata{}, errors.Errorf(
+			"object %q is ambiguous, prefix it with the namespace", ref)
+	}
+}
+
+func identifierSet(ids []*object.ObjMetadata) sets.String {
+	s := sets.NewString()
+	for _, id := range ids {
+		s.Insert(id.String())
+	}
+	return s
+}
+
+func identifierString(id object.ObjMetadata) string {
+	s := fmt.Sprintf("%s/%s", strings.ToLower(id.GroupKind.String()), id.Name)
+	if id.Namespace == "" {
+		return s
+	}
+	return id.Namespace + "/" + s
+}
+
+func toPointers(ids []object.ObjMetadata) []*object.ObjMetadata {
+	result := make([]*object.ObjMetadata, 0, len(ids))
+	for i := range ids {
+		result = append(result, &ids[i])
+	}
+	return result
+}
@@ -0,0 +1,144 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestCheckMove(t *testing.T) {
+	podGK := schema.GroupKind{Kind: "Pod"}
+	pod1 := object.ObjMetadata{GroupKind: podGK, Namespace: namespace, Name: "pod-1"}
+	pod2 := object.ObjMetadata{GroupKind: podGK, Namespace: namespace, Name: "pod-2"}
+	pod3 := object.ObjMetadata{GroupKind: podGK, Namespace: namespace, Name: "pod-3"}
+
+	testCases := map[string]struct {
+		ids         []object.ObjMetadata
+		fromLocal   []object.ObjMetadata
+		toLocal     []object.ObjMetadata
+		fromTracked []object.ObjMetadata
+		toTracked   []object.ObjMetadata
+		isError     bool
+	}{
+		"object moved between packages": {
+			ids:         []object.ObjMetadata{pod1},
+			fromLocal:   []object.ObjMetadata{pod2},
+			toLocal:     []object.ObjMetadata{pod1, pod3},
+			fromTracked: []object.ObjMetadata{pod1, pod2},
+			toTracked:   []object.ObjMetadata{pod3},
+			isError:     false,
+		},
+		"interrupted move can be completed": {
+			ids:         []object.ObjMetadata{pod1},
+			fromLocal:   []object.ObjMetadata{pod2},
+			toLocal:     []object.ObjMetadata{pod1},
+			fromTracked: []object.ObjMetadata{pod2},
+			toTracked:   []object.ObjMetadata{pod1},
+			isError:     false,
+		},
+		"object still in the old package": {
+			ids:         []object.ObjMetadata{pod1},
+			fromLocal:   []object.ObjMetadata{pod1, pod2},
+			toLocal:     []object.ObjMetadata{pod1},
+			fromTracked: []object.ObjMetadata{pod1, pod2},
+			isError:     true,
+		},
+		"object not in the new package": {
+			ids:         []object.ObjMetadata{pod1},
+			fromLocal:   []object.ObjMetadata{pod2},
+			toLocal:     []object.ObjMetadata{pod3},
+			fromTracked: []object.ObjMetadata{pod1, pod2},
+			isError:     true,
+		},
+		"object not tracked": {
+			ids:         []object.ObjMetadata{pod3},
+			fromLocal:   []object.ObjMetadata{pod2},
+			toLocal:     []object.ObjMetadata{pod3},
+			fromTracked: []object.ObjMetadata{pod2},
+			isError:     true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			err := checkMove(tc.ids, toPointers(tc.fromLocal), toPointers(tc.toLocal),
+				toPointers(tc.fromTracked), toPointers(tc.toTracked))
+			if tc.isError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResolveReference(t *testing.T) {
+	deployment := func(ns, name string) *resource.Info {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("apps/v1")
+		u.SetKind("Deployment")
+		u.SetNamespace(ns)
+		u.SetName(name)
+		return &resource.Info{Namespace: ns, Name: name, Object: u}
+	}
+	infos := []*resource.Info{
+		podInfo("pod-1", "app:v1"),
+		deployment("ns-a", "app"),
+		deployment("ns-b", "app"),
+	}
+	deploymentGK := schema.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	testCases := map[string]struct {
+		ref      string
+		expected object.ObjMetadata
+		isError  bool
+	}{
+		"kind and name": {
+			ref: "pod/pod-1",
+			expected: object.ObjMetadata{
+				GroupKind: schema.GroupKind{Kind: "Pod"},
+				Namespace: namespace,
+				Name:      "pod-1",
+			},
+		},
+		"namespace, kind with group and name": {
+			ref: "ns-b/deployment.apps/app",
+			expected: object.ObjMetadata{
+				GroupKind: deploymentGK,
+				Namespace: "ns-b",
+				Name:      "app",
+			},
+		},
+		"ambiguous reference": {
+			ref:     "deployment/app",
+			isError: true,
+		},
+		"unknown object": {
+			ref:     "pod/pod-2",
+			isError: true,
+		},
+		"invalid reference": {
+			ref:     "pod-1",
+			isError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			id, err := resolveReference(tc.ref, infos)
+			if tc.isError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, id)
+		})
+	}
+}
@@ -0,0 +1,396 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/retry"
+)
+
+// ApplierOptions configures an Applier created with
+// NewApplierFromOptions, for programs that use the Applier as a
+// library rather than through the command line.
+type ApplierOptions struct {
+	// Factory provides the clients for the cluster. If it is nil, the
+	// clients are created from the RESTConfig.
+	Factory util.Factory
+	// RESTConfig is the configuration for the cluster. It is only used
+	// if there is no Factory.
+	RESTConfig *rest.Config
+	// Namespace is used for namespaced resources that don't set a
+	// namespace. If it is empty, the default namespace is used.
+	Namespace string
+
+	// Source provides the manifests to apply, including the grouping
+	// object template.
+	Source ManifestSource
+
+	// NoPrune disables pruning of previously applied resources.
+	NoPrune bool
+	// WaitForReconcile makes the Applier wait for all the applied
+	// resources to reach the Current status, until the WaitTimeout.
+	WaitForReconcile bool
+	// WaitTimeout is how long to wait for the resources to be
+	// reconciled. If it is zero, the default of one minute is used.
+	WaitTimeout time.Duration
+	// PollInterval is how often the status of the resources is polled.
+	// If it is zero, the default of two seconds is used.
+	PollInterval time.Duration
+	// DryRun only prints what would be applied and pruned.
+	DryRun bool
+	// ServerDryRun sends the applies and prunes to the server as
+	// dry-run requests, so they are validated without being persisted.
+	ServerDryRun bool
+	// Concurrency is the maximum number of resources that are applied
+	// at the same time. If it is zero, resources are applied one by one.
+	Concurrency int
+	// RetryPolicy decides which errors from the cluster are retried. If
+	// it is nil, the default policy is used.
+	RetryPolicy *retry.Policy
+	// PropagationPolicy is the propagation policy for the pruned
+	// resources. If it is empty, the default policy of each resource
+	// is used.
+	PropagationPolicy metav1.DeletionPropagation
+	// GracePeriodSeconds is the grace period for the pruned resources.
+	// If it is nil, the default grace period of each resource is used.
+	// A grace period of 0 is changed to 1 unless Force is set.
+	GracePeriodSeconds *int64
+	// Force removes the pruned resources from the API immediately when
+	// the GracePeriodSeconds is 0, bypassing graceful deletion.
+	Force bool
+}
+
+// NewApplierFromOptions returns an Applier that is ready to Run,
+// without needing a cobra command. The Initialize and SetFlags
+// methods must not be called on it.
+func NewApplierFromOptions(opts ApplierOptions) (*Applier, error) {
+	if opts.Source == nil {
+		return nil, fmt.Errorf("a Source for the manifests is required")
+	}
+	factory, err := factoryFor(opts.Factory, opts.RESTConfig, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	a := NewApplier(factory, discardStreams())
+	a.Source = opts.Source
+	a.NoPrune = opts.NoPrune
+	a.DryRun = opts.DryRun
+	a.ServerDryRun = opts.ServerDryRun
+	a.Concurrency = opts.Concurrency
+	if opts.RetryPolicy != nil {
+		a.RetryPolicy = opts.RetryPolicy
+	}
+	a.PropagationPolicy = string(opts.PropagationPolicy)
+	if opts.GracePeriodSeconds != nil {
+		a.GracePeriod = int(*opts.GracePeriodSeconds)
+	}
+	a.Force = opts.Force
+	a.StatusOptions.wait = opts.WaitForReconcile
+	if opts.WaitTimeout > 0 {
+		a.StatusOptions.Timeout = opts.WaitTimeout
+	}
+	if opts.PollInterval > 0 {
+		a.StatusOptions.period = opts.PollInterval
+	}
+	if err := a.complete(commandOptions{namespace: opts.Namespace}); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// DestroyerOptions configures a Destroyer created with
+// NewDestroyerFromOptions, for programs that use the Destroyer as a
+// library rather than through the command line.
+type DestroyerOptions struct {
+	// Factory provides the clients for the cluster. If it is nil, the
+	// clients are created from the RESTConfig.
+	Factory util.Factory
+	// RESTConfig is the configuration for the cluster. It is only used
+	// if there is no Factory.
+	RESTConfig *rest.Config
+	// Namespace is used for namespaced resources that don't set a
+	// namespace. If it is empty, the default namespace is used.
+	Namespace string
+
+	// Source provides the manifests of the package, which must include
+	// the grouping object template.
+	Source ManifestSource
+
+	// DryRun only prints what would be deleted.
+	DryRun bool
+	// WaitForDeletion makes the Destroyer delete the resources in
+	// the reverse of the order they are applied in, and wait for them
+	// to be deleted, until the WaitTimeout.
+	WaitForDeletion bool
+	// WaitTimeout is how long to wait for the resources to be deleted.
+	// If it is zero, the default of one minute is used.
+	WaitTimeout time.Duration
+	// PollInterval is how often the resources are polled while waiting.
+	// If it is zero, the default of two seconds is used.
+	PollInterval time.Duration
+	// PropagationPolicy is the propagation policy for the deleted
+	// resources. If it is empty, the default policy of each resource
+	// is used.
+	PropagationPolicy metav1.DeletionPropagation
+	// GracePeriodSeconds is the grace period for the deleted resources.
+	// If it is nil, the default grace period of each resource is used.
+	// A grace period of 0 is changed to 1 unless Force is set.
+	GracePeriodSeconds *int64
+	// Force removes the deleted resources from the API immediately
+	// when the GracePeriodSeconds is 0, bypassing graceful deletion.
+	Force bool
+	// RemoveFinalizers removes the finalizers from the resources that
+	// are still terminating after RemoveFinalizersAfter. It requires
+	// WaitForDeletion.
+	RemoveFinalizers bool
+	// RemoveFinalizersAfter is how long the resources are given to be
+	// deleted before their finalizers are removed. If it is zero, the
+	// default of 30 seconds is used.
+	RemoveFinalizersAfter time.Duration
+}
+
+// NewDestroyerFromOptions returns a Destroyer that is ready to Run,
+// without needing a cobra command. The Initialize and SetFlags
+// methods must not be called on it.
+func NewDestroyerFromOptions(opts DestroyerOptions) (*Destroyer, error) {
+	if opts.Source == nil {
+		return nil, fmt.Errorf("a Source for the manifests is required")
+	}
+	factory, err := factoryFor(opts.Factory, opts.RESTConfig, opts.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	d := NewDestroyer(factory, discardStreams())
+	d.Source = opts.Source
+	d.DryRun = opts.DryRun
+	d.WaitForDeletion = opts.WaitForDeletion
+	d.PropagationPolicy = string(opts.PropagationPolicy)
+	d.RemoveFinalizers = opts.RemoveFinalizers
+	if opts.RemoveFinalizersAfter > 0 {
+		d.RemoveFinalizersAfter = opts.RemoveFinalizersAfter
+	}
+	if opts.GracePeriodSeconds != nil {
+		d.GracePeriod = int(*opts.GracePeriodSeconds)
+	}
+	d.Force = opts.Force
+	if opts.WaitTimeout > 0 {
+		d.WaitTimeout = opts.WaitTimeout
+	}
+	if opts.PollInterval > 0 {
+		d.PollInterval = opts.PollInterval
+	}
+	if err := d.complete(commandOptions{namespace: opts.Namespace}); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// commandOptions are the settings that kubectl reads from the command
+// line flags when completing the ApplyOptions. The zero value is used
+// when there is no command.
+type commandOptions struct {
+	// namespace is used for namespaced resources that don't set a
+	// namespace. If it is empty, the default namespace is used.
+	namespace string
+	// enforceNamespace rejects resources in other namespaces.
+	enforceNamespace bool
+	// validate validates the resources against the OpenAPI schema.
+	validate bool
+	// fileNameFlags are the files and directories to read the
+	// resources from. If it is nil, the ones in the DeleteFlags of
+	// the ApplyOptions are used. The DeleteFlags are left unchanged,
+	// since they might be bound to the command line flags.
+	fileNameFlags   *genericclioptions.FileNameFlags
+	serverSideApply bool
+	forceConflicts  bool
+	fieldManager    string
+}
+
+// commandOptionsFromFlags reads the commandOptions from the command
+// line flags, and the namespace from the kubeconfig of the factory.
+// Flags that haven't been added to the command are left unset.
+func commandOptionsFromFlags(factory util.Factory, cmd *cobra.Command) (commandOptions, error) {
+	var opts commandOptions
+	var err error
+	opts.namespace, opts.enforceNamespace, err = factory.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return opts, err
+	}
+	if cmd.Flags().Lookup("validate") != nil {
+		opts.validate = util.GetFlagBool(cmd, "validate")
+	}
+	// The flags for server-side apply are added together by
+	// AddServerSideApplyFlags. They are looked up by force-conflicts,
+	// since a command can have a --server-side flag of its own, like
+	// the one for a server dry-run in preview.
+	if cmd.Flags().Lookup("force-conflicts") != nil {
+		opts.serverSideApply = util.GetServerSideApplyFlag(cmd)
+		opts.forceConflicts = util.GetForceConflictsFlag(cmd)
+		opts.fieldManager = util.GetFieldManagerFlag(cmd)
+	}
+	return opts, nil
+}
+
+// completeApplyOptions sets up the ApplyOptions the same way as
+// ApplyOptions.Complete, with the settings that Complete reads from the
+// command line flags taken from the commandOptions.
+func completeApplyOptions(ao *apply.ApplyOptions, factory util.Factory, opts commandOptions) error {
+	if opts.forceConflicts && !opts.serverSideApply {
+		return fmt.Errorf("--force-conflicts only works with --server-side")
+	}
+	ao.ServerSideApply = opts.serverSideApply
+	ao.ForceConflicts = opts.forceConflicts
+	ao.FieldManager = opts.fieldManager
+
+	var err error
+	ao.ToPrinter = nil // Replaced by the printer adapter when running.
+	ao.Recorder = genericclioptions.NoopRecorder{}
+	if ao.RecordFlags != nil {
+		ao.Recorder, err = ao.RecordFlags.ToRecorder()
+		if err != nil {
+			return err
+		}
+	}
+	ao.DiscoveryClient, err = factory.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	ao.DynamicClient, err = factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	deleteFlags := *ao.DeleteFlags
+	if opts.fileNameFlags != nil {
+		deleteFlags.FileNameFlags = opts.fileNameFlags
+	}
+	ao.DeleteOptions = deleteFlags.ToOptions(ao.DynamicClient, ao.IOStreams)
+	ao.OpenAPISchema, _ = factory.OpenAPISchema()
+	ao.Validator, err = factory.Validator(opts.validate)
+	if err != nil {
+		return err
+	}
+	ao.Builder = factory.NewBuilder()
+	ao.Mapper, err = factory.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	ao.Namespace = opts.namespace
+	if ao.Namespace == "" {
+		ao.Namespace = metav1.NamespaceDefault
+	}
+	ao.EnforceNamespace = opts.enforceNamespace
+	return nil
+}
+
+// factoryFor returns the factory if there is one, or otherwise creates
+// one for the RESTConfig.
+func factoryFor(factory util.Factory, config *rest.Config, namespace string) (util.Factory, error) {
+	if factory != nil {
+		return factory, nil
+	}
+	if config == nil {
+		return nil, fmt.Errorf("either a Factory or a RESTConfig is required")
+	}
+	return util.NewFactory(&restConfigGetter{config: config, namespace: namespace}), nil
+}
+
+// discardStreams returns IOStreams that discard all output. The
+// progress is reported through the events, so nothing is lost.
+func discardStreams() genericclioptions.IOStreams {
+	return genericclioptions.IOStreams{
+		Out:    ioutil.Discard,
+		ErrOut: ioutil.Discard,
+	}
+}
+
+// restConfigGetter implements the RESTClientGetter interface for a
+// RESTConfig, so a Factory can be created without a kubeconfig file.
+type restConfigGetter struct {
+	config    *rest.Config
+	namespace string
+
+	once       sync.Once
+	discovery  discovery.CachedDiscoveryInterface
+	mapper     meta.RESTMapper
+	clientsErr error
+}
+
+var _ genericclioptions.RESTClientGetter = &restConfigGetter{}
+
+func (r *restConfigGetter) ToRESTConfig() (*rest.Config, error) {
+	return rest.CopyConfig(r.config), nil
+}
+
+func (r *restConfigGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	r.init()
+	return r.discovery, r.clientsErr
+}
+
+func (r *restConfigGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	r.init()
+	return r.mapper, r.clientsErr
+}
+
+// ToRawKubeConfigLoader returns a ClientConfig that only provides the
+// namespace, since the RESTConfig is used for everything else.
+func (r *restConfigGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return &namespaceClientConfig{getter: r}
+}
+
+// namespaceClientConfig is the ClientConfig for a restConfigGetter.
+type namespaceClientConfig struct {
+	getter *restConfigGetter
+}
+
+var _ clientcmd.ClientConfig = &namespaceClientConfig{}
+
+func (n *namespaceClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return *clientcmdapi.NewConfig(), nil
+}
+
+func (n *namespaceClientConfig) ClientConfig() (*rest.Config, error) {
+	return n.getter.ToRESTConfig()
+}
+
+func (n *namespaceClientConfig) Namespace() (string, bool, error) {
+	if n.getter.namespace == "" {
+		return metav1.NamespaceDefault, false, nil
+	}
+	return n.getter.namespace, true, nil
+}
+
+func (n *namespaceClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	return clientcmd.NewDefaultClientConfigLoadingRules()
+}
+
+// init creates the discovery client and the RESTMapper, which are
+// shared so discovery is only done once.
+func (r *restConfigGetter) init() {
+	r.once.Do(func() {
+		dc, err := discovery.NewDiscoveryClientForConfig(rest.CopyConfig(r.config))
+		if err != nil {
+			r.clientsErr = err
+			return
+		}
+		r.discovery = memory.NewMemCacheClient(dc)
+		deferred := restmapper.NewDeferredDiscoveryRESTMapper(r.discovery)
+		r.mapper = restmapper.NewShortcutExpander(deferred, r.discovery)
+	})
+}
@@ -0,0 +1,229 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestNewApplierFromOptions(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+	defer tf.Cleanup()
+
+	source := func() ManifestSource {
+		return KioSource{
+			Reader: &kio.ByteReader{Reader: bytes.NewBufferString(sourceManifests)},
+		}
+	}
+	gracePeriod := int64(30)
+
+	testCases := map[string]struct {
+		opts          ApplierOptions
+		expectedError string
+	}{
+		"source is required": {
+			opts:          ApplierOptions{Factory: tf},
+			expectedError: "a Source for the manifests is required",
+		},
+		"factory or config is required": {
+			opts:          ApplierOptions{Source: source()},
+			expectedError: "either a Factory or a RESTConfig is required",
+		},
+		"defaults": {
+			opts: ApplierOptions{Factory: tf, Source: source()},
+		},
+		"settings": {
+			opts: ApplierOptions{
+				Factory:            tf,
+				Source:             source(),
+				Namespace:          "test",
+				NoPrune:            true,
+				WaitForReconcile:   true,
+				WaitTimeout:        5 * time.Minute,
+				PollInterval:       time.Second,
+				DryRun:             true,
+				Concurrency:        4,
+				PropagationPolicy:  metav1.DeletePropagationOrphan,
+				GracePeriodSeconds: &gracePeriod,
+			},
+		},
+		"invalid propagation policy": {
+			opts: ApplierOptions{
+				Factory:           tf,
+				Source:            source(),
+				PropagationPolicy: "Sideways",
+			},
+			expectedError: `unknown propagation policy "Sideways", must be one of Foreground, Background or Orphan`,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			applier, err := NewApplierFromOptions(tc.opts)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			expectedNamespace := tc.opts.Namespace
+			if expectedNamespace == "" {
+				expectedNamespace = "default"
+			}
+			assert.Equal(t, expectedNamespace, applier.ApplyOptions.Namespace)
+			assert.Equal(t, tc.opts.NoPrune, applier.NoPrune)
+			assert.Equal(t, tc.opts.DryRun, applier.ApplyOptions.DryRun)
+			assert.Equal(t, tc.opts.DryRun, applier.PruneOptions.DryRun)
+			assert.Equal(t, tc.opts.Concurrency, applier.Concurrency)
+			assert.Equal(t, tc.opts.PropagationPolicy, applier.PruneOptions.PropagationPolicy)
+			assert.Equal(t, tc.opts.GracePeriodSeconds, applier.PruneOptions.GracePeriodSeconds)
+			assert.Equal(t, tc.opts.WaitForReconcile, applier.StatusOptions.wait)
+			if tc.opts.WaitTimeout > 0 {
+				assert.Equal(t, tc.opts.WaitTimeout, applier.StatusOptions.Timeout)
+			} else {
+				assert.Equal(t, NewStatusOptions().Timeout, applier.StatusOptions.Timeout)
+			}
+			if tc.opts.PollInterval > 0 {
+				assert.Equal(t, tc.opts.PollInterval, applier.StatusOptions.period)
+			}
+			assert.NotNil(t, applier.RetryPolicy)
+			assert.NotNil(t, applier.statusPoller)
+
+			infos, err := applier.readAndPrepareObjects()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, infos, 2)
+			for _, info := range infos {
+				assert.Equal(t, expectedNamespace, info.Namespace)
+			}
+		})
+	}
+}
+
+func TestNewDestroyerFromOptions(t *testing.T) {
+	tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+	defer tf.Cleanup()
+
+	_, err := NewDestroyerFromOptions(DestroyerOptions{Factory: tf})
+	assert.EqualError(t, err, "a Source for the manifests is required")
+
+	destroyer, err := NewDestroyerFromOptions(DestroyerOptions{
+		Factory: tf,
+		Source: KioSource{
+			Reader: &kio.ByteReader{Reader: bytes.NewBufferString(sourceManifests)},
+		},
+		DryRun: true,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, destroyer.ApplyOptions.DryRun)
+	assert.True(t, destroyer.PruneOptions.DryRun)
+
+	infos, err := destroyer.readObjects()
+	assert.NoError(t, err)
+	assert.Len(t, infos, 2)
+}
+
+func TestApplierInitialize(t *testing.T) {
+	testCases := map[string]struct {
+		args []string
+		// ownServerSideFlag adds a --server-side flag that is not the
+		// one from kubectl, like the one in preview.
+		ownServerSideFlag bool
+		expectedError     string
+	}{
+		"defaults": {},
+		"server-side apply": {
+			args: []string{"--server-side", "--force-conflicts", "--field-manager=manager"},
+		},
+		"force conflicts without server-side apply": {
+			args:          []string{"--force-conflicts"},
+			expectedError: "--force-conflicts only works with --server-side",
+		},
+		"server-side flag that is not for server-side apply": {
+			args:              []string{"--server-side"},
+			ownServerSideFlag: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+			defer tf.Cleanup()
+
+			applier := NewApplier(tf, genericclioptions.NewTestIOStreamsDiscard())
+			cmd := &cobra.Command{}
+			assert.NoError(t, applier.SetFlags(cmd))
+			util.AddValidateFlags(cmd)
+			if tc.ownServerSideFlag {
+				cmd.Flags().Bool("server-side", false, "")
+			} else {
+				util.AddServerSideApplyFlags(cmd)
+			}
+			assert.NoError(t, cmd.Flags().Parse(tc.args))
+
+			err := applier.Initialize(cmd, []string{})
+			if tc.expectedError != "" {
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "namespace", applier.ApplyOptions.Namespace)
+			if tc.ownServerSideFlag {
+				assert.False(t, applier.ApplyOptions.ServerSideApply)
+				return
+			}
+			assert.Equal(t, util.GetServerSideApplyFlag(cmd), applier.ApplyOptions.ServerSideApply)
+			assert.Equal(t, util.GetForceConflictsFlag(cmd), applier.ApplyOptions.ForceConflicts)
+			assert.Equal(t, util.GetFieldManagerFlag(cmd), applier.ApplyOptions.FieldManager)
+			assert.Equal(t, []string{"-"}, applier.Paths())
+			assert.NotNil(t, applier.ApplyOptions.Recorder)
+			assert.NotNil(t, applier.statusPoller)
+		})
+	}
+}
+
+func TestRESTConfigGetterNamespace(t *testing.T) {
+	testCases := map[string]struct {
+		namespace         string
+		expectedNamespace string
+		expectedExplicit  bool
+	}{
+		"no namespace": {
+			expectedNamespace: "default",
+		},
+		"namespace": {
+			namespace:         "test",
+			expectedNamespace: "test",
+			expectedExplicit:  true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			getter := &restConfigGetter{config: &rest.Config{Host: "https://localhost"}, namespace: tc.namespace}
+			namespace, explicit, err := getter.ToRawKubeConfigLoader().Namespace()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedNamespace, namespace)
+			assert.Equal(t, tc.expectedExplicit, explicit)
+		})
+	}
+}
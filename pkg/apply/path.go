@@ -6,10 +6,31 @@ package apply
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmddelete "k8s.io/kubectl/pkg/cmd/delete"
 )
 
+// addPackageFlags adds the flags of the DeleteFlags to the command,
+// which are needed for reading a package. The package is passed as
+// arguments, so the flags for selecting files are hidden, as are the
+// flags for deleting objects.
+func addPackageFlags(cmd *cobra.Command, deleteFlags *cmddelete.DeleteFlags) error {
+	deleteFlags.AddFlags(cmd)
+	for _, flag := range []string{"kustomize", "filename", "recursive"} {
+		err := cmd.Flags().MarkHidden(flag)
+		if err != nil {
+			return err
+		}
+	}
+	for _, flag := range []string{"cascade", "force", "grace-period", "timeout", "wait"} {
+		_ = cmd.Flags().MarkHidden(flag)
+	}
+	return nil
+}
+
 func processPaths(paths []string) genericclioptions.FileNameFlags {
 	// No arguments means we are reading from StdIn
 	fileNameFlags := genericclioptions.FileNameFlags{}
@@ -47,3 +68,59 @@ func isPathADirectory(name string) bool {
 	}
 	return false
 }
+
+// kustomizationFileNames are the names of the file that makes a
+// directory a kustomization root.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// processInputs sorts the paths given as arguments together with the
+// --filename and --kustomize flags into the files and directories that
+// are read directly, and the kustomization roots that must be built
+// first. A directory argument that contains a kustomization file is a
+// kustomization root. Directories are read recursively unless
+// --recursive=false is given, and "-" or no inputs at all means
+// reading from StdIn.
+func processInputs(paths []string, flags *genericclioptions.FileNameFlags) (genericclioptions.FileNameFlags, []string) {
+	var filenames []string
+	var kustomizations []string
+	recursive := true
+	if flags != nil {
+		if flags.Filenames != nil {
+			filenames = append(filenames, *flags.Filenames...)
+		}
+		if flags.Kustomize != nil && *flags.Kustomize != "" {
+			kustomizations = append(kustomizations, *flags.Kustomize)
+		}
+		if flags.Recursive != nil {
+			recursive = *flags.Recursive
+		}
+	}
+	for _, path := range paths {
+		if isKustomization(path) {
+			kustomizations = append(kustomizations, path)
+			continue
+		}
+		filenames = append(filenames, path)
+	}
+	if len(filenames) == 0 && len(kustomizations) == 0 {
+		filenames = []string{"-"}
+	}
+	return genericclioptions.FileNameFlags{
+		Filenames: &filenames,
+		Recursive: &recursive,
+	}, kustomizations
+}
+
+// isKustomization returns true if the path is a directory that
+// contains a kustomization file.
+func isKustomization(path string) bool {
+	if !isPathADirectory(path) {
+		return false
+	}
+	for _, name := range kustomizationFileNames {
+		if fi, err := os.Stat(filepath.Join(path, name)); err == nil && fi.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,9 @@
 package apply
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"gotest.tools/assert"
@@ -62,3 +65,82 @@ func TestProcessPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inputs")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	kustomizeDir := filepath.Join(dir, "overlay")
+	assert.NilError(t, os.Mkdir(kustomizeDir, 0700))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(kustomizeDir, "kustomization.yaml"),
+		[]byte("resources: []"), 0600))
+	plainDir := filepath.Join(dir, "plain")
+	assert.NilError(t, os.Mkdir(plainDir, 0700))
+
+	trueVal := true
+	falseVal := false
+	flagFile := "extra.yaml"
+	flagKustomize := "base"
+	testCases := map[string]struct {
+		paths                  []string
+		flags                  *genericclioptions.FileNameFlags
+		expectedFileNameFlags  genericclioptions.FileNameFlags
+		expectedKustomizations []string
+	}{
+		"no inputs means reading from StdIn": {
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{"-"},
+				Recursive: &trueVal,
+			},
+		},
+		"files and directories are read directly": {
+			paths: []string{plainDir, "pod.yaml", "-"},
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{plainDir, "pod.yaml", "-"},
+				Recursive: &trueVal,
+			},
+		},
+		"directories with a kustomization file are kustomization roots": {
+			paths: []string{kustomizeDir, plainDir},
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{plainDir},
+				Recursive: &trueVal,
+			},
+			expectedKustomizations: []string{kustomizeDir},
+		},
+		"only kustomization roots": {
+			paths: []string{kustomizeDir},
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{},
+				Recursive: &trueVal,
+			},
+			expectedKustomizations: []string{kustomizeDir},
+		},
+		"flags are combined with the arguments": {
+			paths: []string{kustomizeDir, plainDir},
+			flags: &genericclioptions.FileNameFlags{
+				Filenames: &[]string{flagFile},
+				Kustomize: &flagKustomize,
+				Recursive: &falseVal,
+			},
+			expectedFileNameFlags: genericclioptions.FileNameFlags{
+				Filenames: &[]string{flagFile, plainDir},
+				Recursive: &falseVal,
+			},
+			expectedKustomizations: []string{flagKustomize, kustomizeDir},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			fileNameFlags, kustomizations := processInputs(tc.paths, tc.flags)
+			if len(*tc.expectedFileNameFlags.Filenames) == 0 {
+				assert.Equal(t, len(*fileNameFlags.Filenames), 0)
+			} else {
+				assert.DeepEqual(t, *tc.expectedFileNameFlags.Filenames, *fileNameFlags.Filenames)
+			}
+			assert.Equal(t, *tc.expectedFileNameFlags.Recursive, *fileNameFlags.Recursive)
+			assert.DeepEqual(t, tc.expectedKustomizations, kustomizations)
+		})
+	}
+}
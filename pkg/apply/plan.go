@@ -0,0 +1,338 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-errors/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// PlanVersion is the version of the format of the plan files written
+// by WritePlan.
+const PlanVersion = 1
+
+// Plan is a preview of an apply that can be saved, so exactly the
+// changes in it can be approved before they are applied. An Applier
+// with a Plan applies the resources in the plan, and refuses to apply
+// them if the cluster has changed since the plan was created.
+type Plan struct {
+	Version int `json:"version"`
+	// Objects are the resources that are applied, in the order they
+	// are applied. The grouping object is included.
+	Objects []*unstructured.Unstructured `json:"objects"`
+	// Inventory identifies the grouping object for the resources.
+	Inventory PlanInventory `json:"inventory"`
+	// NoPrune is true if the plan doesn't prune any resources.
+	NoPrune bool `json:"noPrune,omitempty"`
+	// Prune are the resources that are pruned.
+	Prune []string `json:"prune"`
+	// ResourceVersions are the resourceVersions of the live resources
+	// that are applied or pruned when the plan was created. Resources
+	// that didn't exist have an empty resourceVersion.
+	ResourceVersions map[string]string `json:"resourceVersions"`
+}
+
+// PlanInventory is the grouping object in a Plan.
+type PlanInventory struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Hash is the hash of the inventory of the resources, which is
+	// the suffix of the name of the grouping object.
+	Hash string `json:"hash"`
+}
+
+// StalePlanError is returned when a plan is applied and the cluster
+// has changed since the plan was created.
+type StalePlanError struct {
+	// Changes describe what has changed.
+	Changes []string
+}
+
+func (e StalePlanError) Error() string {
+	return fmt.Sprintf("the cluster has changed since the plan was created, create a new plan:\n  %s",
+		strings.Join(e.Changes, "\n  "))
+}
+
+// ReadPlan reads a plan written by WritePlan.
+func ReadPlan(r io.Reader) (*Plan, error) {
+	plan := &Plan{}
+	if err := json.NewDecoder(r).Decode(plan); err != nil {
+		return nil, errors.WrapPrefix(err, "error reading plan", 1)
+	}
+	if plan.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d, expected %d", plan.Version, PlanVersion)
+	}
+	return plan, nil
+}
+
+// WritePlan writes the plan as JSON.
+func WritePlan(w io.Writer, plan *Plan) error {
+	b, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// CreatePlan reads the resources and the live state of the cluster,
+// and returns a Plan for applying them. The Applier must be
+// initialized, and must not already have a Plan.
+func (a *Applier) CreatePlan() (*Plan, error) {
+	if a.Plan != nil {
+		return nil, fmt.Errorf("the applier already has a plan")
+	}
+	infos, err := a.readAndPrepareObjects()
+	if err != nil {
+		return nil, err
+	}
+	groupingInfo, found := prune.FindGroupingObject(infos)
+	if !found {
+		return nil, prune.NoGroupingObjError{}
+	}
+	resources, _ := splitInfos(infos)
+	hash, err := prune.InventoryHash(resources)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{
+		Version: PlanVersion,
+		Inventory: PlanInventory{
+			Name:      groupingInfo.Name,
+			Namespace: groupingInfo.Namespace,
+			Hash:      hash,
+		},
+		NoPrune: a.NoPrune,
+		Prune:   []string{},
+	}
+	var ids []object.ObjMetadata
+	for _, info := range infos {
+		obj := info.Object.(*unstructured.Unstructured).DeepCopy()
+		if info.Namespaced() {
+			obj.SetNamespace(info.Namespace)
+		}
+		plan.Objects = append(plan.Objects, obj)
+		ids = append(ids, object.ObjMetadata{
+			GroupKind: obj.GroupVersionKind().GroupKind(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		})
+	}
+	if !a.NoPrune {
+		pruneSet, _, err := a.PruneOptions.RetrievePruneSet(infos)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error calculating the prune set", 1)
+		}
+		for _, id := range pruneSet {
+			plan.Prune = append(plan.Prune, id.String())
+			ids = append(ids, *id)
+		}
+		sort.Strings(plan.Prune)
+	}
+	plan.ResourceVersions, err = a.liveResourceVersions(ids)
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// readPlannedObjects returns the resources in the plan. They are set
+// on the ApplyOptions, so they are applied instead of the resources
+// from the command line.
+func (a *Applier) readPlannedObjects() ([]*resource.Info, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(a.Plan.Objects))
+	for _, obj := range a.Plan.Objects {
+		objs = append(objs, obj.DeepCopy())
+	}
+	// The namespaces are resolved when the plan is created, so the
+	// namespace of the command line is not enforced.
+	infos, err := objectsToInfos(a.factory, objs, a.ApplyOptions.Namespace, false)
+	if err != nil {
+		return nil, err
+	}
+	a.ApplyOptions.SetObjects(infos)
+	return infos, nil
+}
+
+// verifyPlan checks that the resources from the plan are consistent
+// with its inventory, and that the cluster hasn't changed since the
+// plan was created.
+func (a *Applier) verifyPlan(infos []*resource.Info) error {
+	groupingInfo, found := prune.FindGroupingObject(infos)
+	if !found {
+		return prune.NoGroupingObjError{}
+	}
+	resources, _ := splitInfos(infos)
+	hash, err := prune.InventoryHash(resources)
+	if err != nil {
+		return err
+	}
+	groupingObj := groupingInfo.Object.(*unstructured.Unstructured)
+	if hash != a.Plan.Inventory.Hash || groupingObj.GetAnnotations()[prune.GroupingHash] != hash ||
+		groupingInfo.Name != a.Plan.Inventory.Name {
+		return fmt.Errorf("the resources in the plan don't match its inventory %s", a.Plan.Inventory.Name)
+	}
+
+	var changes []string
+	ids := infosToObjMetas(infos)
+	if !a.Plan.NoPrune {
+		pruneSet, _, err := a.PruneOptions.RetrievePruneSet(infos)
+		if err != nil {
+			return errors.WrapPrefix(err, "error calculating the prune set", 1)
+		}
+		var current []string
+		for _, id := range pruneSet {
+			current = append(current, id.String())
+			ids = append(ids, *id)
+		}
+		changes = append(changes, pruneSetChanges(a.Plan.Prune, current)...)
+	}
+	versions, err := a.liveResourceVersions(ids)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		key := id.String()
+		planned, found := a.Plan.ResourceVersions[key]
+		if !found {
+			continue
+		}
+		if change := resourceVersionChange(key, planned, versions[key]); change != "" {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) > 0 {
+		return StalePlanError{Changes: changes}
+	}
+	return nil
+}
+
+// checkPlannedVersion returns a StalePlanError if the live resource
+// has changed since the plan was created. It is called right before
+// each resource is applied, since the resource can change after the
+// plan has been verified. Resources that are not in the plan are not
+// checked.
+func (a *Applier) checkPlannedVersion(info *resource.Info) error {
+	id := infosToObjMetas([]*resource.Info{info})[0]
+	key := id.String()
+	planned, found := a.Plan.ResourceVersions[key]
+	if !found {
+		return nil
+	}
+	versions, err := a.liveResourceVersions([]object.ObjMetadata{id})
+	if err != nil {
+		return err
+	}
+	if change := resourceVersionChange(key, planned, versions[key]); change != "" {
+		return StalePlanError{Changes: []string{change}}
+	}
+	return nil
+}
+
+// resourceVersionChange describes how the resource has changed, based
+// on its planned and current resourceVersion. It returns an empty
+// string if the resource is unchanged.
+func resourceVersionChange(key, planned, current string) string {
+	switch {
+	case current == planned:
+		return ""
+	case planned == "":
+		return fmt.Sprintf("%s was created", key)
+	case current == "":
+		return fmt.Sprintf("%s was deleted", key)
+	default:
+		return fmt.Sprintf("%s was modified (resourceVersion %s, planned %s)", key, current, planned)
+	}
+}
+
+// pruneSetChanges describes the differences between the planned and
+// the current prune set.
+func pruneSetChanges(planned, current []string) []string {
+	var changes []string
+	inPlan := make(map[string]bool)
+	for _, id := range planned {
+		inPlan[id] = true
+	}
+	inCurrent := make(map[string]bool)
+	for _, id := range current {
+		inCurrent[id] = true
+		if !inPlan[id] {
+			changes = append(changes, fmt.Sprintf("%s would be pruned, but is not in the plan", id))
+		}
+	}
+	for _, id := range planned {
+		if !inCurrent[id] {
+			changes = append(changes, fmt.Sprintf("%s is pruned in the plan, but is no longer in the prune set", id))
+		}
+	}
+	return changes
+}
+
+// liveResourceVersions returns the resourceVersion of each of the
+// resources in the cluster, keyed by the string for its identifier.
+// The resourceVersion is empty for resources that don't exist,
+// including resources of kinds that are not known to the cluster yet.
+func (a *Applier) liveResourceVersions(ids []object.ObjMetadata) (map[string]string, error) {
+	versions := make(map[string]string)
+	err := getLiveObjects(a.factory, ids, func(id object.ObjMetadata, obj *unstructured.Unstructured) {
+		versions[id.String()] = ""
+		if obj != nil {
+			versions[id.String()] = obj.GetResourceVersion()
+		}
+	})
+	return versions, err
+}
+
+// getLiveObjects fetches each of the resources from the cluster, and
+// calls the function with the live resource, or nil if it doesn't
+// exist. Resources of kinds that are not known to the cluster don't
+// exist.
+func getLiveObjects(factory util.Factory, ids []object.ObjMetadata,
+	fn func(object.ObjMetadata, *unstructured.Unstructured)) error {
+	mapper, err := factory.ToRESTMapper()
+	if err != nil {
+		return errors.WrapPrefix(err, "error getting RESTMapper", 1)
+	}
+	client, err := factory.DynamicClient()
+	if err != nil {
+		return errors.WrapPrefix(err, "error creating client", 1)
+	}
+	for _, id := range ids {
+		mapping, err := mapper.RESTMapping(id.GroupKind)
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				fn(id, nil)
+				continue
+			}
+			return errors.WrapPrefix(err, "error getting mapping for "+id.String(), 1)
+		}
+		namespace := ""
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			namespace = id.Namespace
+		}
+		obj, err := client.Resource(mapping.Resource).Namespace(namespace).Get(id.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			fn(id, nil)
+		case err != nil:
+			return errors.WrapPrefix(err, "error getting "+id.String(), 1)
+		default:
+			fn(id, obj)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/retry"
+)
+
+func TestPlanReadWrite(t *testing.T) {
+	plan := &Plan{
+		Version: PlanVersion,
+		Objects: []*unstructured.Unstructured{
+			podInfo("pod-1", "app:v1").Object.(*unstructured.Unstructured),
+		},
+		Inventory: PlanInventory{
+			Name:      "inventory-1234",
+			Namespace: namespace,
+			Hash:      "1234",
+		},
+		Prune: []string{"test-namespace_pod-2__Pod"},
+		ResourceVersions: map[string]string{
+			"test-namespace_pod-1__Pod": "",
+			"test-namespace_pod-2__Pod": "5",
+		},
+	}
+	var b bytes.Buffer
+	if !assert.NoError(t, WritePlan(&b, plan)) {
+		return
+	}
+	read, err := ReadPlan(bytes.NewReader(b.Bytes()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, plan, read)
+
+	_, err = ReadPlan(bytes.NewBufferString(`{"version": 2}`))
+	assert.Error(t, err)
+}
+
+func TestVerifyPlan(t *testing.T) {
+	template := func() *resource.Info {
+		return &resource.Info{
+			Namespace: namespace,
+			Name:      "inventory",
+			Mapping: &meta.RESTMapping{
+				Resource: configMapsGVR,
+				Scope:    meta.RESTScopeNamespace,
+			},
+			Object: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]interface{}{
+						"name":      "inventory",
+						"namespace": namespace,
+						"labels": map[string]interface{}{
+							prune.GroupingLabel: "test-app-label",
+						},
+					},
+				},
+			},
+		}
+	}
+	livePod := func(name, resourceVersion string) *unstructured.Unstructured {
+		u := podInfo(name, "app:v1").Object.(*unstructured.Unstructured)
+		u.SetResourceVersion(resourceVersion)
+		return u
+	}
+
+	testCases := map[string]struct {
+		change          func(t *testing.T, client *fakedynamic.FakeDynamicClient, plan *Plan)
+		expectedError   bool
+		expectedChanges []string
+	}{
+		"unchanged": {},
+		"modified": {
+			change: func(t *testing.T, client *fakedynamic.FakeDynamicClient, _ *Plan) {
+				_, err := client.Resource(podsGVR).Namespace(namespace).
+					Update(livePod("pod-1", "2"), metav1.UpdateOptions{})
+				assert.NoError(t, err)
+			},
+			expectedChanges: []string{"test-namespace_pod-1__Pod was modified (resourceVersion 2, planned 1)"},
+		},
+		"created": {
+			change: func(t *testing.T, client *fakedynamic.FakeDynamicClient, _ *Plan) {
+				_, err := client.Resource(podsGVR).Namespace(namespace).
+					Create(livePod("pod-2", "1"), metav1.CreateOptions{})
+				assert.NoError(t, err)
+			},
+			expectedChanges: []string{"test-namespace_pod-2__Pod was created"},
+		},
+		"deleted": {
+			change: func(t *testing.T, client *fakedynamic.FakeDynamicClient, _ *Plan) {
+				err := client.Resource(podsGVR).Namespace(namespace).
+					Delete("pod-1", &metav1.DeleteOptions{})
+				assert.NoError(t, err)
+			},
+			expectedChanges: []string{"test-namespace_pod-1__Pod was deleted"},
+		},
+		"objects don't match the inventory": {
+			change: func(_ *testing.T, _ *fakedynamic.FakeDynamicClient, plan *Plan) {
+				plan.Objects = append(plan.Objects, livePod("pod-3", ""))
+			},
+			expectedError: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			client := fakedynamic.NewSimpleDynamicClient(scheme.Scheme, []runtime.Object{livePod("pod-1", "1")}...)
+			tf.FakeDynamicClient = client
+
+			applier := NewApplier(tf, genericclioptions.NewTestIOStreamsDiscard())
+			applier.NoPrune = true
+			applier.ApplyOptions.SetObjects([]*resource.Info{
+				template(), podInfo("pod-1", "app:v2"), podInfo("pod-2", "app:v1"),
+			})
+
+			plan, err := applier.CreatePlan()
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, map[string]string{
+				"test-namespace_pod-1__Pod":                             "1",
+				"test-namespace_pod-2__Pod":                             "",
+				"test-namespace_" + plan.Inventory.Name + "__ConfigMap": "",
+			}, plan.ResourceVersions)
+			assert.Len(t, plan.Objects, 3)
+
+			if tc.change != nil {
+				tc.change(t, client, plan)
+			}
+			applier.Plan = plan
+			infos, err := applier.readAndPrepareObjects()
+			if !assert.NoError(t, err) {
+				return
+			}
+			err = applier.verifyPlan(infos)
+			switch {
+			case tc.expectedError:
+				assert.Error(t, err)
+			case tc.expectedChanges != nil:
+				assert.Equal(t, StalePlanError{Changes: tc.expectedChanges}, err)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPruneSetChanges(t *testing.T) {
+	changes := pruneSetChanges([]string{"a", "b"}, []string{"b", "c"})
+	assert.Equal(t, []string{
+		"c would be pruned, but is not in the plan",
+		"a is pruned in the plan, but is no longer in the prune set",
+	}, changes)
+}
+
+func TestApplierPlanPreconditions(t *testing.T) {
+	template := &unstructured.Unstructured{}
+	template.SetAPIVersion("v1")
+	template.SetKind("ConfigMap")
+	template.SetNamespace(namespace)
+	template.SetName("inventory")
+	template.SetLabels(map[string]string{prune.GroupingLabel: "test-app-label"})
+	pod := podInfo("pod-1", "app:v2").Object.(*unstructured.Unstructured)
+
+	testCases := map[string]struct {
+		modifiedAfterVerify bool
+		expectedError       string
+	}{
+		"unchanged": {},
+		"modified after the plan is verified": {
+			modifiedAfterVerify: true,
+			expectedError:       "test-namespace_pod-1__Pod was modified (resourceVersion 2, planned 1)",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace(namespace)
+			defer tf.Cleanup()
+			livePod := podInfo("pod-1", "app:v1").Object.(*unstructured.Unstructured)
+			livePod.SetResourceVersion("1")
+			client := fakedynamic.NewSimpleDynamicClient(scheme.Scheme, livePod)
+			// The pod is fetched when the plan is created, when it is
+			// verified and right before it is applied.
+			gets := 0
+			client.PrependReactor("get", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+				gets++
+				if tc.modifiedAfterVerify && gets > 2 {
+					modified := livePod.DeepCopy()
+					modified.SetResourceVersion("2")
+					return true, modified, nil
+				}
+				return false, nil, nil
+			})
+			tf.FakeDynamicClient = client
+			podHandler := &genericHandler{
+				resourceInfo: resourceInfo{
+					manifest: `
+  kind: Pod
+  apiVersion: v1
+  metadata:
+    name: pod-1
+    resourceVersion: "1"
+`,
+					basePath:    "/namespaces/%s/pods",
+					factoryFunc: func() runtime.Object { return &v1.Pod{} },
+				},
+				namespace: namespace,
+			}
+			groupingHandler := &groupingObjectHandler{}
+			tf.UnstructuredClient = newFakeRESTClient(t, []handler{
+				groupingHandler, podHandler, &nsHandler{},
+			})
+
+			applier, err := NewApplierFromOptions(ApplierOptions{
+				Factory:   tf,
+				Namespace: namespace,
+				Source:    ObjectSource{template, pod},
+				NoPrune:   true,
+				RetryPolicy: &retry.Policy{
+					Attempts:       3,
+					InitialBackoff: time.Millisecond,
+					Retryable:      retry.AllClasses,
+				},
+			})
+			if !assert.NoError(t, err) {
+				return
+			}
+			applier.Plan, err = applier.CreatePlan()
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var errs []error
+			var retries int
+			for e := range applier.Run(context.Background()) {
+				switch e.Type {
+				case event.ErrorType:
+					errs = append(errs, e.ErrorEvent.Err)
+				case event.RetryType:
+					retries++
+				}
+			}
+
+			assert.Equal(t, 0, retries)
+			// The grouping object is applied before the pod, so the
+			// plan has been verified in both cases.
+			assert.NotNil(t, groupingHandler.groupingObj)
+			if tc.expectedError != "" {
+				if assert.Len(t, errs, 1) {
+					assert.Contains(t, errs[0].Error(), tc.expectedError)
+				}
+				assert.Empty(t, podHandler.patches)
+				return
+			}
+			assert.Empty(t, errs)
+			if assert.Len(t, podHandler.patches, 1) {
+				// The plan doesn't change the applied manifest, so
+				// the last-applied annotation has no resourceVersion.
+				assert.Contains(t, string(podHandler.patches[0]), "last-applied-configuration")
+				assert.NotContains(t, string(podHandler.patches[0]), "resourceVersion")
+			}
+		})
+	}
+}
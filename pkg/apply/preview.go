@@ -0,0 +1,91 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+
+	"github.com/go-errors/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/drift"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// previewState is the local manifest and the live resource for one
+// of the resources in a preview. They are used to report what applying
+// the resource would really do, since a dry-run doesn't tell whether a
+// resource would be changed.
+type previewState struct {
+	local *unstructured.Unstructured
+	// live is nil if the resource doesn't exist.
+	live *unstructured.Unstructured
+}
+
+// previewStates fetches the live resource for each of the resources
+// that will be applied. The local manifests are copied, since they are
+// replaced by the objects from the server when the resources are
+// applied.
+func previewStates(infos []*resource.Info) (map[object.ObjMetadata]*previewState, error) {
+	states := make(map[object.ObjMetadata]*previewState)
+	for i, id := range infosToObjMetas(infos) {
+		info := infos[i]
+		state := &previewState{
+			local: info.Object.(*unstructured.Unstructured).DeepCopy(),
+		}
+		obj, err := resource.NewHelper(info.Client, info.Mapping).Get(info.Namespace, info.Name, false)
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return nil, errors.WrapPrefix(err, fmt.Sprintf("error getting %s/%s", id.GroupKind.Kind, id.Name), 1)
+		default:
+			state.live, err = toUnstructured(obj)
+			if err != nil {
+				return nil, err
+			}
+		}
+		states[id] = state
+	}
+	return states, nil
+}
+
+// classify sets the operation of an apply event in a preview to what
+// applying the resource would do, based on the live resource: create
+// it, change the fields in ChangedFields, or leave it unchanged.
+func classify(ae *event.ApplyEvent, state *previewState) error {
+	switch ae.Operation {
+	case event.Created, event.Configured, event.ServersideApplied:
+	default:
+		return nil
+	}
+	if state.live == nil {
+		ae.Operation = event.Created
+		return nil
+	}
+	changes, err := drift.Changes(state.local, state.live)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		ae.Operation = event.Unchanged
+		return nil
+	}
+	ae.Operation = event.Configured
+	ae.ChangedFields = changes
+	return nil
+}
+
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u, nil
+	}
+	m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+	return &unstructured.Unstructured{Object: m}, nil
+}
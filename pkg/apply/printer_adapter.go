@@ -7,9 +7,13 @@ import (
 	"fmt"
 	"io"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/printers"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/drift"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 // KubectlPrinterAdapter is a workaround for capturing progress from
@@ -19,29 +23,70 @@ import (
 // printing the info, it emits it as an event on the provided channel.
 type KubectlPrinterAdapter struct {
 	ch chan<- event.Event
+	// reportDefaulted adds the fields that were set by the server to
+	// the events. It is used for a server-side dry-run.
+	reportDefaulted bool
+	// preview holds the local and live state of the resources in a
+	// preview, which is used to report the real operation for each.
+	preview map[object.ObjMetadata]*previewState
 }
 
 // resourcePrinterImpl implements the ResourcePrinter interface. But
 // instead of printing, it emits information on the provided channel.
 type resourcePrinterImpl struct {
-	applyOperation event.ApplyEventOperation
-	ch             chan<- event.Event
+	applyOperation  event.ApplyEventOperation
+	ch              chan<- event.Event
+	reportDefaulted bool
+	preview         map[object.ObjMetadata]*previewState
 }
 
 // PrintObj takes the provided object and operation and emits
 // it on the channel.
 func (r *resourcePrinterImpl) PrintObj(obj runtime.Object, _ io.Writer) error {
+	var defaulted []string
+	if u, ok := obj.(*unstructured.Unstructured); ok && r.reportDefaulted {
+		var err error
+		defaulted, err = drift.DefaultedFields(u)
+		if err != nil {
+			return err
+		}
+	}
+	ae := event.ApplyEvent{
+		Type:            event.ApplyEventResourceUpdate,
+		Operation:       r.applyOperation,
+		Object:          obj,
+		DefaultedFields: defaulted,
+	}
+	if state, found := r.previewState(obj); found {
+		if err := classify(&ae, state); err != nil {
+			return err
+		}
+	}
 	r.ch <- event.Event{
-		Type: event.ApplyType,
-		ApplyEvent: event.ApplyEvent{
-			Type:      event.ApplyEventResourceUpdate,
-			Operation: r.applyOperation,
-			Object:    obj,
-		},
+		Type:       event.ApplyType,
+		ApplyEvent: ae,
 	}
 	return nil
 }
 
+// previewState returns the preview state for the object, if this is
+// a preview.
+func (r *resourcePrinterImpl) previewState(obj runtime.Object) (*previewState, bool) {
+	if r.preview == nil {
+		return nil, false
+	}
+	acc, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, false
+	}
+	state, found := r.preview[object.ObjMetadata{
+		GroupKind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
+		Namespace: acc.GetNamespace(),
+		Name:      acc.GetName(),
+	}]
+	return state, found
+}
+
 type toPrinterFunc func(string) (printers.ResourcePrinter, error)
 
 // toPrinterFunc returns a function of type toPrinterFunc. This
@@ -50,8 +95,10 @@ func (p *KubectlPrinterAdapter) toPrinterFunc() toPrinterFunc {
 	return func(operation string) (printers.ResourcePrinter, error) {
 		applyOperation, err := operationToApplyOperationConst(operation)
 		return &resourcePrinterImpl{
-			ch:             p.ch,
-			applyOperation: applyOperation,
+			ch:              p.ch,
+			applyOperation:  applyOperation,
+			reportDefaulted: p.reportDefaulted,
+			preview:         p.preview,
 		}, err
 	}
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
 func TestKubectlPrinterAdapter(t *testing.T) {
@@ -53,3 +55,95 @@ func TestKubectlPrinterAdapter(t *testing.T) {
 	assert.Equal(t, event.ServersideApplied, msg.ApplyEvent.Operation)
 	assert.Equal(t, &deployment, msg.ApplyEvent.Object)
 }
+
+func TestKubectlPrinterAdapterDefaultedFields(t *testing.T) {
+	ch := make(chan event.Event, 1)
+	adapter := KubectlPrinterAdapter{
+		ch:              ch,
+		reportDefaulted: true,
+	}
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"spec": map[string]interface{}{
+			"type":            "ClusterIP",
+			"sessionAffinity": "None",
+		},
+	}}
+	obj.SetName("name")
+	obj.SetAnnotations(map[string]string{
+		"kubectl.kubernetes.io/last-applied-configuration": `{"spec":{"type":"ClusterIP"}}`,
+	})
+
+	resourcePrinter, err := adapter.toPrinterFunc()("created")
+	assert.NoError(t, err)
+	assert.NoError(t, resourcePrinter.PrintObj(obj, &bytes.Buffer{}))
+
+	msg := <-ch
+	assert.Equal(t, event.Created, msg.ApplyEvent.Operation)
+	assert.Equal(t, []string{".spec.sessionAffinity"}, msg.ApplyEvent.DefaultedFields)
+}
+
+func TestKubectlPrinterAdapterPreview(t *testing.T) {
+	local := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":      "foo",
+			"namespace": "default",
+		},
+		"data": map[string]interface{}{
+			"a": "1",
+		},
+	}}
+	changed := local.DeepCopy()
+	_ = unstructured.SetNestedField(changed.Object, "0", "data", "a")
+
+	testCases := map[string]struct {
+		operation         string
+		live              *unstructured.Unstructured
+		expectedOperation event.ApplyEventOperation
+		expectedChanged   []string
+	}{
+		"not found is created": {
+			operation:         "configured",
+			expectedOperation: event.Created,
+		},
+		"same as live is unchanged": {
+			operation:         "configured",
+			live:              local.DeepCopy(),
+			expectedOperation: event.Unchanged,
+		},
+		"changed fields are configured": {
+			operation:         "serverside-applied",
+			live:              changed,
+			expectedOperation: event.Configured,
+			expectedChanged:   []string{".data.a"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ch := make(chan event.Event, 1)
+			adapter := KubectlPrinterAdapter{
+				ch: ch,
+				preview: map[object.ObjMetadata]*previewState{
+					{
+						GroupKind: local.GroupVersionKind().GroupKind(),
+						Namespace: "default",
+						Name:      "foo",
+					}: {local: local, live: tc.live},
+				},
+			}
+
+			resourcePrinter, err := adapter.toPrinterFunc()(tc.operation)
+			assert.NoError(t, err)
+			assert.NoError(t, resourcePrinter.PrintObj(local.DeepCopy(), &bytes.Buffer{}))
+
+			msg := <-ch
+			assert.Equal(t, tc.expectedOperation, msg.ApplyEvent.Operation)
+			assert.Equal(t, tc.expectedChanged, msg.ApplyEvent.ChangedFields)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterInventoryGroupKind is the GroupKind of the cluster-scoped
+// grouping object. It can be used instead of a ConfigMap for packages
+// that don't have a namespace to hold the inventory, for example
+// packages that only contain cluster-scoped objects.
+var ClusterInventoryGroupKind = schema.GroupKind{
+	Group: "cli-utils.sigs.k8s.io",
+	Kind:  "ClusterInventory",
+}
+
+// ClusterInventoryResource is the resource type of the ClusterInventory
+// in the format accepted by the resource builder.
+const ClusterInventoryResource = "clusterinventories.cli-utils.sigs.k8s.io"
+
+// ClusterInventoryCRD is the CustomResourceDefinition for the
+// ClusterInventory kind. It must be installed in the cluster before
+// a package using a ClusterInventory as grouping object is applied.
+const ClusterInventoryCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: clusterinventories.cli-utils.sigs.k8s.io
+spec:
+  group: cli-utils.sigs.k8s.io
+  names:
+    kind: ClusterInventory
+    listKind: ClusterInventoryList
+    plural: clusterinventories
+    singular: clusterinventory
+  scope: Cluster
+  versions:
+  - name: v1alpha1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          data:
+            type: object
+            additionalProperties:
+              type: string
+`
+
+var configMapGroupKind = schema.GroupKind{Kind: "ConfigMap"}
+
+// isGroupingKind returns true if the GroupKind of the passed object
+// is one that can be used for grouping objects, which is either a
+// ConfigMap or a ClusterInventory. Typed objects don't always have
+// their kind set, so an empty GroupKind is also accepted.
+func isGroupingKind(obj runtime.Object) bool {
+	gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+	return gk.Empty() || gk == configMapGroupKind || gk == ClusterInventoryGroupKind
+}
+
+// IsClusterInventory returns true if the passed object is a
+// ClusterInventory, i.e. a cluster-scoped grouping object.
+func IsClusterInventory(obj runtime.Object) bool {
+	return obj != nil && IsGroupingObject(obj) &&
+		obj.GetObjectKind().GroupVersionKind().GroupKind() == ClusterInventoryGroupKind
+}
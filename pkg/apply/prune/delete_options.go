@@ -0,0 +1,69 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// PropagationPolicyAnnotation overrides the propagation policy
+	// when the annotated object is pruned or destroyed. The value is
+	// one of Foreground, Background or Orphan.
+	PropagationPolicyAnnotation = "cli-utils.sigs.k8s.io/deletion-propagation-policy"
+	// GracePeriodAnnotation overrides the grace period in seconds when
+	// the annotated object is pruned or destroyed.
+	GracePeriodAnnotation = "cli-utils.sigs.k8s.io/deletion-grace-period"
+)
+
+// ParsePropagationPolicy returns the propagation policy with the given
+// name, which is not case sensitive. An empty name is the empty policy,
+// so the default policy of each resource is used.
+func ParsePropagationPolicy(name string) (metav1.DeletionPropagation, error) {
+	for _, policy := range []metav1.DeletionPropagation{
+		metav1.DeletePropagationForeground,
+		metav1.DeletePropagationBackground,
+		metav1.DeletePropagationOrphan,
+	} {
+		if strings.EqualFold(name, string(policy)) {
+			return policy, nil
+		}
+	}
+	if name == "" {
+		return "", nil
+	}
+	return "", fmt.Errorf("unknown propagation policy %q, must be one of Foreground, Background or Orphan", name)
+}
+
+// deleteOptionsFor returns the options for deleting the object, which
+// are the options of the PruneOptions with the overrides from the
+// annotations of the object. Returns an error if an annotation has an
+// invalid value, so an object is never deleted in a way it asked not
+// to be.
+func (po *PruneOptions) deleteOptionsFor(obj *unstructured.Unstructured) (*metav1.DeleteOptions, error) {
+	options := po.deleteOptions()
+	annotations := obj.GetAnnotations()
+	if value, found := annotations[PropagationPolicyAnnotation]; found {
+		policy, err := ParsePropagationPolicy(value)
+		if err != nil || policy == "" {
+			return nil, fmt.Errorf("invalid annotation %s: unknown propagation policy %q",
+				PropagationPolicyAnnotation, value)
+		}
+		options.PropagationPolicy = &policy
+	}
+	if value, found := annotations[GracePeriodAnnotation]; found {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds < 0 {
+			return nil, fmt.Errorf("invalid annotation %s: %q is not a number of seconds",
+				GracePeriodAnnotation, value)
+		}
+		options.GracePeriodSeconds = &seconds
+	}
+	return options, nil
+}
@@ -4,10 +4,10 @@
 // This file contains code for a "grouping" object which
 // stores object metadata to keep track of sets of
 // resources. This "grouping" object must be a ConfigMap
-// and it stores the object metadata in the data field
-// of the ConfigMap. By storing metadata from all applied
-// objects, we can correctly prune and teardown groupings
-// of resources.
+// or a cluster-scoped ClusterInventory, and it stores the
+// object metadata in its data field. By storing metadata
+// from all applied objects, we can correctly prune and
+// teardown groupings of resources.
 
 package prune
 
@@ -30,10 +30,10 @@ const (
 	GroupingHash  = "cli-utils.sigs.k8s.io/inventory-hash"
 )
 
-// retrieveGroupingLabel returns the string value of the GroupingLabel
+// RetrieveGroupingLabel returns the string value of the GroupingLabel
 // for the passed object. Returns error if the passed object is nil or
 // is not a grouping object.
-func retrieveGroupingLabel(obj runtime.Object) (string, error) {
+func RetrieveGroupingLabel(obj runtime.Object) (string, error) {
 	var groupingLabel string
 	if obj == nil {
 		return "", fmt.Errorf("grouping object is nil")
@@ -51,13 +51,12 @@ func retrieveGroupingLabel(obj runtime.Object) (string, error) {
 }
 
 // IsGroupingObject returns true if the passed object has the
-// grouping label.
-// TODO(seans3): Check type is ConfigMap.
+// grouping label, and is either a ConfigMap or a ClusterInventory.
 func IsGroupingObject(obj runtime.Object) bool {
-	if obj == nil {
+	if obj == nil || !isGroupingKind(obj) {
 		return false
 	}
-	groupingLabel, err := retrieveGroupingLabel(obj)
+	groupingLabel, err := RetrieveGroupingLabel(obj)
 	if err == nil && len(groupingLabel) > 0 {
 		return true
 	}
@@ -236,6 +235,17 @@ func computeInventoryHash(inventoryMap map[string]string) (string, error) {
 	return strconv.FormatUint(uint64(invHash), 16), nil
 }
 
+// InventoryHash returns the hash of the inventory of the resources,
+// which is used as the suffix of the name of the grouping object
+// created for them.
+func InventoryHash(resources []*resource.Info) (string, error) {
+	inventoryMap, err := buildInventoryMap(resources)
+	if err != nil {
+		return "", err
+	}
+	return computeInventoryHash(inventoryMap)
+}
+
 // RetrieveInventoryFromGroupingObj returns a slice of pointers to the
 // inventory metadata. This function finds the grouping object, then
 // parses the stored resource metadata into Inventory structs. Returns
@@ -270,6 +280,28 @@ func RetrieveInventoryFromGroupingObj(infos []*resource.Info) ([]*object.ObjMeta
 	return inventory, nil
 }
 
+// SetGroupingObjInventory replaces the inventory stored in the
+// grouping object with the passed inventory. Unlike
+// AddInventoryToGroupingObj, the name and the hash annotation of the
+// grouping object are left unchanged, so it can be used to update a
+// grouping object that already exists in the cluster. Returns an error
+// if the object is not a grouping object in Unstructured format.
+func SetGroupingObjInventory(groupingInfo *resource.Info, inv []*object.ObjMetadata) error {
+	if groupingInfo == nil || !IsGroupingObject(groupingInfo.Object) {
+		return fmt.Errorf("grouping object not found")
+	}
+	groupingObj, ok := groupingInfo.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("grouping object is not an Unstructured: %#v", groupingInfo.Object)
+	}
+	inventoryMap := map[string]string{}
+	for _, obj := range inv {
+		inventoryMap[obj.String()] = ""
+	}
+	return unstructured.SetNestedStringMap(groupingObj.UnstructuredContent(),
+		inventoryMap, "data")
+}
+
 // ClearGroupingObj finds the grouping object in the list of objects,
 // and sets an empty inventory. Returns error if the grouping object
 // is not Unstructured, the grouping object does not exist, or if
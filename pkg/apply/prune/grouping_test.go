@@ -158,7 +158,7 @@ func TestRetrieveGroupingLabel(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		actual, err := retrieveGroupingLabel(test.obj)
+		actual, err := RetrieveGroupingLabel(test.obj)
 		if test.isError && err == nil {
 			t.Errorf("Did not receive expected error.\n")
 		}
@@ -173,6 +173,33 @@ func TestRetrieveGroupingLabel(t *testing.T) {
 	}
 }
 
+var clusterInventory = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "cli-utils.sigs.k8s.io/v1alpha1",
+		"kind":       "ClusterInventory",
+		"metadata": map[string]interface{}{
+			"name": groupingObjName,
+			"labels": map[string]interface{}{
+				GroupingLabel: testGroupingLabel,
+			},
+		},
+	},
+}
+
+var podWithGroupingLabel = unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      pod1Name,
+			"namespace": testNamespace,
+			"labels": map[string]interface{}{
+				GroupingLabel: testGroupingLabel,
+			},
+		},
+	},
+}
+
 func TestIsGroupingObject(t *testing.T) {
 	tests := []struct {
 		obj        runtime.Object
@@ -190,6 +217,14 @@ func TestIsGroupingObject(t *testing.T) {
 			obj:        &pod2,
 			isGrouping: false,
 		},
+		{
+			obj:        &clusterInventory,
+			isGrouping: true,
+		},
+		{
+			obj:        &podWithGroupingLabel,
+			isGrouping: false,
+		},
 	}
 
 	for _, test := range tests {
@@ -697,6 +732,76 @@ func TestClearGroupingObject(t *testing.T) {
 	}
 }
 
+func TestSetGroupingObjInventory(t *testing.T) {
+	pod1Metadata := &object.ObjMetadata{
+		Namespace: testNamespace,
+		Name:      pod1Name,
+		GroupKind: schema.GroupKind{Kind: "Pod"},
+	}
+	pod2Metadata := &object.ObjMetadata{
+		Namespace: testNamespace,
+		Name:      pod2Name,
+		GroupKind: schema.GroupKind{Kind: "Pod"},
+	}
+	tests := map[string]struct {
+		groupingInfo *resource.Info
+		inventory    []*object.ObjMetadata
+		isError      bool
+	}{
+		"Nil info should error": {
+			groupingInfo: nil,
+			isError:      true,
+		},
+		"Non-grouping object should error": {
+			groupingInfo: pod1Info,
+			isError:      true,
+		},
+		"Empty inventory should work": {
+			groupingInfo: copyGroupingInfo(),
+			inventory:    []*object.ObjMetadata{},
+			isError:      false,
+		},
+		"Inventory with objects should work": {
+			groupingInfo: copyGroupingInfo(),
+			inventory:    []*object.ObjMetadata{pod1Metadata, pod2Metadata},
+			isError:      false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			var name string
+			if tc.groupingInfo != nil {
+				name = tc.groupingInfo.Object.(*unstructured.Unstructured).GetName()
+			}
+			err := SetGroupingObjInventory(tc.groupingInfo, tc.inventory)
+			if tc.isError {
+				if err == nil {
+					t.Errorf("Should have produced an error, but returned none.")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Received unexpected error: %#v", err)
+			}
+			actual, err := RetrieveInventoryFromGroupingObj([]*resource.Info{tc.groupingInfo})
+			if err != nil {
+				t.Fatalf("Received unexpected error: %#v", err)
+			}
+			if !NewInventory(tc.inventory).Equals(NewInventory(actual)) {
+				t.Errorf("Expected inventory (%v), got (%v)", tc.inventory, actual)
+			}
+			actualName, err := getObjectName(tc.groupingInfo.Object)
+			if err != nil {
+				t.Fatalf("Received unexpected error: %#v", err)
+			}
+			if name != actualName {
+				t.Errorf("Expected name (%s) to be unchanged, got (%s)", name, actualName)
+			}
+		})
+	}
+}
+
 func getObjectName(obj runtime.Object) (string, error) {
 	u, ok := obj.(*unstructured.Unstructured)
 	if !ok {
@@ -714,3 +819,25 @@ func copyGroupingInfo() *resource.Info {
 	}
 	return groupingInfo
 }
+
+func TestInventoryHash(t *testing.T) {
+	resources := []*resource.Info{pod1Info, pod2Info, pod3Info}
+	groupingInfo, err := CreateGroupingObj(copyGroupingInfo(), resources)
+	if err != nil {
+		t.Fatalf("unexpected error creating grouping object: %s", err)
+	}
+	hash, err := InventoryHash(resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := retrieveInventoryHash(groupingInfo); hash != expected {
+		t.Errorf("expected hash %s, got %s", expected, hash)
+	}
+	reordered, err := InventoryHash([]*resource.Info{pod3Info, pod1Info, pod2Info})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if reordered != hash {
+		t.Errorf("expected the hash to not depend on the order, got %s and %s", hash, reordered)
+	}
+}
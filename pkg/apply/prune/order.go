@@ -0,0 +1,48 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"sort"
+
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/ordering"
+)
+
+// SortForDeletion sorts the objects in the reverse of the order their
+// kinds are applied in, so objects are deleted before the objects
+// they depend on. Objects of the same kind are sorted by namespace
+// and name, so the order is deterministic.
+func SortForDeletion(objs []*object.ObjMetadata) []*object.ObjMetadata {
+	sort.SliceStable(objs, func(i, j int) bool {
+		x, o := objs[i], objs[j]
+		indexX, indexO := ordering.IndexByKind(x.GroupKind.Kind), ordering.IndexByKind(o.GroupKind.Kind)
+		if indexX != indexO {
+			return indexX > indexO
+		}
+		if x.GroupKind != o.GroupKind {
+			return x.GroupKind.String() < o.GroupKind.String()
+		}
+		if x.Namespace != o.Namespace {
+			return x.Namespace < o.Namespace
+		}
+		return x.Name < o.Name
+	})
+	return objs
+}
+
+// DeletionPhases sorts the objects for deletion, and splits them into
+// phases of objects whose kinds don't need to be deleted in any
+// particular order relative to each other. Each phase should be
+// deleted completely before the next one is started.
+func DeletionPhases(objs []*object.ObjMetadata) [][]*object.ObjMetadata {
+	var phases [][]*object.ObjMetadata
+	for i, obj := range SortForDeletion(objs) {
+		if i == 0 || ordering.IndexByKind(obj.GroupKind.Kind) != ordering.IndexByKind(objs[i-1].GroupKind.Kind) {
+			phases = append(phases, nil)
+		}
+		phases[len(phases)-1] = append(phases[len(phases)-1], obj)
+	}
+	return phases
+}
@@ -0,0 +1,46 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package prune
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+func TestDeletionPhases(t *testing.T) {
+	id := func(group, kind, name string) *object.ObjMetadata {
+		return &object.ObjMetadata{
+			GroupKind: schema.GroupKind{Group: group, Kind: kind},
+			Namespace: "ns",
+			Name:      name,
+		}
+	}
+	namespace := id("", "Namespace", "ns")
+	crd := id("apiextensions.k8s.io", "CustomResourceDefinition", "crd")
+	configMap := id("", "ConfigMap", "cm")
+	deployment1 := id("apps", "Deployment", "b")
+	deployment2 := id("apps", "Deployment", "a")
+	custom := id("example.com", "Custom", "c")
+	pod := id("", "Pod", "p")
+	webhook := id("admissionregistration.k8s.io", "ValidatingWebhookConfiguration", "w")
+
+	phases := DeletionPhases([]*object.ObjMetadata{
+		namespace, crd, configMap, deployment1, deployment2, custom, pod, webhook,
+	})
+
+	expected := [][]*object.ObjMetadata{
+		{webhook},
+		{custom, pod},
+		{deployment2, deployment1},
+		{configMap},
+		{crd},
+		{namespace},
+	}
+	if !reflect.DeepEqual(expected, phases) {
+		t.Errorf("expected phases %v, got %v", expected, phases)
+	}
+}
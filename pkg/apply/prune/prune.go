@@ -12,25 +12,33 @@
 package prune
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/validation"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 )
 
 // PruneOptions encapsulates the necessary information to
 // implement the prune functionality.
 type PruneOptions struct {
-	client  dynamic.Interface
-	builder *resource.Builder
-	mapper  meta.RESTMapper
+	client dynamic.Interface
+	// newBuilder returns a new builder for every lookup, since
+	// a resource.Builder can not be reused.
+	newBuilder func() *resource.Builder
+	mapper     meta.RESTMapper
 	// The currently applied objects (as Infos), including the
 	// current grouping object. These objects are used to
 	// calculate the prune set after retreiving the previous
@@ -46,7 +54,26 @@ type PruneOptions struct {
 	DryRun    bool
 	validator validation.Schema
 
-	// TODO: DeleteOptions--cascade?
+	// ServerDryRun sends the deletes to the server as dry-run
+	// requests, so they are validated by the server without
+	// deleting anything.
+	ServerDryRun bool
+
+	// RetryPolicy decides which errors from fetching and deleting
+	// objects will be retried. An event is sent for every retry.
+	// If it is nil, errors are never retried.
+	RetryPolicy *retry.Policy
+
+	// PropagationPolicy decides how the dependents of the deleted
+	// objects are deleted. If it is empty, the default policy of each
+	// resource is used. It can be overridden for an object with the
+	// PropagationPolicyAnnotation.
+	PropagationPolicy metav1.DeletionPropagation
+	// GracePeriodSeconds is how long the deleted objects are given to
+	// terminate gracefully. If it is nil, the default grace period of
+	// each resource is used. It can be overridden for an object with
+	// the GracePeriodAnnotation.
+	GracePeriodSeconds *int64
 }
 
 // NewPruneOptions returns a struct (PruneOptions) encapsulating the necessary
@@ -64,7 +91,7 @@ func (po *PruneOptions) Initialize(factory util.Factory) error {
 	if err != nil {
 		return err
 	}
-	po.builder = factory.NewBuilder()
+	po.newBuilder = factory.NewBuilder
 	po.mapper, err = factory.ToRESTMapper()
 	if err != nil {
 		return err
@@ -87,7 +114,7 @@ func (po *PruneOptions) getPreviousGroupingObjects() ([]*resource.Info, error) {
 	}
 	// Ensures the "pastGroupingObjects" is set.
 	if !po.retrievedGroupingObjects {
-		if err := po.retrievePreviousGroupingObjects(current.Namespace); err != nil {
+		if err := po.retrieveGroupingObjectsForCurrent(); err != nil {
 			return nil, err
 		}
 	}
@@ -105,39 +132,186 @@ func (po *PruneOptions) getPreviousGroupingObjects() ([]*resource.Info, error) {
 	return pastGroupInfos, nil
 }
 
+// RetrieveGroupingObjects returns all the grouping objects in the
+// cluster that have the same label as the grouping object among the
+// currentObjects. Unlike when pruning, a grouping object with the
+// same name as the current one is included if it has already been
+// applied. Returns a NoGroupingObjError if the currentObjects don't
+// have a grouping object.
+func (po *PruneOptions) RetrieveGroupingObjects(currentObjects []*resource.Info) ([]*resource.Info, error) {
+	currentGroupingObject, found := FindGroupingObject(currentObjects)
+	if !found {
+		return nil, NoGroupingObjError{}
+	}
+	po.currentGroupingObject = currentGroupingObject
+	if err := po.retrieveGroupingObjectsForCurrent(); err != nil {
+		return nil, err
+	}
+	return po.pastGroupingObjects, nil
+}
+
+// retrieveGroupingObjectsForCurrent retrieves the grouping objects
+// with the same label as the current grouping object, starting the
+// search in its namespace and the namespaces of the objects in its
+// inventory.
+func (po *PruneOptions) retrieveGroupingObjectsForCurrent() error {
+	current, err := infoToObjMetadata(po.currentGroupingObject)
+	if err != nil {
+		return err
+	}
+	currentInv, err := RetrieveInventoryFromGroupingObj([]*resource.Info{po.currentGroupingObject})
+	if err != nil {
+		return err
+	}
+	var namespaces []string
+	// A cluster-scoped grouping object has no namespace.
+	if current.Namespace != "" {
+		namespaces = append(namespaces, current.Namespace)
+	}
+	namespaces = append(namespaces, inventoryNamespaces(currentInv)...)
+	return po.retrievePreviousGroupingObjects(namespaces)
+}
+
 // retrievePreviousGroupingObjects requests the previous grouping objects
 // using the grouping label from the current grouping object. Sets
 // the field "pastGroupingObjects". Returns an error if the grouping
 // label doesn't exist for the current currentGroupingObject does not
 // exist or if the call to retrieve the past grouping objects fails.
-func (po *PruneOptions) retrievePreviousGroupingObjects(namespace string) error {
+//
+// A package can span several namespaces, and a previous grouping
+// object might have been stored in a different namespace than the
+// current one (for example if the default namespace was different,
+// or the grouping object was moved together with all the objects in
+// its namespace). So the ConfigMaps are listed in all namespaces. If
+// that is forbidden, the lookup starts with the provided namespaces
+// instead, and keeps searching the namespaces referenced by the
+// retrieved grouping objects until no new namespaces are found.
+//
+// If the current grouping object is a ClusterInventory, previous
+// ClusterInventory objects are retrieved as well. They are not looked
+// up otherwise, since the ClusterInventory CRD might not be installed.
+func (po *PruneOptions) retrievePreviousGroupingObjects(namespaces []string) error {
 	// Get the grouping label for this grouping object, and create
 	// a label selector from it.
 	if po.currentGroupingObject == nil || po.currentGroupingObject.Object == nil {
 		return fmt.Errorf("missing current grouping object")
 	}
-	groupingLabel, err := retrieveGroupingLabel(po.currentGroupingObject.Object)
+	groupingLabel, err := RetrieveGroupingLabel(po.currentGroupingObject.Object)
 	if err != nil {
 		return err
 	}
 	labelSelector := fmt.Sprintf("%s=%s", GroupingLabel, groupingLabel)
-	retrievedGroupingInfos, err := po.builder.
+	var retrievedGroupingInfos []*resource.Info
+	if IsClusterInventory(po.currentGroupingObject.Object) {
+		infos, err := po.listGroupingObjects(ClusterInventoryResource, metav1.NamespaceNone, labelSelector)
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			inv, err := RetrieveInventoryFromGroupingObj([]*resource.Info{info})
+			if err != nil {
+				return err
+			}
+			namespaces = append(namespaces, inventoryNamespaces(inv)...)
+		}
+		retrievedGroupingInfos = append(retrievedGroupingInfos, infos...)
+	}
+	infos, err := po.listGroupingObjects("configmap", metav1.NamespaceAll, labelSelector)
+	switch {
+	case err == nil:
+		retrievedGroupingInfos = append(retrievedGroupingInfos, infos...)
+	case isForbidden(err):
+		infos, err := po.searchGroupingObjects(namespaces, labelSelector)
+		if err != nil {
+			return err
+		}
+		retrievedGroupingInfos = append(retrievedGroupingInfos, infos...)
+	default:
+		return err
+	}
+	po.pastGroupingObjects = retrievedGroupingInfos
+	po.retrievedGroupingObjects = true
+	return nil
+}
+
+// searchGroupingObjects returns the ConfigMaps that match the label
+// selector in the provided namespaces, and in the namespaces of the
+// objects in their inventories.
+func (po *PruneOptions) searchGroupingObjects(namespaces []string,
+	labelSelector string) ([]*resource.Info, error) {
+	var retrievedGroupingInfos []*resource.Info
+	searched := make(map[string]bool)
+	for len(namespaces) > 0 {
+		namespace := namespaces[0]
+		namespaces = namespaces[1:]
+		if searched[namespace] {
+			continue
+		}
+		searched[namespace] = true
+		infos, err := po.listGroupingObjects("configmap", namespace, labelSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			inv, err := RetrieveInventoryFromGroupingObj([]*resource.Info{info})
+			if err != nil {
+				return nil, err
+			}
+			namespaces = append(namespaces, inventoryNamespaces(inv)...)
+		}
+		retrievedGroupingInfos = append(retrievedGroupingInfos, infos...)
+	}
+	return retrievedGroupingInfos, nil
+}
+
+// listGroupingObjects returns the objects of the given resource type
+// in the namespace that match the label selector. The namespace is
+// ignored for cluster-scoped resource types, and an empty namespace
+// means all namespaces.
+func (po *PruneOptions) listGroupingObjects(resourceType, namespace,
+	labelSelector string) ([]*resource.Info, error) {
+	return po.newBuilder().
 		Unstructured().
 		// TODO: Check if this validator is necessary.
 		Schema(po.validator).
 		ContinueOnError().
 		NamespaceParam(namespace).DefaultNamespace().
-		ResourceTypes("configmap").
+		ResourceTypes(resourceType).
 		LabelSelectorParam(labelSelector).
 		Flatten().
 		Do().
 		Infos()
-	if err != nil {
-		return err
+}
+
+// isForbidden returns true if the error is a Forbidden error, or an
+// aggregate of only Forbidden errors like the ones returned by the
+// resource.Builder.
+func isForbidden(err error) bool {
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		for _, e := range agg.Errors() {
+			if !apierrors.IsForbidden(e) {
+				return false
+			}
+		}
+		return len(agg.Errors()) > 0
 	}
-	po.pastGroupingObjects = retrievedGroupingInfos
-	po.retrievedGroupingObjects = true
-	return nil
+	return apierrors.IsForbidden(err)
+}
+
+// inventoryNamespaces returns the namespaces of the namespaced
+// objects in the inventory, in the order they are first found.
+// Cluster-scoped objects have no namespace and are skipped.
+func inventoryNamespaces(inv []*object.ObjMetadata) []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, obj := range inv {
+		if obj.Namespace == "" || seen[obj.Namespace] {
+			continue
+		}
+		seen[obj.Namespace] = true
+		namespaces = append(namespaces, obj.Namespace)
+	}
+	return namespaces
 }
 
 // infoToObjMetadata transforms the object represented by the passed "info"
@@ -152,12 +326,12 @@ func infoToObjMetadata(info *resource.Info) (*object.ObjMetadata, error) {
 	return object.CreateObjMetadata(info.Namespace, info.Name, gk)
 }
 
-// unionPastInventory takes a set of grouping objects (infos), returning the
+// UnionInventory takes a set of grouping objects (infos), returning the
 // union of the objects referenced by these grouping objects as an
 // Inventory. Returns an error if any of the passed objects are not
 // grouping objects, or if unable to retrieve the inventory from any
 // grouping object.
-func unionPastInventory(infos []*resource.Info) (*Inventory, error) {
+func UnionInventory(infos []*resource.Info) (*Inventory, error) {
 	inventorySet := NewInventory([]*object.ObjMetadata{})
 	for _, info := range infos {
 		inv, err := RetrieveInventoryFromGroupingObj([]*resource.Info{info})
@@ -180,7 +354,7 @@ func unionPastInventory(infos []*resource.Info) (*Inventory, error) {
 // applied objects, or if we are unable to get the currently applied objects
 // from the current grouping object.
 func (po *PruneOptions) calcPruneSet(pastGroupingInfos []*resource.Info) (*Inventory, error) {
-	pastInventory, err := unionPastInventory(pastGroupingInfos)
+	pastInventory, err := UnionInventory(pastGroupingInfos)
 	if err != nil {
 		return nil, err
 	}
@@ -197,10 +371,36 @@ func (po *PruneOptions) calcPruneSet(pastGroupingInfos []*resource.Info) (*Inven
 // (retrieved from previous grouping objects) but omitted in
 // the current apply. Prune also delete all previous grouping
 // objects. Returns an error if there was a problem.
-func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan<- event.Event) error {
+// The objects are deleted in the reverse of the order they are
+// applied in, so objects are deleted before the objects they
+// depend on.
+// The context is checked before each object is deleted. If it has
+// been cancelled, an event is sent for each object that was not
+// pruned and Prune returns without deleting the previous grouping
+// objects, so the remaining objects will be pruned by the next apply.
+func (po *PruneOptions) Prune(ctx context.Context, currentObjects []*resource.Info,
+	eventChannel chan<- event.Event) error {
+	pruneObjs, pastGroupingInfos, err := po.RetrievePruneSet(currentObjects)
+	if err != nil {
+		return err
+	}
+	completed, err := po.DeleteObjects(ctx, SortForDeletion(pruneObjs), eventChannel)
+	if err != nil || !completed {
+		return err
+	}
+	return po.DeleteGroupingObjects(ctx, pastGroupingInfos, eventChannel)
+}
+
+// RetrievePruneSet returns the objects that Prune would delete if the
+// currentObjects were applied, and the previous grouping objects
+// that are deleted once all of them are deleted.
+// The prune set is the union of the previous applies minus the
+// current one. Nothing is deleted, so it can also be used to find
+// what an apply would prune.
+func (po *PruneOptions) RetrievePruneSet(currentObjects []*resource.Info) ([]*object.ObjMetadata, []*resource.Info, error) {
 	currentGroupingObject, found := FindGroupingObject(currentObjects)
 	if !found {
-		return fmt.Errorf("current grouping object not found during prune")
+		return nil, nil, fmt.Errorf("current grouping object not found during prune")
 	}
 	po.currentGroupingObject = currentGroupingObject
 	// Initialize past grouping objects as empty.
@@ -211,33 +411,58 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 	// union of the previous applies as an inventory set.
 	pastGroupingInfos, err := po.getPreviousGroupingObjects()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	pruneSet, err := po.calcPruneSet(pastGroupingInfos)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	// Delete the prune objects.
-	for _, inv := range pruneSet.GetItems() {
+	return pruneSet.GetItems(), pastGroupingInfos, nil
+}
+
+// DeleteObjects deletes the objects in the order they are given, and
+// sends a prune event for each of them. Objects that no longer exist
+// are skipped. The context is checked before each object is deleted.
+// If it has been cancelled, an event is sent for each of the objects
+// that were not deleted, and false is returned.
+func (po *PruneOptions) DeleteObjects(ctx context.Context, objs []*object.ObjMetadata,
+	eventChannel chan<- event.Event) (bool, error) {
+	for i, inv := range objs {
+		if ctx.Err() != nil {
+			sendCancelledEvents(objs[i:], eventChannel)
+			return false, nil
+		}
 		mapping, err := po.mapper.RESTMapping(inv.GroupKind)
 		if err != nil {
-			return err
+			return false, err
 		}
 		// Fetching the resource here before deletion seems a bit unnecessary, but
 		// it allows us to work with the ResourcePrinter.
 		namespacedClient := po.client.Resource(mapping.Resource).Namespace(inv.Namespace)
-		obj, err := namespacedClient.Get(inv.Name, metav1.GetOptions{})
+		var obj *unstructured.Unstructured
+		err = po.RetryPolicy.Do(ctx, func() error {
+			var getErr error
+			obj, getErr = namespacedClient.Get(inv.Name, metav1.GetOptions{})
+			return getErr
+		}, onRetry(*inv, eventChannel))
 		if err != nil {
 			// Do not return if object to prune (delete) is not found
 			if apierrors.IsNotFound(err) {
+				sendSkippedEvent(inv, eventChannel)
 				continue
 			}
-			return err
+			return false, err
 		}
 		if !po.DryRun {
-			err = namespacedClient.Delete(inv.Name, &metav1.DeleteOptions{})
+			options, err := po.deleteOptionsFor(obj)
 			if err != nil {
-				return err
+				return false, fmt.Errorf("can't delete %s: %v", inv, err)
+			}
+			err = po.RetryPolicy.Do(ctx, func() error {
+				return ignoreNotFound(namespacedClient.Delete(inv.Name, options))
+			}, onRetry(*inv, eventChannel))
+			if err != nil {
+				return false, err
 			}
 		}
 		eventChannel <- event.Event{
@@ -248,12 +473,25 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 			},
 		}
 	}
-	// Delete previous grouping objects.
+	return true, nil
+}
+
+// DeleteGroupingObjects deletes the previous grouping objects, and
+// sends a prune event for each of them.
+func (po *PruneOptions) DeleteGroupingObjects(ctx context.Context, pastGroupingInfos []*resource.Info,
+	eventChannel chan<- event.Event) error {
 	for _, pastGroupInfo := range pastGroupingInfos {
 		if !po.DryRun {
-			err = po.client.Resource(pastGroupInfo.Mapping.Resource).
-				Namespace(pastGroupInfo.Namespace).
-				Delete(pastGroupInfo.Name, &metav1.DeleteOptions{})
+			groupingClient := po.client.Resource(pastGroupInfo.Mapping.Resource).
+				Namespace(pastGroupInfo.Namespace)
+			id := object.ObjMetadata{
+				GroupKind: pastGroupInfo.Object.GetObjectKind().GroupVersionKind().GroupKind(),
+				Namespace: pastGroupInfo.Namespace,
+				Name:      pastGroupInfo.Name,
+			}
+			err := po.RetryPolicy.Do(ctx, func() error {
+				return ignoreNotFound(groupingClient.Delete(pastGroupInfo.Name, po.deleteOptions()))
+			}, onRetry(id, eventChannel))
 			if err != nil {
 				return err
 			}
@@ -268,3 +506,144 @@ func (po *PruneOptions) Prune(currentObjects []*resource.Info, eventChannel chan
 	}
 	return nil
 }
+
+// RemoveFinalizers removes the finalizers from the objects that are
+// still terminating, so they are deleted even if the controllers that
+// should remove the finalizers are gone. Objects that no longer exist,
+// or that are not being deleted, are left alone. If an object changes
+// before its finalizers are removed, it is fetched and checked again.
+// A prune event is sent for every object whose finalizers are removed.
+func (po *PruneOptions) RemoveFinalizers(ctx context.Context, objs []*object.ObjMetadata,
+	eventChannel chan<- event.Event) error {
+	for _, inv := range objs {
+		if ctx.Err() != nil {
+			return nil
+		}
+		mapping, err := po.mapper.RESTMapping(inv.GroupKind)
+		if err != nil {
+			return err
+		}
+		namespacedClient := po.client.Resource(mapping.Resource).Namespace(inv.Namespace)
+		var obj *unstructured.Unstructured
+		var removed bool
+		err = po.RetryPolicy.Do(ctx, func() error {
+			removed = false
+			var getErr error
+			obj, getErr = namespacedClient.Get(inv.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			if obj.GetDeletionTimestamp() == nil || len(obj.GetFinalizers()) == 0 {
+				return nil
+			}
+			if !po.DryRun {
+				// The resourceVersion makes the patch fail with a
+				// Conflict if the object has changed since it was
+				// fetched, so only the finalizers that are reported
+				// are removed. The retry fetches and checks it again.
+				patch := fmt.Sprintf(`{"metadata":{"finalizers":null,"resourceVersion":%q}}`,
+					obj.GetResourceVersion())
+				_, patchErr := namespacedClient.Patch(inv.Name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+				if patchErr != nil {
+					return patchErr
+				}
+			}
+			removed = true
+			return nil
+		}, onRetry(*inv, eventChannel))
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("can't remove the finalizers of %s: %v", inv, err)
+		}
+		if !removed {
+			continue
+		}
+		eventChannel <- event.Event{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				Type:   event.PruneEventFinalizersRemoved,
+				Object: obj,
+			},
+		}
+	}
+	return nil
+}
+
+// deleteOptions returns the options for deleting the pruned objects.
+func (po *PruneOptions) deleteOptions() *metav1.DeleteOptions {
+	options := &metav1.DeleteOptions{
+		GracePeriodSeconds: po.GracePeriodSeconds,
+	}
+	if po.PropagationPolicy != "" {
+		policy := po.PropagationPolicy
+		options.PropagationPolicy = &policy
+	}
+	if po.ServerDryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	return options
+}
+
+// sendCancelledEvents sends an event for each of the provided
+// objects to signal that they were not pruned.
+func sendCancelledEvents(objs []*object.ObjMetadata, eventChannel chan<- event.Event) {
+	for _, obj := range objs {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(obj.GroupKind.WithVersion(""))
+		u.SetNamespace(obj.Namespace)
+		u.SetName(obj.Name)
+		eventChannel <- event.Event{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				Type:   event.PruneEventResourceCancelled,
+				Object: u,
+			},
+		}
+	}
+}
+
+// sendSkippedEvent sends an event to signal that the object was not
+// pruned since it no longer exists.
+func sendSkippedEvent(obj *object.ObjMetadata, eventChannel chan<- event.Event) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(obj.GroupKind.WithVersion(""))
+	u.SetNamespace(obj.Namespace)
+	u.SetName(obj.Name)
+	eventChannel <- event.Event{
+		Type: event.PruneType,
+		PruneEvent: event.PruneEvent{
+			Type:   event.PruneEventResourceSkipped,
+			Object: u,
+		},
+	}
+}
+
+// onRetry returns a function that sends an event on the eventChannel
+// when fetching or deleting the identified object is retried.
+func onRetry(id object.ObjMetadata, eventChannel chan<- event.Event) retry.OnRetryFunc {
+	return func(attempt int, err error, backoff time.Duration) {
+		eventChannel <- event.Event{
+			Type: event.RetryType,
+			RetryEvent: event.RetryEvent{
+				Action:     event.PruneAction,
+				Identifier: id,
+				Attempt:    attempt,
+				Backoff:    backoff,
+				Err:        err,
+			},
+		}
+	}
+}
+
+// ignoreNotFound returns nil if the error is a NotFound error. An
+// object that is already gone doesn't need to be deleted, which can
+// happen if a delete is retried after an error even though the
+// first attempt actually succeeded.
+func ignoreNotFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -4,10 +4,22 @@
 package prune
 
 import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"regexp"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	"k8s.io/client-go/restmapper"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/cli-utils/pkg/object"
 )
 
@@ -149,7 +161,7 @@ func TestUnionPastInventory(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			actual, err := unionPastInventory(tc.groupingInfos)
+			actual, err := UnionInventory(tc.groupingInfos)
 			expected := NewInventory(tc.expected)
 			if err != nil {
 				t.Errorf("Unexpected error received: %s\n", err)
@@ -242,3 +254,347 @@ func TestCalcPruneSet(t *testing.T) {
 		})
 	}
 }
+
+func TestInventoryNamespaces(t *testing.T) {
+	clusterRole := &object.ObjMetadata{
+		Name: "cluster-role",
+		GroupKind: schema.GroupKind{
+			Group: "rbac.authorization.k8s.io",
+			Kind:  "ClusterRole",
+		},
+	}
+	otherPod := &object.ObjMetadata{
+		Namespace: "other-namespace",
+		Name:      pod1Name,
+		GroupKind: schema.GroupKind{
+			Group: "",
+			Kind:  "Pod",
+		},
+	}
+	tests := map[string]struct {
+		inv      []*object.ObjMetadata
+		expected []string
+	}{
+		"Empty inventory has no namespaces": {
+			inv:      []*object.ObjMetadata{},
+			expected: nil,
+		},
+		"Cluster-scoped objects are skipped": {
+			inv:      []*object.ObjMetadata{clusterRole},
+			expected: nil,
+		},
+		"Namespaces are returned once in the order they are found": {
+			inv:      []*object.ObjMetadata{pod1Inv, clusterRole, otherPod, pod2Inv},
+			expected: []string{testNamespace, "other-namespace"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual := inventoryNamespaces(tc.inv)
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Errorf("expected namespaces (%v), got (%v)", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRetrievePreviousGroupingObjects(t *testing.T) {
+	podInNamespace := func(namespace string) *object.ObjMetadata {
+		return &object.ObjMetadata{
+			Namespace: namespace,
+			Name:      pod1Name,
+			GroupKind: schema.GroupKind{Kind: "Pod"},
+		}
+	}
+	groupingObjIn := func(namespace, name string, inv ...*object.ObjMetadata) map[string]interface{} {
+		data := map[string]interface{}{}
+		for _, obj := range inv {
+			data[obj.String()] = ""
+		}
+		obj := map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]interface{}{
+				"name": name,
+				"labels": map[string]interface{}{
+					GroupingLabel: testGroupingLabel,
+				},
+			},
+			"data": data,
+		}
+		if namespace == "" {
+			obj["apiVersion"] = "cli-utils.sigs.k8s.io/v1alpha1"
+			obj["kind"] = "ClusterInventory"
+		} else {
+			_ = unstructured.SetNestedField(obj, namespace, "metadata", "namespace")
+		}
+		return obj
+	}
+	// The grouping object in the "first" namespace references an object
+	// in the "second" namespace, which holds an older grouping object
+	// referencing an object in the "third" namespace. The grouping
+	// object in the "moved" namespace is never referenced, as if the
+	// package had moved its grouping object out of that namespace
+	// together with all of its objects. The ClusterInventory references
+	// an object in the "fourth" namespace.
+	groupingObjs := map[string][]map[string]interface{}{
+		"first": {
+			groupingObjIn("first", "inventory-1", podInNamespace("first"), podInNamespace("second")),
+		},
+		"second": {
+			groupingObjIn("second", "inventory-2", podInNamespace("third")),
+		},
+		"moved": {
+			groupingObjIn("moved", "inventory-3", podInNamespace("moved")),
+		},
+		"": {
+			groupingObjIn("", "inventory-4", podInNamespace("fourth")),
+		},
+	}
+
+	tests := map[string]struct {
+		current             map[string]interface{}
+		namespaces          []string
+		forbidAllNamespaces bool
+		expectedNamespaces  []string
+		expectedNames       []string
+	}{
+		"Grouping objects are listed in all namespaces": {
+			current:            groupingObjIn("first", "inventory-current", podInNamespace("first")),
+			namespaces:         []string{"first"},
+			expectedNamespaces: []string{"*"},
+			expectedNames:      []string{"inventory-1", "inventory-3", "inventory-2"},
+		},
+		"Grouping object moved out of a namespace is found": {
+			current:            groupingObjIn("second", "inventory-current", podInNamespace("second")),
+			namespaces:         []string{"second"},
+			expectedNamespaces: []string{"*"},
+			expectedNames:      []string{"inventory-1", "inventory-3", "inventory-2"},
+		},
+		"Namespaces referenced by previous grouping objects are searched": {
+			current:             groupingObjIn("first", "inventory-current", podInNamespace("first")),
+			namespaces:          []string{"first"},
+			forbidAllNamespaces: true,
+			expectedNamespaces:  []string{"*", "first", "second", "third"},
+			expectedNames:       []string{"inventory-1", "inventory-2"},
+		},
+		"Previous ClusterInventory objects are retrieved": {
+			current:             groupingObjIn("", "inventory-current"),
+			namespaces:          []string{},
+			forbidAllNamespaces: true,
+			expectedNamespaces:  []string{"", "*", "fourth"},
+			expectedNames:       []string{"inventory-4"},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("first")
+			defer tf.Cleanup()
+
+			var requested []string
+			codec := scheme.Codecs.LegacyCodec(scheme.Scheme.PrioritizedVersionsAllGroups()...)
+			pathRegex := regexp.MustCompile(`^(?:/namespaces/([^/]+))?/(configmaps|clusterinventories)$`)
+			client := &fake.RESTClient{
+				NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+				Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					match := pathRegex.FindStringSubmatch(req.URL.Path)
+					if req.Method != http.MethodGet || match == nil {
+						t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+					}
+					namespaces := []string{match[1]}
+					// ConfigMaps listed without a namespace are
+					// listed in all namespaces.
+					if match[1] == "" && match[2] == "configmaps" {
+						requested = append(requested, "*")
+						if tc.forbidAllNamespaces {
+							return &http.Response{
+								StatusCode: http.StatusForbidden,
+								Header:     cmdtesting.DefaultHeader(),
+								Body: cmdtesting.ObjBody(codec, &metav1.Status{
+									Status: metav1.StatusFailure,
+									Reason: metav1.StatusReasonForbidden,
+									Code:   http.StatusForbidden,
+								}),
+							}, nil
+						}
+						namespaces = []string{"first", "moved", "second"}
+					} else {
+						requested = append(requested, match[1])
+					}
+					list := &unstructured.UnstructuredList{
+						Object: map[string]interface{}{
+							"apiVersion": "v1",
+							"kind":       "List",
+						},
+					}
+					for _, namespace := range namespaces {
+						for _, obj := range groupingObjs[namespace] {
+							list.Items = append(list.Items, unstructured.Unstructured{Object: obj})
+						}
+					}
+					body, err := list.MarshalJSON()
+					if err != nil {
+						t.Fatal(err)
+					}
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     cmdtesting.DefaultHeader(),
+						Body:       ioutil.NopCloser(bytes.NewReader(body)),
+					}, nil
+				}),
+			}
+			mapper, err := tf.ToRESTMapper()
+			if err != nil {
+				t.Fatal(err)
+			}
+			clusterInventoryGV := schema.GroupVersion{Group: ClusterInventoryGroupKind.Group, Version: "v1alpha1"}
+			clusterInventoryMapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{clusterInventoryGV})
+			clusterInventoryMapper.Add(clusterInventoryGV.WithKind(ClusterInventoryGroupKind.Kind), meta.RESTScopeRoot)
+
+			po := NewPruneOptions()
+			po.newBuilder = func() *resource.Builder {
+				return resource.NewFakeBuilder(
+					func(schema.GroupVersion) (resource.RESTClient, error) {
+						return client, nil
+					},
+					func() (meta.RESTMapper, error) {
+						return meta.MultiRESTMapper{mapper, clusterInventoryMapper}, nil
+					},
+					func() (restmapper.CategoryExpander, error) {
+						return resource.FakeCategoryExpander, nil
+					},
+				)
+			}
+			po.currentGroupingObject = &resource.Info{
+				Object: &unstructured.Unstructured{Object: tc.current},
+			}
+			if err := po.retrievePreviousGroupingObjects(tc.namespaces); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tc.expectedNamespaces, requested) {
+				t.Errorf("expected lookups in namespaces (%v), got (%v)", tc.expectedNamespaces, requested)
+			}
+			var names []string
+			for _, info := range po.pastGroupingObjects {
+				names = append(names, info.Name)
+			}
+			if !reflect.DeepEqual(tc.expectedNames, names) {
+				t.Errorf("expected grouping objects (%v), got (%v)", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestDeleteOptions(t *testing.T) {
+	po := NewPruneOptions()
+	if dryRun := po.deleteOptions().DryRun; len(dryRun) != 0 {
+		t.Errorf("expected no dry-run for delete, got %v", dryRun)
+	}
+	if options := po.deleteOptions(); options.PropagationPolicy != nil || options.GracePeriodSeconds != nil {
+		t.Errorf("expected default propagation policy and grace period, got %v", options)
+	}
+	po.ServerDryRun = true
+	if dryRun := po.deleteOptions().DryRun; !reflect.DeepEqual(dryRun, []string{"All"}) {
+		t.Errorf("expected server dry-run for delete, got %v", dryRun)
+	}
+	po.PropagationPolicy = metav1.DeletePropagationOrphan
+	if policy := po.deleteOptions().PropagationPolicy; policy == nil || *policy != metav1.DeletePropagationOrphan {
+		t.Errorf("expected orphan propagation policy, got %v", policy)
+	}
+}
+
+func TestDeleteOptionsFor(t *testing.T) {
+	gracePeriod := int64(10)
+	orphan := metav1.DeletePropagationOrphan
+	foreground := metav1.DeletePropagationForeground
+	zero := int64(0)
+
+	tests := map[string]struct {
+		annotations         map[string]string
+		expectedPolicy      *metav1.DeletionPropagation
+		expectedGracePeriod *int64
+		isError             bool
+	}{
+		"no annotations use the prune options": {
+			expectedPolicy:      &foreground,
+			expectedGracePeriod: &gracePeriod,
+		},
+		"annotations override the prune options": {
+			annotations: map[string]string{
+				PropagationPolicyAnnotation: "orphan",
+				GracePeriodAnnotation:       "0",
+			},
+			expectedPolicy:      &orphan,
+			expectedGracePeriod: &zero,
+		},
+		"unknown propagation policy": {
+			annotations: map[string]string{PropagationPolicyAnnotation: "sideways"},
+			isError:     true,
+		},
+		"empty propagation policy": {
+			annotations: map[string]string{PropagationPolicyAnnotation: ""},
+			isError:     true,
+		},
+		"negative grace period": {
+			annotations: map[string]string{GracePeriodAnnotation: "-1"},
+			isError:     true,
+		},
+		"grace period is not a number": {
+			annotations: map[string]string{GracePeriodAnnotation: "1m"},
+			isError:     true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			po := NewPruneOptions()
+			po.PropagationPolicy = metav1.DeletePropagationForeground
+			po.GracePeriodSeconds = &gracePeriod
+			obj := &unstructured.Unstructured{}
+			obj.SetAnnotations(tc.annotations)
+			options, err := po.deleteOptionsFor(obj)
+			if tc.isError {
+				if err == nil {
+					t.Errorf("expected error, but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(tc.expectedPolicy, options.PropagationPolicy) {
+				t.Errorf("expected propagation policy %v, got %v", *tc.expectedPolicy, options.PropagationPolicy)
+			}
+			if !reflect.DeepEqual(tc.expectedGracePeriod, options.GracePeriodSeconds) {
+				t.Errorf("expected grace period %v, got %v", *tc.expectedGracePeriod, options.GracePeriodSeconds)
+			}
+		})
+	}
+}
+
+func TestParsePropagationPolicy(t *testing.T) {
+	tests := map[string]struct {
+		name     string
+		expected metav1.DeletionPropagation
+		isError  bool
+	}{
+		"empty":            {name: "", expected: ""},
+		"exact":            {name: "Background", expected: metav1.DeletePropagationBackground},
+		"case insensitive": {name: "FOREGROUND", expected: metav1.DeletePropagationForeground},
+		"unknown":          {name: "cascade", isError: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			policy, err := ParsePropagationPolicy(tc.name)
+			if tc.isError != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tc.isError, err)
+			}
+			if policy != tc.expected {
+				t.Errorf("expected policy %q, got %q", tc.expected, policy)
+			}
+		})
+	}
+}
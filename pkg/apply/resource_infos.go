@@ -8,6 +8,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/ordering"
 )
 
 type ResourceInfos []*resource.Info
@@ -27,58 +28,16 @@ func (a ResourceInfos) Less(i, j int) bool {
 	return a[i].Namespace+a[i].Name < a[j].Namespace+a[j].Name
 }
 
-// An attempt to order things to help k8s, e.g.
-// a Service should come before things that refer to it.
-// Namespace should be first.
-// In some cases order just specified to provide determinism.
-var orderFirst = []string{
-	"Namespace",
-	"ResourceQuota",
-	"StorageClass",
-	"CustomResourceDefinition",
-	"MutatingWebhookConfiguration",
-	"ServiceAccount",
-	"PodSecurityPolicy",
-	"Role",
-	"ClusterRole",
-	"RoleBinding",
-	"ClusterRoleBinding",
-	"ConfigMap",
-	"Secret",
-	"Service",
-	"LimitRange",
-	"PriorityClass",
-	"Deployment",
-	"StatefulSet",
-	"CronJob",
-	"PodDisruptionBudget",
-}
-
-var orderLast = []string{
-	"ValidatingWebhookConfiguration",
-}
-
-// getIndexByKind returns the index of the kind respecting the order
-func getIndexByKind(kind string) int {
-	m := map[string]int{}
-	for i, n := range orderFirst {
-		m[n] = -len(orderFirst) + i
-	}
-	for i, n := range orderLast {
-		m[n] = 1 + i
-	}
-	return m[kind]
-}
-
 // Equals returns true if the GVK's have equal fields.
 func Equals(x schema.GroupVersionKind, o schema.GroupVersionKind) bool {
 	return x.Group == o.Group && x.Version == o.Version && x.Kind == o.Kind
 }
 
-// IsLessThan compares two GVK's as per orderFirst and orderLast, returns boolean result.
+// IsLessThan compares two GVK's as per the order defined in the ordering
+// package, returns boolean result.
 func IsLessThan(x schema.GroupVersionKind, o schema.GroupVersionKind) bool {
-	indexI := getIndexByKind(x.Kind)
-	indexJ := getIndexByKind(o.Kind)
+	indexI := ordering.IndexByKind(x.Kind)
+	indexJ := ordering.IndexByKind(o.Kind)
 	if indexI != indexJ {
 		return indexI < indexJ
 	}
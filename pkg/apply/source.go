@@ -0,0 +1,149 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"fmt"
+
+	"github.com/go-errors/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// ManifestSource provides the manifests of the resources to apply. It
+// is an alternative to reading the manifests from files and directories
+// with the ApplyOptions, for programs that produce them some other way.
+// The grouping object template must be among the manifests.
+type ManifestSource interface {
+	Objects() ([]*unstructured.Unstructured, error)
+}
+
+// KioSource reads the manifests with a kyaml Reader and passes them
+// through the Filters before they are applied, so setters, KRM functions
+// or any other kio.Filter can be run on the package. The Reader is read
+// every time the manifests are needed, so a Reader for a stream like
+// StdIn can only be used once.
+type KioSource struct {
+	Reader  kio.Reader
+	Filters []kio.Filter
+}
+
+var _ ManifestSource = KioSource{}
+
+// Objects runs the pipeline and returns the resulting manifests. The
+// annotations that kyaml adds to record where each manifest was read
+// from are removed, since they are not part of the resources.
+func (k KioSource) Objects() ([]*unstructured.Unstructured, error) {
+	var objs []*unstructured.Unstructured
+	err := kio.Pipeline{
+		Inputs:  []kio.Reader{k.Reader},
+		Filters: k.Filters,
+		Outputs: []kio.Writer{kio.WriterFunc(func(nodes []*yaml.RNode) error {
+			for _, node := range nodes {
+				obj, err := nodeToUnstructured(node)
+				if err != nil {
+					return err
+				}
+				objs = append(objs, obj)
+			}
+			return nil
+		})},
+	}.Execute()
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error reading manifests", 1)
+	}
+	return objs, nil
+}
+
+// ObjectSource provides manifests that are already in memory, so
+// programs embedding the library don't have to write them to files.
+type ObjectSource []*unstructured.Unstructured
+
+var _ ManifestSource = ObjectSource{}
+
+// Objects returns copies of the manifests, so they are not changed
+// when they are applied.
+func (o ObjectSource) Objects() ([]*unstructured.Unstructured, error) {
+	objs := make([]*unstructured.Unstructured, 0, len(o))
+	for _, obj := range o {
+		objs = append(objs, obj.DeepCopy())
+	}
+	return objs, nil
+}
+
+// nodeToUnstructured converts a manifest read with kyaml to
+// Unstructured, without the kyaml index and path annotations. The
+// manifest is converted through JSON so numbers are decoded as int64
+// where possible, the same way as by the kubectl builder.
+func nodeToUnstructured(node *yaml.RNode) (*unstructured.Unstructured, error) {
+	s, err := node.String()
+	if err != nil {
+		return nil, err
+	}
+	j, err := sigsyaml.YAMLToJSON([]byte(s))
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(j); err != nil {
+		return nil, err
+	}
+	annotations := u.GetAnnotations()
+	delete(annotations, kioutil.IndexAnnotation)
+	delete(annotations, kioutil.PathAnnotation)
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(u.Object, "metadata", "annotations")
+	} else {
+		u.SetAnnotations(annotations)
+	}
+	return u, nil
+}
+
+// objectsToInfos creates the Infos for the manifests, with a client
+// for each of them. Namespaced resources without a namespace are put
+// in the default namespace. If the namespace is enforced, a manifest
+// for a different namespace is an error, as it is for kubectl.
+func objectsToInfos(factory util.Factory, objs []*unstructured.Unstructured,
+	namespace string, enforceNamespace bool) ([]*resource.Info, error) {
+	mapper, err := factory.ToRESTMapper()
+	if err != nil {
+		return nil, errors.WrapPrefix(err, "error getting RESTMapper", 1)
+	}
+	var infos []*resource.Info
+	for _, obj := range objs {
+		gvk := obj.GroupVersionKind()
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, fmt.Sprintf("error getting mapping for %s %s", gvk.Kind, obj.GetName()), 1)
+		}
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			switch ns := obj.GetNamespace(); {
+			case ns == "":
+				obj.SetNamespace(namespace)
+			case enforceNamespace && ns != namespace:
+				return nil, fmt.Errorf("the namespace from the provided object %q does not match the namespace %q. "+
+					"You must pass '--namespace=%s' to perform this operation", ns, namespace, ns)
+			}
+		}
+		client, err := factory.UnstructuredClientForMapping(mapping)
+		if err != nil {
+			return nil, errors.WrapPrefix(err, "error creating client", 1)
+		}
+		infos = append(infos, &resource.Info{
+			Client:          client,
+			Mapping:         mapping,
+			Namespace:       obj.GetNamespace(),
+			Name:            obj.GetName(),
+			Object:          obj,
+			ResourceVersion: obj.GetResourceVersion(),
+		})
+	}
+	return infos, nil
+}
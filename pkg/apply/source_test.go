@@ -0,0 +1,156 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+var sourceManifests = `
+kind: ConfigMap
+apiVersion: v1
+metadata:
+  labels:
+    cli-utils.sigs.k8s.io/inventory-id: test
+  name: inventory
+---
+kind: Deployment
+apiVersion: apps/v1
+metadata:
+  name: foo
+  annotations:
+    owner: team
+spec:
+  replicas: 1
+`
+
+func TestKioSource(t *testing.T) {
+	setReplicas := kio.FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		for _, node := range nodes {
+			meta, err := node.GetMeta()
+			if err != nil {
+				return nil, err
+			}
+			if meta.Kind != "Deployment" {
+				continue
+			}
+			err = node.PipeE(yaml.Lookup("spec"), yaml.SetField("replicas", yaml.NewScalarRNode("3")))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	})
+	source := KioSource{
+		Reader:  &kio.ByteReader{Reader: bytes.NewBufferString(sourceManifests)},
+		Filters: []kio.Filter{setReplicas},
+	}
+
+	objs, err := source.Objects()
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, objs, 2) {
+		return
+	}
+
+	assert.Equal(t, "inventory", objs[0].GetName())
+	_, found, _ := unstructured.NestedMap(objs[0].Object, "metadata", "annotations")
+	assert.False(t, found, "kyaml annotations should be removed")
+
+	assert.Equal(t, "foo", objs[1].GetName())
+	assert.Equal(t, map[string]string{"owner": "team"}, objs[1].GetAnnotations())
+	replicas, _, _ := unstructured.NestedFieldNoCopy(objs[1].Object, "spec", "replicas")
+	assert.Equal(t, int64(3), replicas)
+}
+
+func TestObjectSource(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetName("foo")
+	source := ObjectSource{obj}
+
+	objs, err := source.Objects()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []*unstructured.Unstructured{obj}, objs)
+	objs[0].SetNamespace("changed")
+	assert.Equal(t, "", obj.GetNamespace(), "the manifests should be copied")
+}
+
+func TestReadObjectsFromSource(t *testing.T) {
+	testCases := map[string]struct {
+		enforceNamespace  bool
+		namespace         string
+		expectedNamespace string
+		expectedError     bool
+	}{
+		"namespace is defaulted": {
+			expectedNamespace: "namespace",
+		},
+		"namespace in manifest is kept": {
+			namespace:         "other",
+			expectedNamespace: "other",
+		},
+		"namespace in manifest must match the enforced namespace": {
+			enforceNamespace: true,
+			namespace:        "other",
+			expectedError:    true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tf := cmdtesting.NewTestFactory().WithNamespace("namespace")
+			defer tf.Cleanup()
+
+			ioStreams, _, _, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			applier := NewApplier(tf, ioStreams)
+			applier.ApplyOptions.Namespace = "namespace"
+			applier.ApplyOptions.EnforceNamespace = tc.enforceNamespace
+
+			objs, err := KioSource{
+				Reader: &kio.ByteReader{Reader: bytes.NewBufferString(sourceManifests)},
+			}.Objects()
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, obj := range objs {
+				obj.SetNamespace(tc.namespace)
+			}
+			applier.Source = ObjectSource(objs)
+
+			infos, err := applier.readAndPrepareObjects()
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			if !assert.Len(t, infos, 2) {
+				return
+			}
+			assert.True(t, prune.IsGroupingObject(infos[0].Object))
+			for _, info := range infos {
+				assert.Equal(t, tc.expectedNamespace, info.Namespace)
+				assert.NotNil(t, info.Mapping)
+			}
+			cached, err := applier.ApplyOptions.GetObjects()
+			assert.NoError(t, err)
+			assert.Len(t, cached, 2)
+		})
+	}
+}
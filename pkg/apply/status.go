@@ -11,9 +11,10 @@ import (
 
 func NewStatusOptions() *StatusOptions {
 	return &StatusOptions{
-		wait:    false,
-		period:  2 * time.Second,
-		Timeout: time.Minute,
+		wait:        false,
+		period:      2 * time.Second,
+		Timeout:     time.Minute,
+		ErrorBudget: 5,
 	}
 }
 
@@ -21,10 +22,14 @@ type StatusOptions struct {
 	wait    bool
 	period  time.Duration
 	Timeout time.Duration
+	// ErrorBudget is the number of consecutive times polling for
+	// status can fail before the apply is aborted.
+	ErrorBudget int
 }
 
 func (s *StatusOptions) AddFlags(c *cobra.Command) {
 	c.Flags().BoolVar(&s.wait, "wait-for-reconcile", s.wait, "Wait for all applied resources to reach the Current status.")
 	c.Flags().DurationVar(&s.period, "wait-polling-period", s.period, "Polling period for resource statuses.")
 	c.Flags().DurationVar(&s.Timeout, "wait-timeout", s.Timeout, "Timeout threshold for waiting for all resources to reach the Current status.")
+	c.Flags().IntVar(&s.ErrorBudget, "wait-error-budget", s.ErrorBudget, "Number of consecutive times polling for resource statuses can fail before giving up.")
 }
@@ -4,31 +4,316 @@
 package task
 
 import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/ordering"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 )
 
 // ApplyTask applies the given Objects to the cluster
-// by using the ApplyOptions.
+// by using the ApplyOptions. Every object is applied with a copy
+// of the ApplyOptions, so ApplyTasks that don't depend on each
+// other can share the same ApplyOptions.
 type ApplyTask struct {
 	ApplyOptions *apply.ApplyOptions
 	Objects      []*resource.Info
+	// EventChannel is used to report the objects that were
+	// not applied if the task is cancelled.
+	EventChannel chan event.Event
+	// Context is checked before each object is applied. If it
+	// has been cancelled, the remaining objects are skipped.
+	// If no Context is set, the task can not be cancelled.
+	Context context.Context
+	// Concurrency is the maximum number of objects that will be
+	// applied at the same time. Only objects in the same ordering
+	// group, i.e. with kinds that have the same index in the apply
+	// order, are applied concurrently. The Objects are expected to
+	// already be sorted. Values less than 2 means the objects are
+	// applied one at a time.
+	Concurrency int
+	// RetryPolicy decides which errors from applying an object
+	// will be retried. An event is sent on the EventChannel for
+	// every retry. If it is nil, errors are never retried.
+	RetryPolicy *retry.Policy
+	// ReportErrors makes the task send an ApplyEventResourceFailed
+	// event for each object that fails to apply and continue with the
+	// remaining objects, rather than failing the task. It is used for
+	// a server-side dry-run, where every rejection should be reported.
+	ReportErrors bool
+	// Precondition is called for each object right before it is
+	// applied. If it returns an error, the object is not applied.
+	// Errors from the cluster are retried with the RetryPolicy, but
+	// any other error is handled like a failure to apply the object.
+	// If it is nil, there is no precondition.
+	Precondition func(*resource.Info) error
 }
 
 // Start creates a new goroutine that will invoke
 // the Run function on the ApplyOptions to update
 // the cluster. It will push a TaskResult on the taskChannel
 // to signal to the taskrunner that the task has completed (or failed).
+// The objects are applied one at a time, or in concurrent batches
+// if Concurrency is set, so the task can stop between objects if
+// the context is cancelled. In that case an event is sent for each
+// object that was not applied and the task completes without an error.
 func (a *ApplyTask) Start(taskChannel chan taskrunner.TaskResult) {
 	go func() {
-		a.ApplyOptions.SetObjects(a.Objects)
-		err := a.ApplyOptions.Run()
+		ctx := a.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var err error
+		if a.Concurrency > 1 {
+			err = a.applyConcurrently(ctx)
+		} else {
+			err = a.applySequentially(ctx)
+		}
 		taskChannel <- taskrunner.TaskResult{
 			Err: err,
 		}
 	}()
 }
 
+// applySequentially applies the objects one at a time. Each object
+// is applied with a copy of the ApplyOptions, so other tasks that
+// share the same ApplyOptions can run at the same time.
+func (a *ApplyTask) applySequentially(ctx context.Context) error {
+	for i, info := range a.Objects {
+		if ctx.Err() != nil {
+			a.sendCancelledEvents(a.Objects[i:])
+			return nil
+		}
+		result := a.applyObject(ctx, info)
+		err := result.flush(a.ApplyOptions.ToPrinter, a.ApplyOptions.Out)
+		if err == nil {
+			err = result.err
+		}
+		if err != nil {
+			if !a.ReportErrors {
+				return err
+			}
+			a.sendFailedEvent(info, err)
+		}
+	}
+	return nil
+}
+
+// applyConcurrently applies the objects one ordering group at a
+// time, with up to Concurrency objects from the group being applied
+// at the same time. The output from applying each object is buffered
+// and passed on to the printer of the ApplyOptions in the same order
+// as the objects once the whole group has been applied, so the
+// events are always delivered in a deterministic order. If applying
+// any of the objects fails, the first error is returned after the
+// group has completed and no further groups are applied.
+func (a *ApplyTask) applyConcurrently(ctx context.Context) error {
+	groups := groupByOrder(a.Objects)
+	for i, group := range groups {
+		results := make([]*applyResult, len(group))
+		started := a.applyGroup(ctx, group, results)
+		var err error
+		for j, result := range results[:started] {
+			if flushErr := result.flush(a.ApplyOptions.ToPrinter, a.ApplyOptions.Out); flushErr != nil && err == nil {
+				err = flushErr
+			}
+			if result.err != nil && a.ReportErrors {
+				a.sendFailedEvent(group[j], result.err)
+				continue
+			}
+			if result.err != nil && err == nil {
+				err = result.err
+			}
+		}
+		if err != nil {
+			return err
+		}
+		if started < len(group) {
+			a.sendCancelledEvents(group[started:])
+			for _, g := range groups[i+1:] {
+				a.sendCancelledEvents(g)
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// applyGroup applies the provided objects concurrently and stores
+// the result for each of them in results. Objects are started in
+// order, and no new objects are started once the context has been
+// cancelled. It returns the number of objects that were started,
+// after all of them have completed.
+func (a *ApplyTask) applyGroup(ctx context.Context, infos []*resource.Info, results []*applyResult) int {
+	semaphore := make(chan struct{}, a.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for i, info := range infos {
+		semaphore <- struct{}{}
+		if ctx.Err() != nil {
+			return i
+		}
+		wg.Add(1)
+		go func(i int, info *resource.Info) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+			results[i] = a.applyObject(ctx, info)
+		}(i, info)
+	}
+	return len(infos)
+}
+
+// applyObject applies a single object using a copy of the
+// ApplyOptions, so it can safely run at the same time as other
+// objects are being applied. The copy doesn't share the sets
+// of visited objects and namespaces, as those are only needed
+// for the kubectl prune, which isn't used here.
+func (a *ApplyTask) applyObject(ctx context.Context, info *resource.Info) *applyResult {
+	result := &applyResult{}
+	if result.err = a.checkPrecondition(ctx, info); result.err != nil {
+		return result
+	}
+	opts := *a.ApplyOptions
+	opts.VisitedUids = sets.NewString()
+	opts.VisitedNamespaces = sets.NewString()
+	opts.ToPrinter = result.toPrinter
+	opts.SetObjects([]*resource.Info{info})
+	result.err = a.RetryPolicy.Do(ctx, func() error {
+		// Only keep the output from the last attempt.
+		result.printed = nil
+		return opts.Run()
+	}, a.onRetry(info))
+	return result
+}
+
+// checkPrecondition calls the Precondition of the task for the
+// provided object, if there is one.
+func (a *ApplyTask) checkPrecondition(ctx context.Context, info *resource.Info) error {
+	if a.Precondition == nil {
+		return nil
+	}
+	return a.RetryPolicy.Do(ctx, func() error {
+		return a.Precondition(info)
+	}, a.onRetry(info))
+}
+
+// onRetry returns a function that sends an event on the EventChannel
+// when applying the provided object is retried.
+func (a *ApplyTask) onRetry(info *resource.Info) retry.OnRetryFunc {
+	return func(attempt int, err error, backoff time.Duration) {
+		a.EventChannel <- event.Event{
+			Type: event.RetryType,
+			RetryEvent: event.RetryEvent{
+				Action: event.ApplyAction,
+				Identifier: object.ObjMetadata{
+					GroupKind: info.Object.GetObjectKind().GroupVersionKind().GroupKind(),
+					Namespace: info.Namespace,
+					Name:      info.Name,
+				},
+				Attempt: attempt,
+				Backoff: backoff,
+				Err:     err,
+			},
+		}
+	}
+}
+
+// sendCancelledEvents sends an event for each of the provided
+// objects to signal that they were not applied.
+func (a *ApplyTask) sendCancelledEvents(infos []*resource.Info) {
+	for _, info := range infos {
+		a.EventChannel <- event.Event{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Type:   event.ApplyEventResourceCancelled,
+				Object: info.Object,
+			},
+		}
+	}
+}
+
+// sendFailedEvent sends an event to signal that the provided object
+// could not be applied.
+func (a *ApplyTask) sendFailedEvent(info *resource.Info, err error) {
+	a.EventChannel <- event.Event{
+		Type: event.ApplyType,
+		ApplyEvent: event.ApplyEvent{
+			Type:   event.ApplyEventResourceFailed,
+			Object: info.Object,
+			Error:  err,
+		},
+	}
+}
+
+// groupByOrder splits the sorted infos into groups of consecutive
+// objects that have the same index in the apply order.
+func groupByOrder(infos []*resource.Info) [][]*resource.Info {
+	var groups [][]*resource.Info
+	lastIndex := 0
+	for i, info := range infos {
+		index := ordering.IndexByKind(info.Object.GetObjectKind().GroupVersionKind().Kind)
+		if i == 0 || index != lastIndex {
+			groups = append(groups, []*resource.Info{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], info)
+		lastIndex = index
+	}
+	return groups
+}
+
+// applyResult keeps the output and the error from applying a
+// single object.
+type applyResult struct {
+	printed []printedObject
+	err     error
+}
+
+// printedObject is an object that was passed to a printer by the
+// ApplyOptions, along with the operation that was performed on it.
+type printedObject struct {
+	operation string
+	obj       runtime.Object
+}
+
+// toPrinter is used as the ToPrinter function of the ApplyOptions
+// when applying a single object. Rather than printing, it keeps
+// everything in the applyResult until it is flushed.
+func (r *applyResult) toPrinter(operation string) (printers.ResourcePrinter, error) {
+	return printers.ResourcePrinterFunc(func(obj runtime.Object, _ io.Writer) error {
+		r.printed = append(r.printed, printedObject{
+			operation: operation,
+			obj:       obj,
+		})
+		return nil
+	}), nil
+}
+
+// flush passes all the objects in the applyResult on to the
+// printers from the provided toPrinter function.
+func (r *applyResult) flush(toPrinter func(string) (printers.ResourcePrinter, error), w io.Writer) error {
+	for _, p := range r.printed {
+		printer, err := toPrinter(p.operation)
+		if err != nil {
+			return err
+		}
+		if err := printer.PrintObj(p.obj, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ClearTimeout is not supported by the ApplyTask.
 func (a *ApplyTask) ClearTimeout() {}
@@ -0,0 +1,166 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+)
+
+func newInfo(kind, name string) *resource.Info {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetName(name)
+	return &resource.Info{Name: name, Object: u}
+}
+
+func TestApplyTask_Cancelled(t *testing.T) {
+	infos := []*resource.Info{
+		newInfo("Namespace", "ns"),
+		newInfo("ConfigMap", "foo"),
+		newInfo("ConfigMap", "bar"),
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		eventChannel := make(chan event.Event)
+		taskChannel := make(chan taskrunner.TaskResult)
+		applyTask := &ApplyTask{
+			// The ApplyOptions are never used, since the context
+			// is cancelled before the first object is applied.
+			ApplyOptions: &apply.ApplyOptions{},
+			Objects:      infos,
+			EventChannel: eventChannel,
+			Context:      ctx,
+			Concurrency:  concurrency,
+		}
+		applyTask.Start(taskChannel)
+
+		for _, info := range infos {
+			e := <-eventChannel
+			if want, got := event.ApplyEventResourceCancelled, e.ApplyEvent.Type; want != got {
+				t.Errorf("expected event type %s, but got %s", want, got)
+			}
+			if e.ApplyEvent.Object != info.Object {
+				t.Errorf("expected cancelled event for %s", info.Name)
+			}
+		}
+		result := <-taskChannel
+		if result.Err != nil {
+			t.Errorf("expected no error, but got %v", result.Err)
+		}
+	}
+}
+
+func TestGroupByOrder(t *testing.T) {
+	ns := newInfo("Namespace", "ns")
+	crd := newInfo("CustomResourceDefinition", "crd")
+	cm1 := newInfo("ConfigMap", "cm1")
+	cm2 := newInfo("ConfigMap", "cm2")
+	custom1 := newInfo("Custom", "custom")
+	custom2 := newInfo("OtherCustom", "custom")
+
+	groups := groupByOrder([]*resource.Info{ns, crd, cm1, cm2, custom1, custom2})
+
+	assert.Equal(t, [][]*resource.Info{
+		{ns},
+		{crd},
+		{cm1, cm2},
+		{custom1, custom2},
+	}, groups)
+	assert.Empty(t, groupByOrder([]*resource.Info{}))
+}
+
+func TestApplyResult_Flush(t *testing.T) {
+	result := &applyResult{}
+	for _, name := range []string{"foo", "bar"} {
+		p, err := result.toPrinter("created")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, p.PrintObj(newInfo("ConfigMap", name).Object, nil))
+	}
+
+	var printed []string
+	err := result.flush(func(operation string) (printers.ResourcePrinter, error) {
+		return printers.ResourcePrinterFunc(func(obj runtime.Object, _ io.Writer) error {
+			printed = append(printed, operation+" "+obj.(*unstructured.Unstructured).GetName())
+			return nil
+		}), nil
+	}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"created foo", "created bar"}, printed)
+}
+
+func TestApplyTask_ReportErrors(t *testing.T) {
+	rejected := fmt.Errorf("admission webhook denied the request")
+	newRejectedInfo := func(name string) *resource.Info {
+		info := newInfo("ConfigMap", name)
+		info.Namespace = "default"
+		info.Client = &fake.RESTClient{Err: rejected}
+		info.Mapping = &meta.RESTMapping{
+			Resource: schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+			Scope:    meta.RESTScopeNamespace,
+		}
+		return info
+	}
+
+	for _, concurrency := range []int{1, 4} {
+		infos := []*resource.Info{
+			newRejectedInfo("foo"),
+			newRejectedInfo("bar"),
+		}
+		eventChannel := make(chan event.Event)
+		taskChannel := make(chan taskrunner.TaskResult)
+		applyTask := &ApplyTask{
+			ApplyOptions: &apply.ApplyOptions{
+				Recorder:          genericclioptions.NoopRecorder{},
+				VisitedUids:       sets.NewString(),
+				VisitedNamespaces: sets.NewString(),
+			},
+			Objects:      infos,
+			EventChannel: eventChannel,
+			Concurrency:  concurrency,
+			ReportErrors: true,
+		}
+		applyTask.Start(taskChannel)
+
+		for _, info := range infos {
+			e := <-eventChannel
+			if want, got := event.ApplyEventResourceFailed, e.ApplyEvent.Type; want != got {
+				t.Errorf("expected event type %s, but got %s", want, got)
+			}
+			if e.ApplyEvent.Object != info.Object {
+				t.Errorf("expected failed event for %s", info.Name)
+			}
+			if e.ApplyEvent.Error == nil || !strings.Contains(e.ApplyEvent.Error.Error(), rejected.Error()) {
+				t.Errorf("expected error %q, but got %v", rejected, e.ApplyEvent.Error)
+			}
+		}
+		result := <-taskChannel
+		if result.Err != nil {
+			t.Errorf("expected no error, but got %v", result.Err)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/ordering"
+	"sigs.k8s.io/cli-utils/pkg/retry"
+)
+
+// NewTaskQueueBuilder returns a new TaskQueueBuilder. Any tasks
+// added by the builder that need to send events will use the
+// provided eventChannel.
+func NewTaskQueueBuilder(eventChannel chan event.Event) *TaskQueueBuilder {
+	return &TaskQueueBuilder{
+		EventChannel: eventChannel,
+	}
+}
+
+// TaskQueueBuilder is used to construct a queue of tasks that can be
+// executed by one of the task runners in the taskrunner package. It
+// provides functions for adding the tasks used by the Applier, but
+// custom implementations of the Task interface can also be added
+// anywhere in the queue with the AppendTask function.
+type TaskQueueBuilder struct {
+	// EventChannel is the channel used by the tasks created by the
+	// builder when they need to send events.
+	EventChannel chan event.Event
+
+	// Context is passed to the tasks created by the builder that
+	// can be interrupted, so they stop when it is cancelled.
+	Context context.Context
+
+	// ApplyConcurrency is the maximum number of objects that
+	// the apply tasks will apply at the same time.
+	ApplyConcurrency int
+
+	// RetryPolicy is used by the apply tasks to decide which
+	// errors should be retried.
+	RetryPolicy *retry.Policy
+
+	// ReportApplyErrors makes the apply tasks report the objects that
+	// fail to apply with events, rather than failing.
+	ReportApplyErrors bool
+
+	// ApplyPrecondition is checked by the apply tasks for each
+	// object right before it is applied.
+	ApplyPrecondition func(*resource.Info) error
+
+	// tasks are the tasks added to the builder, in the order they
+	// were added, with the indexes of the tasks they depend on.
+	tasks []builderTask
+
+	// last are the indexes of the tasks that the tasks added next
+	// will depend on.
+	last []int
+
+	// branches are the apply tasks added by the last call to
+	// AppendApplyBranches, along with the objects they apply.
+	branches []applyBranch
+}
+
+// builderTask is a task added to the builder, with the indexes
+// of the tasks that must complete before it is started.
+type builderTask struct {
+	task         taskrunner.Task
+	dependencies []int
+}
+
+// applyBranch is an apply task added by AppendApplyBranches, with
+// the identifiers of the objects it applies.
+type applyBranch struct {
+	index       int
+	identifiers []object.ObjMetadata
+}
+
+// WithContext sets the context that will be passed to the tasks
+// added after this call. This allows the ApplyTask and PruneTask
+// to stop between objects when the context is cancelled.
+func (t *TaskQueueBuilder) WithContext(ctx context.Context) *TaskQueueBuilder {
+	t.Context = ctx
+	return t
+}
+
+// WithApplyConcurrency sets the maximum number of objects that
+// will be applied at the same time by the apply tasks added after
+// this call.
+func (t *TaskQueueBuilder) WithApplyConcurrency(concurrency int) *TaskQueueBuilder {
+	t.ApplyConcurrency = concurrency
+	return t
+}
+
+// WithRetryPolicy sets the policy that the apply tasks added after
+// this call will use for retrying failed objects.
+func (t *TaskQueueBuilder) WithRetryPolicy(policy *retry.Policy) *TaskQueueBuilder {
+	t.RetryPolicy = policy
+	return t
+}
+
+// WithReportApplyErrors sets whether the apply tasks added after this
+// call report the objects that fail to apply with an event and carry
+// on with the remaining objects, rather than failing.
+func (t *TaskQueueBuilder) WithReportApplyErrors(report bool) *TaskQueueBuilder {
+	t.ReportApplyErrors = report
+	return t
+}
+
+// WithApplyPrecondition sets the precondition that the apply tasks
+// added after this call check for each object before applying it.
+func (t *TaskQueueBuilder) WithApplyPrecondition(precondition func(*resource.Info) error) *TaskQueueBuilder {
+	t.ApplyPrecondition = precondition
+	return t
+}
+
+// AppendApplyTask adds a task that will apply the provided objects
+// by using the ApplyOptions.
+func (t *TaskQueueBuilder) AppendApplyTask(objects []*resource.Info,
+	applyOptions *apply.ApplyOptions) *TaskQueueBuilder {
+	return t.AppendTask(t.newApplyTask(objects, applyOptions))
+}
+
+// AppendApplyBranches adds the tasks that will apply the provided
+// objects, split into branches that can be applied at the same time
+// when the builder is used with BuildGraph. The cluster-scoped
+// objects and the grouping object are applied first, since the other
+// objects might need them. After that there is one branch for each
+// namespace, and finally the objects that are ordered last, like
+// the ValidatingWebhookConfigurations, are applied once all the
+// branches have completed. The objects are expected to already be
+// sorted, and keep their order within each branch. The tasks added
+// after this call are only started once all the objects have been
+// applied. Wait tasks for each branch can be added with
+// AppendBranchWaitTasks.
+func (t *TaskQueueBuilder) AppendApplyBranches(objects []*resource.Info,
+	applyOptions *apply.ApplyOptions) *TaskQueueBuilder {
+	var shared, last []*resource.Info
+	var namespaces []string
+	namespaced := make(map[string][]*resource.Info)
+	for _, info := range objects {
+		index := ordering.IndexByKind(info.Object.GetObjectKind().GroupVersionKind().Kind)
+		switch {
+		case index > 0:
+			last = append(last, info)
+		case !info.Namespaced() || prune.IsGroupingObject(info.Object):
+			shared = append(shared, info)
+		default:
+			if _, found := namespaced[info.Namespace]; !found {
+				namespaces = append(namespaces, info.Namespace)
+			}
+			namespaced[info.Namespace] = append(namespaced[info.Namespace], info)
+		}
+	}
+	sort.Strings(namespaces)
+
+	t.branches = nil
+	var applies []int
+	dependencies := t.last
+	if len(shared) > 0 || len(objects) == 0 {
+		index := t.addBranch(shared, applyOptions, dependencies)
+		applies = append(applies, index)
+		dependencies = []int{index}
+	}
+	for _, ns := range namespaces {
+		applies = append(applies, t.addBranch(namespaced[ns], applyOptions, dependencies))
+	}
+	if len(last) > 0 {
+		if len(applies) == 0 {
+			applies = dependencies
+		}
+		applies = []int{t.addBranch(last, applyOptions, applies)}
+	}
+	t.last = applies
+	return t
+}
+
+// addBranch adds an apply task for the provided objects that
+// depends on the given tasks, and returns its index.
+func (t *TaskQueueBuilder) addBranch(objects []*resource.Info,
+	applyOptions *apply.ApplyOptions, dependencies []int) int {
+	index := t.add(t.newApplyTask(objects, applyOptions), dependencies)
+	branch := applyBranch{index: index}
+	for _, info := range objects {
+		branch.identifiers = append(branch.identifiers, object.ObjMetadata{
+			GroupKind: info.Object.GetObjectKind().GroupVersionKind().GroupKind(),
+			Namespace: info.Namespace,
+			Name:      info.Name,
+		})
+	}
+	t.branches = append(t.branches, branch)
+	return index
+}
+
+// newApplyTask returns a task that will apply the provided objects
+// with the settings of the builder.
+func (t *TaskQueueBuilder) newApplyTask(objects []*resource.Info,
+	applyOptions *apply.ApplyOptions) *ApplyTask {
+	return &ApplyTask{
+		Objects:      objects,
+		ApplyOptions: applyOptions,
+		EventChannel: t.EventChannel,
+		Context:      t.Context,
+		Concurrency:  t.ApplyConcurrency,
+		RetryPolicy:  t.RetryPolicy,
+		ReportErrors: t.ReportApplyErrors,
+		Precondition: t.ApplyPrecondition,
+	}
+}
+
+// AppendWaitTask adds a task that will wait until all the resources
+// identified by the identifiers meets the provided condition, or
+// the timeout is reached.
+func (t *TaskQueueBuilder) AppendWaitTask(identifiers []object.ObjMetadata,
+	condition taskrunner.Condition, timeout time.Duration) *TaskQueueBuilder {
+	return t.AppendTask(taskrunner.NewWaitTask(identifiers, condition, timeout))
+}
+
+// AppendBranchWaitTasks adds a wait task for each of the branches
+// added by the last call to AppendApplyBranches. Each wait task only
+// depends on the apply task of its own branch, so it is started as
+// soon as those objects have been applied, while the other branches
+// are still being applied. The tasks added after this call are only
+// started once all the wait tasks have completed.
+func (t *TaskQueueBuilder) AppendBranchWaitTasks(condition taskrunner.Condition,
+	timeout time.Duration) *TaskQueueBuilder {
+	for _, branch := range t.branches {
+		wait := taskrunner.NewWaitTask(branch.identifiers, condition, timeout)
+		t.last = append(t.last, t.add(wait, []int{branch.index}))
+	}
+	t.branches = nil
+	return t
+}
+
+// AppendPruneTask adds a task that will prune any objects that
+// were part of previous applies, but are not among the provided
+// objects.
+func (t *TaskQueueBuilder) AppendPruneTask(objects []*resource.Info,
+	pruneOptions *prune.PruneOptions) *TaskQueueBuilder {
+	return t.AppendTask(&PruneTask{
+		Objects:      objects,
+		PruneOptions: pruneOptions,
+		EventChannel: t.EventChannel,
+		Context:      t.Context,
+	})
+}
+
+// AppendDeleteTask adds a task that will delete the provided
+// objects, and then the grouping objects.
+func (t *TaskQueueBuilder) AppendDeleteTask(objects []*object.ObjMetadata,
+	groupingObjects []*resource.Info, pruneOptions *prune.PruneOptions) *TaskQueueBuilder {
+	return t.AppendTask(&DeleteTask{
+		Objects:         objects,
+		GroupingObjects: groupingObjects,
+		PruneOptions:    pruneOptions,
+		EventChannel:    t.EventChannel,
+		Context:         t.Context,
+	})
+}
+
+// AppendRemoveFinalizersTask adds a task that waits until the
+// provided objects are deleted or the timeout is reached, followed by
+// a task that removes the finalizers from the objects that are still
+// terminating.
+func (t *TaskQueueBuilder) AppendRemoveFinalizersTask(objects []*object.ObjMetadata,
+	timeout time.Duration, pruneOptions *prune.PruneOptions) *TaskQueueBuilder {
+	var identifiers []object.ObjMetadata
+	for _, obj := range objects {
+		identifiers = append(identifiers, *obj)
+	}
+	waitTask := taskrunner.NewWaitTask(identifiers, taskrunner.AllNotFound, timeout)
+	waitTask.ContinueOnTimeout = true
+	return t.AppendTask(waitTask).AppendTask(&RemoveFinalizersTask{
+		Objects:      objects,
+		PruneOptions: pruneOptions,
+		EventChannel: t.EventChannel,
+		Context:      t.Context,
+	})
+}
+
+// AppendSendEventTask adds a task that will send the provided
+// event on the EventChannel.
+func (t *TaskQueueBuilder) AppendSendEventTask(e event.Event) *TaskQueueBuilder {
+	return t.AppendTask(&SendEventTask{
+		Event:        e,
+		EventChannel: t.EventChannel,
+	})
+}
+
+// AppendTask adds the provided task to the end of the queue.
+func (t *TaskQueueBuilder) AppendTask(tsk taskrunner.Task) *TaskQueueBuilder {
+	return t.AppendParallelTasks(tsk)
+}
+
+// AppendParallelTasks adds the provided tasks to the end of the
+// queue. When the builder is used with BuildGraph, the tasks don't
+// depend on each other so they will run at the same time.
+func (t *TaskQueueBuilder) AppendParallelTasks(tasks ...taskrunner.Task) *TaskQueueBuilder {
+	var added []int
+	for _, tsk := range tasks {
+		added = append(added, t.add(tsk, t.last))
+	}
+	t.last = added
+	return t
+}
+
+// add adds the task with the given dependencies and returns
+// its index.
+func (t *TaskQueueBuilder) add(tsk taskrunner.Task, dependencies []int) int {
+	t.tasks = append(t.tasks, builderTask{
+		task:         tsk,
+		dependencies: append([]int(nil), dependencies...),
+	})
+	return len(t.tasks) - 1
+}
+
+// Tasks returns the tasks that have been added to the
+// builder so far, in the order they will be executed.
+func (t *TaskQueueBuilder) Tasks() []taskrunner.Task {
+	var tasks []taskrunner.Task
+	for _, bt := range t.tasks {
+		tasks = append(tasks, bt.task)
+	}
+	return tasks
+}
+
+// Build returns the queue (channel) of tasks that can be passed
+// to one of the task runners. The tasks in the queue are executed
+// one at a time, in the order they were added, including the ones
+// that would run at the same time with BuildGraph.
+func (t *TaskQueueBuilder) Build() chan taskrunner.Task {
+	tasks := t.Tasks()
+	taskQueue := make(chan taskrunner.Task, len(tasks))
+	for _, tsk := range tasks {
+		taskQueue <- tsk
+	}
+	return taskQueue
+}
+
+// BuildGraph returns a graph of the tasks that can be passed to
+// the RunGraph function of the task runners. Every task depends on
+// the tasks added before it, except for the tasks added together
+// with AppendParallelTasks and the branches added with
+// AppendApplyBranches and AppendBranchWaitTasks, which run at the
+// same time.
+func (t *TaskQueueBuilder) BuildGraph() (*taskrunner.TaskGraph, error) {
+	g := taskrunner.NewTaskGraph()
+	for _, bt := range t.tasks {
+		if _, err := g.AddTask(bt.task, bt.dependencies...); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
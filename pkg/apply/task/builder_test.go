@@ -0,0 +1,218 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/apply"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+var depID = object.ObjMetadata{
+	GroupKind: schema.GroupKind{
+		Group: "apps",
+		Kind:  "Deployment",
+	},
+	Namespace: "default",
+	Name:      "dep",
+}
+
+type customTask struct{}
+
+func (c *customTask) Start(taskChannel chan taskrunner.TaskResult) {
+	go func() {
+		taskChannel <- taskrunner.TaskResult{}
+	}()
+}
+
+func (c *customTask) ClearTimeout() {}
+
+func TestTaskQueueBuilder(t *testing.T) {
+	eventChannel := make(chan event.Event)
+	infos := []*resource.Info{}
+	applyOptions := &apply.ApplyOptions{}
+	pruneOptions := prune.NewPruneOptions()
+	custom := &customTask{}
+
+	taskQueue := NewTaskQueueBuilder(eventChannel).
+		AppendApplyTask(infos, applyOptions).
+		AppendTask(custom).
+		AppendWaitTask([]object.ObjMetadata{depID}, taskrunner.AllCurrent, time.Minute).
+		AppendPruneTask(infos, pruneOptions).
+		AppendSendEventTask(event.Event{Type: event.PruneType}).
+		Build()
+
+	if want, got := 5, len(taskQueue); want != got {
+		t.Fatalf("expected %d tasks, but got %d", want, got)
+	}
+
+	if at, ok := (<-taskQueue).(*ApplyTask); !ok || at.ApplyOptions != applyOptions {
+		t.Errorf("expected first task to be the ApplyTask")
+	}
+	if ct := <-taskQueue; ct != custom {
+		t.Errorf("expected second task to be the custom task")
+	}
+	wt, ok := (<-taskQueue).(*taskrunner.WaitTask)
+	if !ok {
+		t.Fatalf("expected third task to be a WaitTask")
+	}
+	if want, got := taskrunner.AllCurrent, wt.Condition; want != got {
+		t.Errorf("expected condition %s, but got %s", want, got)
+	}
+	pt, ok := (<-taskQueue).(*PruneTask)
+	if !ok {
+		t.Fatalf("expected fourth task to be a PruneTask")
+	}
+	if pt.EventChannel != eventChannel || pt.PruneOptions != pruneOptions {
+		t.Errorf("expected PruneTask to use the provided channel and options")
+	}
+	st, ok := (<-taskQueue).(*SendEventTask)
+	if !ok {
+		t.Fatalf("expected fifth task to be a SendEventTask")
+	}
+	if want, got := event.PruneType, st.Event.Type; want != got {
+		t.Errorf("expected event type %s, but got %s", want, got)
+	}
+}
+
+func TestTaskQueueBuilderDeleteTask(t *testing.T) {
+	eventChannel := make(chan event.Event)
+	pruneOptions := prune.NewPruneOptions()
+	groupingObjects := []*resource.Info{{Name: "inventory"}}
+
+	taskQueue := NewTaskQueueBuilder(eventChannel).
+		AppendDeleteTask([]*object.ObjMetadata{&depID}, groupingObjects, pruneOptions).
+		Build()
+
+	dt, ok := (<-taskQueue).(*DeleteTask)
+	if !ok {
+		t.Fatalf("expected the task to be a DeleteTask")
+	}
+	if dt.EventChannel != eventChannel || dt.PruneOptions != pruneOptions {
+		t.Errorf("expected DeleteTask to use the provided channel and options")
+	}
+	if len(dt.Objects) != 1 || *dt.Objects[0] != depID || len(dt.GroupingObjects) != 1 {
+		t.Errorf("expected DeleteTask to delete the provided objects")
+	}
+}
+
+func TestTaskQueueBuilderRemoveFinalizersTask(t *testing.T) {
+	eventChannel := make(chan event.Event)
+	pruneOptions := prune.NewPruneOptions()
+
+	taskQueue := NewTaskQueueBuilder(eventChannel).
+		AppendRemoveFinalizersTask([]*object.ObjMetadata{&depID}, time.Minute, pruneOptions).
+		Build()
+
+	wt, ok := (<-taskQueue).(*taskrunner.WaitTask)
+	if !ok {
+		t.Fatalf("expected the first task to be a WaitTask")
+	}
+	if !wt.ContinueOnTimeout || wt.Timeout != time.Minute || wt.Condition != taskrunner.AllNotFound {
+		t.Errorf("expected WaitTask to wait for the objects to be deleted and continue on timeout")
+	}
+	if len(wt.Identifiers) != 1 || wt.Identifiers[0] != depID {
+		t.Errorf("expected WaitTask to wait for the provided objects")
+	}
+	rt, ok := (<-taskQueue).(*RemoveFinalizersTask)
+	if !ok {
+		t.Fatalf("expected the second task to be a RemoveFinalizersTask")
+	}
+	if rt.EventChannel != eventChannel || rt.PruneOptions != pruneOptions {
+		t.Errorf("expected RemoveFinalizersTask to use the provided channel and options")
+	}
+	if len(rt.Objects) != 1 || *rt.Objects[0] != depID {
+		t.Errorf("expected RemoveFinalizersTask to use the provided objects")
+	}
+}
+
+func TestTaskQueueBuilderApplyBranches(t *testing.T) {
+	newNamespacedInfo := func(kind, namespace, name string) *resource.Info {
+		info := newInfo(kind, name)
+		info.Namespace = namespace
+		return info
+	}
+	ns := newInfo("Namespace", "ns1")
+	inventory := newNamespacedInfo("ConfigMap", "ns1", "inventory")
+	inventory.Object.(*unstructured.Unstructured).SetLabels(map[string]string{
+		prune.GroupingLabel: "test",
+	})
+	dep1 := newNamespacedInfo("Deployment", "ns1", "dep")
+	cm1 := newNamespacedInfo("ConfigMap", "ns1", "cm")
+	dep2 := newNamespacedInfo("Deployment", "ns2", "dep")
+	webhook := newInfo("ValidatingWebhookConfiguration", "webhook")
+	applyOptions := &apply.ApplyOptions{}
+	custom := &customTask{}
+
+	b := NewTaskQueueBuilder(make(chan event.Event)).
+		AppendApplyBranches([]*resource.Info{ns, inventory, dep1, dep2, cm1, webhook}, applyOptions).
+		AppendSendEventTask(event.Event{Type: event.ApplyType}).
+		AppendBranchWaitTasks(taskrunner.AllCurrent, time.Minute).
+		AppendTask(custom)
+
+	expected := []struct {
+		objects      []*resource.Info
+		wait         bool
+		dependencies []int
+	}{
+		{objects: []*resource.Info{ns, inventory}},
+		{objects: []*resource.Info{dep1, cm1}, dependencies: []int{0}},
+		{objects: []*resource.Info{dep2}, dependencies: []int{0}},
+		{objects: []*resource.Info{webhook}, dependencies: []int{0, 1, 2}},
+		{dependencies: []int{3}},
+		{objects: []*resource.Info{ns, inventory}, wait: true, dependencies: []int{0}},
+		{objects: []*resource.Info{dep1, cm1}, wait: true, dependencies: []int{1}},
+		{objects: []*resource.Info{dep2}, wait: true, dependencies: []int{2}},
+		{objects: []*resource.Info{webhook}, wait: true, dependencies: []int{3}},
+		{dependencies: []int{4, 5, 6, 7, 8}},
+	}
+	if want, got := len(expected), len(b.tasks); want != got {
+		t.Fatalf("expected %d tasks, but got %d", want, got)
+	}
+	for i, e := range expected {
+		bt := b.tasks[i]
+		switch tsk := bt.task.(type) {
+		case *ApplyTask:
+			if tsk.ApplyOptions != applyOptions || !reflect.DeepEqual(e.objects, tsk.Objects) {
+				t.Errorf("expected task %d to apply %d objects with the provided options", i, len(e.objects))
+			}
+		case *taskrunner.WaitTask:
+			if !e.wait || len(tsk.Identifiers) != len(e.objects) {
+				t.Errorf("expected task %d to wait for %d objects", i, len(e.objects))
+			}
+			for j, id := range tsk.Identifiers {
+				if j < len(e.objects) && (id.Name != e.objects[j].Name || id.Namespace != e.objects[j].Namespace) {
+					t.Errorf("expected task %d to wait for %s, but got %s", i, e.objects[j].Name, id.Name)
+				}
+			}
+		default:
+			if e.objects != nil {
+				t.Errorf("expected task %d to be an apply or wait task, but got %T", i, tsk)
+			}
+		}
+		if !reflect.DeepEqual(e.dependencies, bt.dependencies) {
+			t.Errorf("expected task %d to depend on %v, but got %v", i, e.dependencies, bt.dependencies)
+		}
+	}
+	if b.tasks[9].task != custom {
+		t.Errorf("expected the last task to be the custom task")
+	}
+
+	g, err := b.BuildGraph()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want, got := len(expected), g.Len(); want != got {
+		t.Errorf("expected %d tasks in the graph, but got %d", want, got)
+	}
+}
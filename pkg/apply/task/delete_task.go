@@ -0,0 +1,50 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// DeleteTask deletes the Objects from the cluster by using the
+// PruneOptions, and then the GroupingObjects. It is used to delete
+// the resources in phases, so it can be waited for each phase to be
+// deleted before the next one is started.
+type DeleteTask struct {
+	PruneOptions    *prune.PruneOptions
+	EventChannel    chan event.Event
+	Objects         []*object.ObjMetadata
+	GroupingObjects []*resource.Info
+	// Context is passed on to the PruneOptions, so deleting
+	// stops between objects if it is cancelled.
+	Context context.Context
+}
+
+// Start creates a new goroutine that deletes the objects. It will
+// push a TaskResult on the taskChannel to signal to the taskrunner
+// that the task has completed (or failed).
+func (d *DeleteTask) Start(taskChannel chan taskrunner.TaskResult) {
+	go func() {
+		ctx := d.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		completed, err := d.PruneOptions.DeleteObjects(ctx, d.Objects, d.EventChannel)
+		if err == nil && completed {
+			err = d.PruneOptions.DeleteGroupingObjects(ctx, d.GroupingObjects, d.EventChannel)
+		}
+		taskChannel <- taskrunner.TaskResult{
+			Err: err,
+		}
+	}()
+}
+
+// ClearTimeout is not supported by the DeleteTask.
+func (d *DeleteTask) ClearTimeout() {}
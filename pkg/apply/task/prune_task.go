@@ -4,6 +4,8 @@
 package task
 
 import (
+	"context"
+
 	"k8s.io/cli-runtime/pkg/resource"
 	"sigs.k8s.io/cli-utils/pkg/apply/event"
 	"sigs.k8s.io/cli-utils/pkg/apply/prune"
@@ -17,6 +19,9 @@ type PruneTask struct {
 	PruneOptions *prune.PruneOptions
 	EventChannel chan event.Event
 	Objects      []*resource.Info
+	// Context is passed on to the PruneOptions, so pruning
+	// stops between objects if it is cancelled.
+	Context context.Context
 }
 
 // Start creates a new goroutine that will invoke
@@ -25,7 +30,11 @@ type PruneTask struct {
 // to signal to the taskrunner that the task has completed (or failed).
 func (p *PruneTask) Start(taskChannel chan taskrunner.TaskResult) {
 	go func() {
-		err := p.PruneOptions.Prune(p.Objects, p.EventChannel)
+		ctx := p.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		err := p.PruneOptions.Prune(ctx, p.Objects, p.EventChannel)
 		taskChannel <- taskrunner.TaskResult{
 			Err: err,
 		}
@@ -0,0 +1,45 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
+	"sigs.k8s.io/cli-utils/pkg/apply/taskrunner"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// RemoveFinalizersTask removes the finalizers from the Objects that
+// are still terminating, by using the PruneOptions. It is used to
+// force the deletion of resources whose finalizers will never be
+// removed, for example since their controller has been deleted.
+type RemoveFinalizersTask struct {
+	PruneOptions *prune.PruneOptions
+	EventChannel chan event.Event
+	Objects      []*object.ObjMetadata
+	// Context is passed on to the PruneOptions, so no more
+	// finalizers are removed if it is cancelled.
+	Context context.Context
+}
+
+// Start creates a new goroutine that removes the finalizers. It will
+// push a TaskResult on the taskChannel to signal to the taskrunner
+// that the task has completed (or failed).
+func (r *RemoveFinalizersTask) Start(taskChannel chan taskrunner.TaskResult) {
+	go func() {
+		ctx := r.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		err := r.PruneOptions.RemoveFinalizers(ctx, r.Objects, r.EventChannel)
+		taskChannel <- taskrunner.TaskResult{
+			Err: err,
+		}
+	}()
+}
+
+// ClearTimeout is not supported by the RemoveFinalizersTask.
+func (r *RemoveFinalizersTask) ClearTimeout() {}
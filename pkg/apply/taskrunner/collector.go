@@ -48,7 +48,7 @@ func (a *resourceStatusCollector) resourceStatus(identifier object.ObjMetadata,
 
 // conditionMet tests whether the provided Condition holds true for
 // all resources given by the list of Identifiers.
-func (a *resourceStatusCollector) conditionMet(identifiers []object.ObjMetadata, c condition) bool {
+func (a *resourceStatusCollector) conditionMet(identifiers []object.ObjMetadata, c Condition) bool {
 	switch c {
 	case AllCurrent:
 		return a.allMatchStatus(identifiers, status.CurrentStatus)
@@ -0,0 +1,86 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"fmt"
+)
+
+// NewTaskGraph returns a new empty TaskGraph.
+func NewTaskGraph() *TaskGraph {
+	return &TaskGraph{}
+}
+
+// TaskGraph is a directed acyclic graph of tasks. Each task can
+// depend on any number of other tasks in the graph, and will only be
+// started once all of its dependencies have completed. Tasks that
+// don't depend on each other can be executed at the same time.
+// Since dependencies must be added to the graph before the tasks
+// that depend on them, it is not possible to create a cycle.
+//
+// Note that the tasks are responsible for making sure they can
+// safely run at the same time as any other task in the graph that
+// isn't a dependency. For example, the ApplyTask applies every
+// object with a copy of its ApplyOptions, so two ApplyTasks that
+// share the same ApplyOptions can run at the same time.
+type TaskGraph struct {
+	nodes []*taskNode
+}
+
+// taskNode is a single task in the TaskGraph, with references to
+// the tasks it depends on, given as indexes into the nodes slice.
+type taskNode struct {
+	task         Task
+	dependencies []int
+}
+
+// AddTask adds the provided task to the graph and returns its
+// index. The task will not be started until all the tasks at the
+// provided dependency indexes have completed. All dependencies must
+// already have been added to the graph. The tasks are tracked by
+// their index rather than compared to each other, so any value can
+// be used as a task, but each task should only be added once.
+func (g *TaskGraph) AddTask(task Task, dependencies ...int) (int, error) {
+	if task == nil {
+		return -1, fmt.Errorf("task can not be nil")
+	}
+	for _, dep := range dependencies {
+		if dep < 0 || dep >= len(g.nodes) {
+			return -1, fmt.Errorf("dependency %d must be added to the graph before the tasks that depend on it", dep)
+		}
+	}
+	g.nodes = append(g.nodes, &taskNode{
+		task:         task,
+		dependencies: append([]int(nil), dependencies...),
+	})
+	return len(g.nodes) - 1, nil
+}
+
+// Len returns the number of tasks in the graph.
+func (g *TaskGraph) Len() int {
+	return len(g.nodes)
+}
+
+// newLinearTaskGraph creates a TaskGraph from the tasks in the
+// taskQueue, where every task depends on the one before it. So the
+// tasks will be executed one at a time, in the order they were
+// found in the taskQueue.
+func newLinearTaskGraph(taskQueue chan Task) *TaskGraph {
+	g := NewTaskGraph()
+	for {
+		select {
+		case t := <-taskQueue:
+			var deps []int
+			if len(g.nodes) > 0 {
+				deps = []int{len(g.nodes) - 1}
+			}
+			g.nodes = append(g.nodes, &taskNode{
+				task:         t,
+				dependencies: deps,
+			})
+		default:
+			return g
+		}
+	}
+}
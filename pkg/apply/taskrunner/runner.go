@@ -14,11 +14,12 @@ import (
 	pollevent "sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 )
 
 // NewTaskStatusRunner returns a new TaskStatusRunner.
-func NewTaskStatusRunner(identifiers []object.ObjMetadata, statusPoller poller.Poller) *taskStatusRunner {
-	return &taskStatusRunner{
+func NewTaskStatusRunner(identifiers []object.ObjMetadata, statusPoller poller.Poller) *TaskStatusRunner {
+	return &TaskStatusRunner{
 		identifiers:  identifiers,
 		statusPoller: statusPoller,
 
@@ -26,10 +27,10 @@ func NewTaskStatusRunner(identifiers []object.ObjMetadata, statusPoller poller.P
 	}
 }
 
-// taskStatusRunner is a taskRunner that executes a set of
+// TaskStatusRunner is a taskRunner that executes a set of
 // tasks while at the same time uses the statusPoller to
 // keep track of the status of the resources.
-type taskStatusRunner struct {
+type TaskStatusRunner struct {
 	identifiers  []object.ObjMetadata
 	statusPoller poller.Poller
 
@@ -41,18 +42,30 @@ type taskStatusRunner struct {
 type PollingOptions struct {
 	PollInterval time.Duration
 	UseCache     bool
+	RetryPolicy  *retry.Policy
+	ErrorBudget  int
 }
 
-// Run starts the execution of the taskqueue. It will start the
-// statusPoller and then pass the statusChannel to the baseRunner
-// that does most of the work.
-func (tsr *taskStatusRunner) Run(ctx context.Context, taskQueue chan Task,
+// Run starts the execution of the taskqueue. The tasks in the
+// queue will be executed one at a time, in the order they were
+// added to the queue. See RunGraph for details.
+func (tsr *TaskStatusRunner) Run(ctx context.Context, taskQueue chan Task,
+	eventChannel chan event.Event, pollingOptions PollingOptions) error {
+	return tsr.RunGraph(ctx, newLinearTaskGraph(taskQueue), eventChannel, pollingOptions)
+}
+
+// RunGraph starts the execution of the tasks in the taskGraph. It
+// will start the statusPoller and then pass the statusChannel to the
+// baseRunner that does most of the work.
+func (tsr *TaskStatusRunner) RunGraph(ctx context.Context, taskGraph *TaskGraph,
 	eventChannel chan event.Event, pollingOptions PollingOptions) error {
 	statusCtx, cancelFunc := context.WithCancel(context.Background())
 	statusChannel := tsr.statusPoller.Poll(statusCtx, tsr.identifiers, polling.Options{
 		PollUntilCancelled: true,
 		PollInterval:       pollingOptions.PollInterval,
 		UseCache:           pollingOptions.UseCache,
+		RetryPolicy:        pollingOptions.RetryPolicy,
+		ErrorBudget:        pollingOptions.ErrorBudget,
 		// Not actually in use since we use a separate collector to keep
 		// track of the status for each resource.
 		//TODO(mortent): Remove the aggregator from the polling engine
@@ -60,7 +73,7 @@ func (tsr *taskStatusRunner) Run(ctx context.Context, taskQueue chan Task,
 		DesiredStatus: status.CurrentStatus,
 	})
 
-	err := tsr.baseRunner.run(ctx, taskQueue, statusChannel, eventChannel)
+	err := tsr.baseRunner.run(ctx, taskGraph, statusChannel, eventChannel)
 	// cancel the statusPoller by cancelling the context.
 	cancelFunc()
 	// drain the statusChannel to make sure the lack of a consumer
@@ -70,29 +83,38 @@ func (tsr *taskStatusRunner) Run(ctx context.Context, taskQueue chan Task,
 	return err
 }
 
-// NewTaskRunner returns a new taskRunner. It can process taskqueues
+// NewTaskRunner returns a new TaskRunner. It can process taskqueues
 // that does not contain any wait tasks.
-func NewTaskRunner() *taskRunner {
+func NewTaskRunner() *TaskRunner {
 	collector := newResourceStatusCollector([]object.ObjMetadata{})
-	return &taskRunner{
+	return &TaskRunner{
 		baseRunner: newBaseRunner(collector),
 	}
 }
 
-// taskRunner is a simplified taskRunner that does not support
+// TaskRunner is a simplified taskRunner that does not support
 // wait tasks and does not provide any status updates for the
 // resources. This is useful in situations where we are not interested
 // in status, for example during dry-run.
-type taskRunner struct {
+type TaskRunner struct {
 	baseRunner *baseRunner
 }
 
-// Run starts the execution of the task queue. It delegates the
-// work to the baseRunner, but gives it as nil channel as the statusChannel.
-func (tr *taskRunner) Run(ctx context.Context, taskQueue chan Task,
+// Run starts the execution of the task queue. The tasks in the
+// queue will be executed one at a time, in the order they were
+// added to the queue.
+func (tr *TaskRunner) Run(ctx context.Context, taskQueue chan Task,
+	eventChannel chan event.Event) error {
+	return tr.RunGraph(ctx, newLinearTaskGraph(taskQueue), eventChannel)
+}
+
+// RunGraph starts the execution of the tasks in the taskGraph. It
+// delegates the work to the baseRunner, but gives it as nil channel
+// as the statusChannel.
+func (tr *TaskRunner) RunGraph(ctx context.Context, taskGraph *TaskGraph,
 	eventChannel chan event.Event) error {
 	var nilStatusChannel chan pollevent.Event
-	return tr.baseRunner.run(ctx, taskQueue, nilStatusChannel, eventChannel)
+	return tr.baseRunner.run(ctx, taskGraph, nilStatusChannel, eventChannel)
 }
 
 // newBaseRunner returns a new baseRunner using the given collector.
@@ -107,34 +129,42 @@ func newBaseRunner(collector *resourceStatusCollector) *baseRunner {
 // wait tasks, but it can also be used with a nil statusChannel for
 // cases where polling and waiting for status is not needed.
 // This is not meant to be used directly. It is used by the
-// taskRunner and the taskStatusRunner.
+// TaskRunner and the TaskStatusRunner.
 type baseRunner struct {
 	collector *resourceStatusCollector
 }
 
 // run is the main function that implements the processing of
-// tasks in the taskqueue. It sets up a loop where a single goroutine
-// will process events from three different channels.
-func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
+// tasks in the taskGraph. It sets up a loop where a single goroutine
+// will process events from three different channels. Every task
+// whose dependencies have all completed is started right away, so
+// independent tasks will run at the same time.
+func (b *baseRunner) run(ctx context.Context, taskGraph *TaskGraph,
 	statusChannel <-chan pollevent.Event, eventChannel chan event.Event) error {
-	// taskChannel is used by tasks running in a separate goroutine
-	// to signal back to the main loop that the task is either finished
-	// or it has failed.
-	taskChannel := make(chan TaskResult)
-
-	// Find and start the first task in the queue.
-	currentTask, done := b.nextTask(taskQueue, taskChannel)
-	if done {
+	// resultChannel is used to signal back to the main loop that
+	// one of the running tasks is either finished or it has failed.
+	resultChannel := make(chan graphTaskResult)
+
+	state := newGraphState(taskGraph)
+
+	// Start all tasks that doesn't have any dependencies.
+	b.startReadyTasks(state, resultChannel)
+	if len(state.running) == 0 {
 		return nil
 	}
 
 	// abort is used to signal that something has failed, and
-	// the task processing should end as soon as is possible. Only
-	// wait tasks can be interrupted, so for all other tasks we need
-	// to wait for the currently running one to finish before we can
-	// exit.
+	// the task processing should end as soon as is possible. No new
+	// tasks will be started after this has been set. Wait tasks
+	// can be interrupted, but for all other tasks we need to wait for
+	// the currently running ones to finish before we can exit. Tasks
+	// that are given the same context, like the apply and prune tasks,
+	// will stop on their own when the context is cancelled.
 	abort := false
 	var abortReason error
+	// taskErr keeps the first error returned from any of the tasks.
+	// It takes precedence over the abortReason.
+	var taskErr error
 
 	// We do this so we can set the doneCh to a nil channel after
 	// it has been closed. This is needed to avoid a busy loop.
@@ -159,14 +189,15 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 
 			// An error event on the statusChannel means the StatusPoller
 			// has encountered a problem so it can't continue. This means
-			// the statusChannel will be closed soon.
+			// the statusChannel will be closed soon. Warning events are
+			// just passed on, since the StatusPoller will keep going.
 			if statusEvent.EventType == pollevent.ErrorEvent {
 				abort = true
 				abortReason = fmt.Errorf("polling for status failed: %v",
 					statusEvent.Error)
-				// If the current task is a wait task, we just set it
-				// to complete so we can exit the loop as soon as possible.
-				completeIfWaitTask(currentTask, taskChannel)
+				// If any of the running tasks are wait tasks, we just set
+				// them to complete so we can exit the loop as soon as possible.
+				state.completeWaitTasks()
 				continue
 			}
 
@@ -176,73 +207,91 @@ func (b *baseRunner) run(ctx context.Context, taskQueue chan Task,
 				StatusEvent: statusEvent,
 			}
 
+			if statusEvent.EventType != pollevent.ResourceUpdateEvent {
+				continue
+			}
 			// The collector needs to keep track of the latest status
 			// for all resources so we can check whether wait task conditions
 			// has been met.
 			b.collector.resourceStatus(statusEvent.Resource.Identifier,
 				statusEvent.Resource.Status)
-			// If the current task is a wait task, we check whether
-			// the condition has been met. If so, we complete the task.
-			if wt, ok := currentTask.(*WaitTask); ok {
-				if b.collector.conditionMet(wt.Identifiers, wt.Condition) {
-					completeIfWaitTask(currentTask, taskChannel)
+			// For every running wait task, we check whether the
+			// condition has been met. If so, we complete the task.
+			for index, tsk := range state.running {
+				if wt, ok := tsk.(*WaitTask); ok {
+					if b.collector.conditionMet(wt.Identifiers, wt.Condition) {
+						wt.Complete(state.taskChannels[index])
+					}
+				}
+			}
+		// A message on the resultChannel means that one of the running
+		// tasks has either completed or failed. If it has failed, we
+		// stop starting new tasks and return the error once all the
+		// running tasks have finished. The same happens if the abort
+		// flag is true, which means something else has gone wrong.
+		// If everything is ok, we start any tasks that were waiting
+		// for this one to finish.
+		case msg := <-resultChannel:
+			tsk := state.finish(msg.index)
+			tsk.ClearTimeout()
+			if msg.result.Err != nil {
+				if taskErr == nil {
+					taskErr = msg.result.Err
 				}
+				abort = true
+				state.completeWaitTasks()
+			}
+			// Don't start any new tasks if the context has been
+			// cancelled, even if we haven't seen it on the doneCh yet.
+			if !abort && ctx.Err() != nil {
+				doneCh = nil
+				abort = true
+				state.completeWaitTasks()
 			}
-		// A message on the taskChannel means that the current task
-		// has either completed or failed. If it has failed, we return
-		// the error. If the abort flag is true, which means something
-		// else has gone wrong and we are waiting for the current task to
-		// finish, we exit.
-		// If everything is ok, we fetch and start the next task.
-		case msg := <-taskChannel:
-			currentTask.ClearTimeout()
-			if msg.Err != nil {
-				return msg.Err
+			if !abort {
+				b.startReadyTasks(state, resultChannel)
 			}
-			if abort {
+			// If there are no more running tasks, we are done. Either
+			// because all tasks have completed or because we are
+			// aborting. So just return.
+			if len(state.running) == 0 {
+				if taskErr != nil {
+					return taskErr
+				}
 				return abortReason
 			}
-			currentTask, done = b.nextTask(taskQueue, taskChannel)
-			// If there are no more tasks, we are done. So just
-			// return.
-			if done {
-				return nil
-			}
 		// The doneCh will be closed if the passed in context is cancelled.
 		// If so, we just set the abort flag and wait for the currently running
-		// task to complete before we exit.
+		// tasks to complete before we exit.
 		case <-doneCh:
 			doneCh = nil // Set doneCh to nil so we don't enter a busy loop.
 			abort = true
-			completeIfWaitTask(currentTask, taskChannel)
+			state.completeWaitTasks()
 		}
 	}
 }
 
-// completeIfWaitTask checks if the current task is a wait task. If so,
-// we invoke the complete function to complete it.
-func completeIfWaitTask(currentTask Task, taskChannel chan TaskResult) {
-	if wt, ok := currentTask.(*WaitTask); ok {
-		wt.complete(taskChannel)
+// startReadyTasks starts all tasks in the graph that haven't been
+// started yet and where all the dependencies have completed.
+func (b *baseRunner) startReadyTasks(state *graphState, resultChannel chan graphTaskResult) {
+	for _, index := range state.readyTasks() {
+		taskChannel := make(chan TaskResult)
+		state.start(index, taskChannel)
+		// Each task gets its own taskChannel, so we forward the
+		// result together with the index of the task.
+		go func(index int) {
+			res := <-taskChannel
+			resultChannel <- graphTaskResult{
+				index:  index,
+				result: res,
+			}
+		}(index)
+		b.startTask(state.graph.nodes[index].task, taskChannel)
 	}
 }
 
-// nextTask fetches the latest task from the taskQueue and
-// starts it. If the taskQueue is empty, it the second
-// return value will be true.
-func (b *baseRunner) nextTask(taskQueue chan Task,
-	taskChannel chan TaskResult) (Task, bool) {
-	var tsk Task
-	select {
-	// If there is any tasks left in the queue, this
-	// case statement will be executed.
-	case t := <-taskQueue:
-		tsk = t
-	default:
-		// Only happens when the channel is empty.
-		return nil, true
-	}
-
+// startTask starts the given task.
+func (b *baseRunner) startTask(tsk Task, taskChannel chan TaskResult) {
 	switch st := tsk.(type) {
 	case *WaitTask:
 		// The wait tasks need to be handled specifically here. Before
@@ -257,7 +306,85 @@ func (b *baseRunner) nextTask(taskQueue chan Task,
 	default:
 		tsk.Start(taskChannel)
 	}
-	return tsk, false
+}
+
+// graphTaskResult is the result of a task in the graph,
+// identified by its index.
+type graphTaskResult struct {
+	index  int
+	result TaskResult
+}
+
+// newGraphState returns a new graphState for the given graph, where
+// none of the tasks have been started.
+func newGraphState(graph *TaskGraph) *graphState {
+	return &graphState{
+		graph:        graph,
+		started:      make([]bool, len(graph.nodes)),
+		completed:    make([]bool, len(graph.nodes)),
+		running:      make(map[int]Task),
+		taskChannels: make(map[int]chan TaskResult),
+	}
+}
+
+// graphState keeps track of the progress of execution of the
+// tasks in a TaskGraph. It is only accessed by the goroutine
+// running the main loop, so it doesn't need synchronization.
+type graphState struct {
+	graph        *TaskGraph
+	started      []bool
+	completed    []bool
+	running      map[int]Task
+	taskChannels map[int]chan TaskResult
+}
+
+// readyTasks returns the indexes of the tasks that have not been
+// started and where all dependencies have completed.
+func (g *graphState) readyTasks() []int {
+	var ready []int
+	for i, n := range g.graph.nodes {
+		if g.started[i] {
+			continue
+		}
+		depsCompleted := true
+		for _, dep := range n.dependencies {
+			if !g.completed[dep] {
+				depsCompleted = false
+				break
+			}
+		}
+		if depsCompleted {
+			ready = append(ready, i)
+		}
+	}
+	return ready
+}
+
+// start marks the task with the given index as running.
+func (g *graphState) start(index int, taskChannel chan TaskResult) {
+	g.started[index] = true
+	g.running[index] = g.graph.nodes[index].task
+	g.taskChannels[index] = taskChannel
+}
+
+// finish marks the task with the given index as completed and
+// returns it.
+func (g *graphState) finish(index int) Task {
+	tsk := g.running[index]
+	g.completed[index] = true
+	delete(g.running, index)
+	delete(g.taskChannels, index)
+	return tsk
+}
+
+// completeWaitTasks invokes the Complete function on all running
+// wait tasks.
+func (g *graphState) completeWaitTasks() {
+	for index, tsk := range g.running {
+		if wt, ok := tsk.(*WaitTask); ok {
+			wt.Complete(g.taskChannels[index])
+		}
+	}
 }
 
 // TaskResult is the type returned from tasks once they have completed
@@ -162,7 +162,7 @@ func TestBaseRunner(t *testing.T) {
 				}
 			}()
 
-			err := runner.run(context.Background(), taskQueue, statusChannel, eventChannel)
+			err := runner.run(context.Background(), newLinearTaskGraph(taskQueue), statusChannel, eventChannel)
 			close(statusChannel)
 			close(eventChannel)
 			wg.Wait()
@@ -277,6 +277,37 @@ func TestBaseRunnerCancellation(t *testing.T) {
 			expectedError:      testError,
 			expectedEventTypes: []event.Type{},
 		},
+		"warning from status poller while wait task is running": {
+			identifiers: []object.ObjMetadata{depID},
+			tasks: []Task{
+				NewWaitTask([]object.ObjMetadata{depID}, AllCurrent, 20*time.Second),
+				&busyTask{
+					resultEvent: event.Event{
+						Type: event.PruneType,
+					},
+					duration: 1 * time.Second,
+				},
+			},
+			statusEvents: []pollevent.Event{
+				{
+					EventType: pollevent.WarningEvent,
+					Error:     testError,
+				},
+				{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: depID,
+						Status:     status.CurrentStatus,
+					},
+				},
+			},
+			contextTimeout: 30 * time.Second,
+			expectedEventTypes: []event.Type{
+				event.StatusType,
+				event.StatusType,
+				event.PruneType,
+			},
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -319,7 +350,7 @@ func TestBaseRunnerCancellation(t *testing.T) {
 
 			ctx, cancel := context.WithTimeout(context.Background(), tc.contextTimeout)
 			defer cancel()
-			err := runner.run(ctx, taskQueue, statusChannel, eventChannel)
+			err := runner.run(ctx, newLinearTaskGraph(taskQueue), statusChannel, eventChannel)
 			close(statusChannel)
 			close(eventChannel)
 			wg.Wait()
@@ -346,6 +377,277 @@ func TestBaseRunnerCancellation(t *testing.T) {
 	}
 }
 
+func TestBaseRunnerGraph(t *testing.T) {
+	testError := fmt.Errorf("this is a test error")
+
+	testCases := map[string]struct {
+		identifiers        []object.ObjMetadata
+		graph              func(eventChannel chan event.Event) *TaskGraph
+		statusEventsDelay  time.Duration
+		statusEvents       []pollevent.Event
+		contextTimeout     time.Duration
+		expectedError      error
+		maxDuration        time.Duration
+		expectedEventTypes []event.Type
+	}{
+		"independent tasks run in parallel": {
+			identifiers: []object.ObjMetadata{},
+			graph: func(eventChannel chan event.Event) *TaskGraph {
+				first := newBusyTask(eventChannel, event.ApplyType, 1*time.Second, nil)
+				second := newBusyTask(eventChannel, event.DeleteType, 3*time.Second, nil)
+				third := newBusyTask(eventChannel, event.PruneType, 1*time.Second, nil)
+				g := NewTaskGraph()
+				f := mustAddTask(t, g, first)
+				s := mustAddTask(t, g, second)
+				mustAddTask(t, g, third, f, s)
+				return g
+			},
+			contextTimeout: 30 * time.Second,
+			maxDuration:    5 * time.Second,
+			expectedEventTypes: []event.Type{
+				event.ApplyType,
+				event.DeleteType,
+				event.PruneType,
+			},
+		},
+		"status events are multiplexed to all running wait tasks": {
+			identifiers: []object.ObjMetadata{depID, cmID},
+			graph: func(eventChannel chan event.Event) *TaskGraph {
+				depWait := NewWaitTask([]object.ObjMetadata{depID}, AllCurrent, 1*time.Minute)
+				cmWait := NewWaitTask([]object.ObjMetadata{cmID}, AllCurrent, 1*time.Minute)
+				g := NewTaskGraph()
+				d := mustAddTask(t, g, depWait)
+				c := mustAddTask(t, g, cmWait)
+				mustAddTask(t, g, newBusyTask(eventChannel, event.ApplyType, 1*time.Second, nil), d)
+				mustAddTask(t, g, newBusyTask(eventChannel, event.PruneType, 2*time.Second, nil), d, c)
+				return g
+			},
+			statusEventsDelay: 1 * time.Second,
+			statusEvents: []pollevent.Event{
+				{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: depID,
+						Status:     status.CurrentStatus,
+					},
+				},
+				{
+					EventType: pollevent.ResourceUpdateEvent,
+					Resource: &pollevent.ResourceStatus{
+						Identifier: cmID,
+						Status:     status.CurrentStatus,
+					},
+				},
+			},
+			contextTimeout: 30 * time.Second,
+			maxDuration:    10 * time.Second,
+			expectedEventTypes: []event.Type{
+				event.StatusType,
+				event.StatusType,
+				event.ApplyType,
+				event.PruneType,
+			},
+		},
+		"error in one task waits for running tasks and skips dependents": {
+			identifiers: []object.ObjMetadata{},
+			graph: func(eventChannel chan event.Event) *TaskGraph {
+				failing := newBusyTask(eventChannel, event.ApplyType, 1*time.Second, testError)
+				slow := newBusyTask(eventChannel, event.DeleteType, 3*time.Second, nil)
+				g := NewTaskGraph()
+				f := mustAddTask(t, g, failing)
+				mustAddTask(t, g, slow)
+				mustAddTask(t, g, newBusyTask(eventChannel, event.PruneType, 1*time.Second, nil), f)
+				return g
+			},
+			contextTimeout: 30 * time.Second,
+			expectedError:  testError,
+			maxDuration:    10 * time.Second,
+			expectedEventTypes: []event.Type{
+				event.ApplyType,
+				event.DeleteType,
+			},
+		},
+		"cancellation stops new tasks from starting": {
+			identifiers: []object.ObjMetadata{depID},
+			graph: func(eventChannel chan event.Event) *TaskGraph {
+				wait := NewWaitTask([]object.ObjMetadata{depID}, AllCurrent, 20*time.Second)
+				busy := newBusyTask(eventChannel, event.ApplyType, 3*time.Second, nil)
+				g := NewTaskGraph()
+				w := mustAddTask(t, g, wait)
+				b := mustAddTask(t, g, busy)
+				mustAddTask(t, g, newBusyTask(eventChannel, event.PruneType, 1*time.Second, nil), w, b)
+				return g
+			},
+			contextTimeout: 1 * time.Second,
+			maxDuration:    10 * time.Second,
+			expectedEventTypes: []event.Type{
+				event.ApplyType,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			runner := newBaseRunner(newResourceStatusCollector(tc.identifiers))
+			eventChannel := make(chan event.Event)
+			graph := tc.graph(eventChannel)
+
+			// Use a WaitGroup to make sure changes in the goroutines
+			// are visible to the main goroutine.
+			var wg sync.WaitGroup
+
+			statusChannel := make(chan pollevent.Event)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				<-time.NewTimer(tc.statusEventsDelay).C
+				for _, se := range tc.statusEvents {
+					statusChannel <- se
+				}
+			}()
+
+			var events []event.Event
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.contextTimeout)
+			defer cancel()
+			start := time.Now()
+			err := runner.run(ctx, graph, statusChannel, eventChannel)
+			duration := time.Since(start)
+			close(statusChannel)
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectedError == nil && err != nil {
+				t.Errorf("expected no error, but got %v", err)
+			}
+
+			if tc.expectedError != nil && err != tc.expectedError {
+				t.Errorf("expected error %v, but got %v", tc.expectedError, err)
+			}
+
+			if duration > tc.maxDuration {
+				t.Errorf("expected tasks to complete within %s, but took %s",
+					tc.maxDuration, duration)
+			}
+
+			if want, got := len(tc.expectedEventTypes), len(events); want != got {
+				t.Fatalf("expected %d events, but got %d", want, got)
+			}
+			for i, e := range events {
+				expectedEventType := tc.expectedEventTypes[i]
+				if want, got := expectedEventType, e.Type; want != got {
+					t.Errorf("expected event type %s, but got %s",
+						want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTaskGraph_AddTask(t *testing.T) {
+	g := NewTaskGraph()
+	if _, err := g.AddTask(nil); err == nil {
+		t.Errorf("expected error when adding a nil task")
+	}
+	if _, err := g.AddTask(&busyTask{}, 0); err == nil {
+		t.Errorf("expected error when dependency is not in the graph")
+	}
+	first := mustAddTask(t, g, &busyTask{})
+	// Tasks that are not comparable can be added, and the same value
+	// can be added more than once since the tasks are tracked by index.
+	second := mustAddTask(t, g, valueTask{events: []event.Type{event.ApplyType}}, first)
+	mustAddTask(t, g, valueTask{events: []event.Type{event.ApplyType}}, first, second)
+
+	if want, got := 3, g.Len(); want != got {
+		t.Errorf("expected %d tasks in graph, but got %d", want, got)
+	}
+}
+
+// TestBaseRunnerGraphBranches verifies that two branches that
+// depend on the same task are running at the same time. Each of the
+// tasks in the branches waits until the other one has started, so
+// the run can only complete if they overlap.
+func TestBaseRunnerGraphBranches(t *testing.T) {
+	eventChannel := make(chan event.Event)
+	var started sync.WaitGroup
+	started.Add(2)
+	g := NewTaskGraph()
+	first := mustAddTask(t, g, newBusyTask(eventChannel, event.InitType, 0, nil))
+	left := mustAddTask(t, g, &barrierTask{eventChannel: eventChannel, started: &started}, first)
+	right := mustAddTask(t, g, &barrierTask{eventChannel: eventChannel, started: &started}, first)
+	mustAddTask(t, g, newBusyTask(eventChannel, event.PruneType, 0, nil), left, right)
+
+	var events []event.Event
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range eventChannel {
+			events = append(events, msg)
+		}
+	}()
+
+	runErr := make(chan error)
+	go func() {
+		runErr <- newBaseRunner(newResourceStatusCollector(nil)).
+			run(context.Background(), g, nil, eventChannel)
+	}()
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("expected no error, but got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("expected the branches to run at the same time")
+	}
+	close(eventChannel)
+	<-done
+
+	expectedEventTypes := []event.Type{
+		event.InitType,
+		event.ApplyType,
+		event.ApplyType,
+		event.PruneType,
+	}
+	if want, got := len(expectedEventTypes), len(events); want != got {
+		t.Fatalf("expected %d events, but got %d", want, got)
+	}
+	for i, e := range events {
+		if want, got := expectedEventTypes[i], e.Type; want != got {
+			t.Errorf("expected event type %s, but got %s", want, got)
+		}
+	}
+}
+
+func mustAddTask(t *testing.T, g *TaskGraph, tsk Task, deps ...int) int {
+	index, err := g.AddTask(tsk, deps...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func newBusyTask(eventChannel chan event.Event, eventType event.Type,
+	duration time.Duration, err error) *busyTask {
+	return &busyTask{
+		eventChannel: eventChannel,
+		resultEvent: event.Event{
+			Type: eventType,
+		},
+		duration: duration,
+		err:      err,
+	}
+}
+
 type busyTask struct {
 	eventChannel chan event.Event
 	resultEvent  event.Event
@@ -364,3 +666,38 @@ func (b *busyTask) Start(taskChannel chan TaskResult) {
 }
 
 func (b *busyTask) ClearTimeout() {}
+
+// barrierTask signals that it has started, and then waits for all
+// the other tasks that share the started WaitGroup to start before
+// it completes.
+type barrierTask struct {
+	eventChannel chan event.Event
+	started      *sync.WaitGroup
+}
+
+func (b *barrierTask) Start(taskChannel chan TaskResult) {
+	go func() {
+		b.started.Done()
+		b.started.Wait()
+		b.eventChannel <- event.Event{
+			Type: event.ApplyType,
+		}
+		taskChannel <- TaskResult{}
+	}()
+}
+
+func (b *barrierTask) ClearTimeout() {}
+
+// valueTask is a Task that is not comparable, since it is used as
+// a value and has a slice field.
+type valueTask struct {
+	events []event.Type
+}
+
+func (v valueTask) Start(taskChannel chan TaskResult) {
+	go func() {
+		taskChannel <- TaskResult{}
+	}()
+}
+
+func (v valueTask) ClearTimeout() {}
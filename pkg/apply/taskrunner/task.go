@@ -19,7 +19,7 @@ type Task interface {
 
 // NewWaitTask creates a new wait task where we will wait until
 // the resources specifies by ids all meet the specified condition.
-func NewWaitTask(ids []object.ObjMetadata, cond condition, timeout time.Duration) *WaitTask {
+func NewWaitTask(ids []object.ObjMetadata, cond Condition, timeout time.Duration) *WaitTask {
 	// Create the token channel and only add one item.
 	tokenChannel := make(chan struct{}, 1)
 	tokenChannel <- struct{}{}
@@ -44,10 +44,13 @@ type WaitTask struct {
 	// Identifiers is the list of resources that we are waiting for.
 	Identifiers []object.ObjMetadata
 	// Condition defines the status we want all resources to reach
-	Condition condition
+	Condition Condition
 	// Timeout defines how long we are willing to wait for the condition
 	// to be met.
 	Timeout time.Duration
+	// ContinueOnTimeout makes the task complete instead of failing
+	// when the timeout is reached, so the next task is started.
+	ContinueOnTimeout bool
 
 	// cancelFunc is a function that will cancel the timeout timer
 	// on the task.
@@ -75,6 +78,10 @@ func (w *WaitTask) Start(taskChannel chan TaskResult) {
 		// We only send the taskResult if no one has gotten
 		// to the token first.
 		case <-w.token:
+			if w.ContinueOnTimeout {
+				taskChannel <- TaskResult{}
+				return
+			}
 			taskChannel <- TaskResult{
 				Err: timeoutError{
 					message: fmt.Sprintf("timeout after %.0f seconds waiting for %d resources to reach condition %s",
@@ -96,13 +103,15 @@ func (w *WaitTask) Start(taskChannel chan TaskResult) {
 // completes the task.
 func (w *WaitTask) startAndComplete(taskChannel chan TaskResult) {
 	w.cancelFunc = func() {}
-	w.complete(taskChannel)
+	w.Complete(taskChannel)
 }
 
-// complete is invoked by the taskrunner when all the conditions
+// Complete is invoked by the taskrunner when all the conditions
 // for the task has been met, or something has failed so the task
-// need to be stopped.
-func (w *WaitTask) complete(taskChannel chan TaskResult) {
+// need to be stopped. Only the first call will result in a
+// TaskResult on the taskChannel, so it is safe to call this
+// multiple times.
+func (w *WaitTask) Complete(taskChannel chan TaskResult) {
 	select {
 	// Only do something if we can get the token.
 	case <-w.token:
@@ -121,15 +130,15 @@ func (w *WaitTask) ClearTimeout() {
 
 // Condition is a type that defines the types of conditions
 // which a WaitTask can use.
-type condition string
+type Condition string
 
 const (
 	// AllCurrent Condition means all the provided resources
 	// has reached (and remains in) the Current status.
-	AllCurrent condition = "AllCurrent"
+	AllCurrent Condition = "AllCurrent"
 
 	// AllNotFound Condition means all the provided resources
 	// has reached the NotFound status, i.e. they are all deleted
 	// from the cluster.
-	AllNotFound condition = "AllNotFound"
+	AllNotFound Condition = "AllNotFound"
 )
@@ -32,6 +32,28 @@ func TestWaitTask_TimeoutTriggered(t *testing.T) {
 	}
 }
 
+func TestWaitTask_ContinueOnTimeout(t *testing.T) {
+	task := NewWaitTask([]object.ObjMetadata{}, AllCurrent, time.Second)
+	task.ContinueOnTimeout = true
+
+	taskChannel := make(chan TaskResult)
+	defer close(taskChannel)
+
+	task.Start(taskChannel)
+
+	timer := time.NewTimer(2 * time.Second)
+
+	select {
+	case res := <-taskChannel:
+		if res.Err != nil {
+			t.Errorf("expected the task to complete, but got %v", res.Err)
+		}
+		return
+	case <-timer.C:
+		t.Errorf("expected timeout to trigger, but it didn't")
+	}
+}
+
 func TestWaitTask_TimeoutCancelled(t *testing.T) {
 	task := NewWaitTask([]object.ObjMetadata{}, AllCurrent, 2*time.Second)
 
@@ -62,7 +84,7 @@ func TestWaitTask_SingleTaskResult(t *testing.T) {
 		completeWg.Add(1)
 		go func() {
 			defer completeWg.Done()
-			task.complete(taskChannel)
+			task.Complete(taskChannel)
 		}()
 	}
 	completeWg.Wait()
@@ -0,0 +1,194 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/cli-utils/pkg/apply/event"
+)
+
+// Watcher applies a package every time the manifests in its files and
+// directories change, which makes it possible to keep a cluster in sync
+// with a directory that is updated by other means, for example by
+// pulling from git. The paths are polled for changes, so it also works
+// for directories on network file systems.
+//
+// Runs are serialized: the package is never applied while a previous
+// apply is still running. Changes made during a run are applied by the
+// next run. Pruning is done by every run, as usual.
+type Watcher struct {
+	// Paths are the files and directories of the package.
+	Paths []string
+	// PollInterval is how often the paths are checked for changes.
+	PollInterval time.Duration
+	// Debounce is how long the package must be unchanged before it
+	// is applied, so that a change made in several steps is applied
+	// once, when it is complete.
+	Debounce time.Duration
+	// ResyncInterval is how often the package is applied even if it
+	// has not changed, which reverts any changes made to the live
+	// objects outside of apply. If it is zero, the package is only
+	// applied when it changes.
+	ResyncInterval time.Duration
+
+	// NewApplier returns an initialized Applier for the package. A new
+	// Applier is needed for every run, since it reads the package once.
+	NewApplier func() (*Applier, error)
+	// Handle processes the events from a run. It must return when the
+	// channel is closed.
+	Handle func(<-chan event.Event)
+	// Out is where messages about the state of the watcher are written
+	// between runs.
+	Out io.Writer
+
+	// runOnce applies the package. It is only replaced in tests.
+	runOnce func(ctx context.Context) error
+}
+
+// Run applies the package and then keeps applying it whenever it
+// changes, until the context is cancelled. Cancelling the context also
+// stops a run that is in progress, the same way as for the Applier.
+// Errors from a run are reported through the events and do not stop
+// the watcher, but an error creating the Applier for the first run is
+// returned, since it usually means the configuration is invalid.
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.runOnce == nil {
+		w.runOnce = w.apply
+	}
+	fingerprint, err := packageFingerprint(w.Paths)
+	if err != nil {
+		return err
+	}
+	if err := w.runOnce(ctx); err != nil {
+		return err
+	}
+	lastRun := time.Now()
+	w.logf("Watching %s for changes", strings.Join(w.Paths, ", "))
+
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := packageFingerprint(w.Paths)
+		if err != nil {
+			w.logf("Error reading package: %v", err)
+			continue
+		}
+		resync := w.ResyncInterval > 0 && time.Since(lastRun) >= w.ResyncInterval
+		if current == fingerprint && !resync {
+			continue
+		}
+		if current != fingerprint {
+			current, err = w.waitForQuiet(ctx, current)
+			if err != nil {
+				w.logf("Error reading package: %v", err)
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			w.logf("Package changed, applying")
+		} else {
+			w.logf("Resync interval elapsed, applying")
+		}
+		// The fingerprint is taken before the run, so any changes made
+		// while applying are picked up by the next run.
+		fingerprint = current
+		if err := w.runOnce(ctx); err != nil {
+			w.logf("Error: %v", err)
+		}
+		lastRun = time.Now()
+		w.logf("Watching %s for changes", strings.Join(w.Paths, ", "))
+	}
+}
+
+// waitForQuiet waits until the package has not changed for the
+// debounce period, and returns its fingerprint at that point.
+func (w *Watcher) waitForQuiet(ctx context.Context, fingerprint string) (string, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return fingerprint, nil
+		case <-time.After(w.Debounce):
+		}
+		current, err := packageFingerprint(w.Paths)
+		if err != nil {
+			return "", err
+		}
+		if current == fingerprint {
+			return current, nil
+		}
+		fingerprint = current
+	}
+}
+
+// apply runs a new Applier and waits for the events to be handled.
+func (w *Watcher) apply(ctx context.Context) error {
+	applier, err := w.NewApplier()
+	if err != nil {
+		return err
+	}
+	w.Handle(applier.Run(ctx))
+	return nil
+}
+
+func (w *Watcher) logf(format string, a ...interface{}) {
+	if w.Out == nil {
+		return
+	}
+	fmt.Fprintf(w.Out, "%s %s\n", time.Now().Format(time.RFC3339), fmt.Sprintf(format, a...))
+}
+
+// packageFingerprint returns a hash of the names and contents of the
+// files in the paths. Hidden files and directories, like .git, are
+// skipped since they are not part of the package.
+func packageFingerprint(paths []string) (string, error) {
+	h := sha256.New()
+	for _, root := range paths {
+		fmt.Fprintf(h, "%s\x00", root)
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00%d\x00", rel, len(content))
+			_, err = h.Write(content)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPackageFingerprint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fingerprint")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "pod.yaml"), "kind: Pod")
+
+	initial, err := packageFingerprint([]string{dir})
+	assert.NoError(t, err)
+
+	writeFile(t, filepath.Join(dir, ".git", "HEAD"), "ref: refs/heads/master")
+	writeFile(t, filepath.Join(dir, ".hidden.yaml"), "kind: Service")
+	fingerprint, err := packageFingerprint([]string{dir})
+	assert.NoError(t, err)
+	assert.Equal(t, initial, fingerprint, "hidden files should be ignored")
+
+	writeFile(t, filepath.Join(dir, "pod.yaml"), "kind: Deployment")
+	fingerprint, err = packageFingerprint([]string{dir})
+	assert.NoError(t, err)
+	assert.NotEqual(t, initial, fingerprint, "changed content should change the fingerprint")
+
+	writeFile(t, filepath.Join(dir, "pod.yaml"), "kind: Pod")
+	writeFile(t, filepath.Join(dir, "sub", "service.yaml"), "kind: Service")
+	fingerprint, err = packageFingerprint([]string{dir})
+	assert.NoError(t, err)
+	assert.NotEqual(t, initial, fingerprint, "new files should change the fingerprint")
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watcher")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "pod.yaml"), "kind: Pod")
+
+	var mu sync.Mutex
+	runs := 0
+	running := false
+	overlapping := false
+	ranCh := make(chan struct{}, 10)
+
+	w := &Watcher{
+		Paths:        []string{dir},
+		PollInterval: 10 * time.Millisecond,
+		Debounce:     50 * time.Millisecond,
+	}
+	w.runOnce = func(ctx context.Context) error {
+		mu.Lock()
+		if running {
+			overlapping = true
+		}
+		running = true
+		runs++
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running = false
+		mu.Unlock()
+		ranCh <- struct{}{}
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	waitForRun := func() {
+		select {
+		case <-ranCh:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for run")
+		}
+	}
+
+	// The package is applied when the watcher starts.
+	waitForRun()
+
+	// Several changes in a row are applied once.
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet"} {
+		writeFile(t, filepath.Join(dir, "pod.yaml"), "kind: "+kind)
+		time.Sleep(15 * time.Millisecond)
+	}
+	waitForRun()
+
+	// Nothing is applied while the package is unchanged.
+	time.Sleep(200 * time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 2, runs)
+	assert.False(t, overlapping)
+}
@@ -12,14 +12,20 @@ import (
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/cli-utils/pkg/apply/prune"
 	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
 
 	"github.com/google/uuid"
 )
 
 const manifestFilename = "inventory-template.yaml"
 
+const defaultInventoryName = "inventory"
+
 const configMapTemplate = `# NOTE: auto-generated. Some fields should NOT be modified.
 # Date: <DATETIME>
 #
@@ -48,7 +54,44 @@ metadata:
   # NOTE: The name of the inventory object does NOT have
   # any impact on group-related functionality such as
   # deletion or pruning.
-  name: inventory
+  name: <NAME>
+  labels:
+    # DANGER: Do not change the value of this label.
+    # Changing this value will cause a loss of continuity
+    # with previously applied grouped objects. Set deletion
+    # and pruning functionality will be impaired.
+    cli-utils.sigs.k8s.io/inventory-id: <INVENTORYID>
+`
+
+const clusterInventoryTemplate = `# NOTE: auto-generated. Some fields should NOT be modified.
+# Date: <DATETIME>
+#
+# Contains the "inventory object" template ClusterInventory.
+# When this object is applied, it is handled specially,
+# storing the metadata of all the other objects applied.
+# This object and its stored inventory is subsequently
+# used to calculate the set of objects to automatically
+# delete (prune), when an object is omitted from further
+# applies. When applied, this "inventory object" is also
+# used to identify the entire set of objects to delete.
+#
+# The ClusterInventory is cluster-scoped, so the package
+# does not need a namespace to hold it. The CRD for the
+# ClusterInventory must be installed in the cluster; it
+# can be printed with "init --print-crd".
+#
+# NOTE: The name of this inventory template file
+# (e.g. ` + manifestFilename + `) does NOT have any
+# impact on group-related functionality such as deletion
+# or pruning.
+#
+apiVersion: cli-utils.sigs.k8s.io/v1alpha1
+kind: ClusterInventory
+metadata:
+  # NOTE: The name of the inventory object does NOT have
+  # any impact on group-related functionality such as
+  # deletion or pruning.
+  name: <NAME>
   labels:
     # DANGER: Do not change the value of this label.
     # Changing this value will cause a loss of continuity
@@ -65,8 +108,22 @@ type InitOptions struct {
 	Dir string
 	// Namespace for inventory object; can not be empty.
 	Namespace string
+	// Name of the inventory object; defaults to "inventory".
+	Name string
 	// Inventory object label value; must be a valid k8s label value.
 	InventoryID string
+	// ClusterScoped generates a cluster-scoped ClusterInventory
+	// template instead of a ConfigMap. Namespace is not used.
+	ClusterScoped bool
+	// PrintCRD prints the ClusterInventory CRD instead of
+	// generating a template. No directory argument is needed.
+	PrintCRD bool
+	// Upgrade rewrites an existing inventory object template in
+	// the current format. The inventory-id is kept, as are the
+	// namespace and name unless they are set explicitly.
+	Upgrade bool
+	// templatePath is the file the template is written to.
+	templatePath string
 }
 
 func NewInitOptions(ioStreams genericclioptions.IOStreams) *InitOptions {
@@ -79,6 +136,12 @@ func NewInitOptions(ioStreams genericclioptions.IOStreams) *InitOptions {
 // TODO(seans3): Look into changing this kubectl-inspired way of organizing
 // the InitOptions (e.g. Complete and Run methods).
 func (i *InitOptions) Complete(args []string) error {
+	if i.PrintCRD {
+		if len(args) != 0 {
+			return fmt.Errorf("no arguments allowed with --print-crd; have %d", len(args))
+		}
+		return nil
+	}
 	if len(args) != 1 {
 		return fmt.Errorf("need one 'directory' arg; have %d", len(args))
 	}
@@ -87,7 +150,22 @@ func (i *InitOptions) Complete(args []string) error {
 		return err
 	}
 	i.Dir = dir
-	if len(i.Namespace) == 0 {
+	templates, err := findInventoryTemplates(i.Dir)
+	if err != nil {
+		return err
+	}
+	if i.Upgrade {
+		if err := i.completeUpgrade(templates); err != nil {
+			return err
+		}
+	} else {
+		if len(templates) > 0 {
+			return fmt.Errorf("package already contains an inventory object template: %s",
+				templates[0].path)
+		}
+		i.templatePath = filepath.Join(i.Dir, manifestFilename)
+	}
+	if len(i.Namespace) == 0 && !i.ClusterScoped {
 		// Returns default namespace if no namespace found.
 		namespace, err := calcPackageNamespace(i.Dir)
 		if err != nil {
@@ -95,6 +173,12 @@ func (i *InitOptions) Complete(args []string) error {
 		}
 		i.Namespace = namespace
 	}
+	if len(i.Name) == 0 {
+		i.Name = defaultInventoryName
+	}
+	if errs := validation.IsDNS1123Subdomain(i.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid inventory object name %q: %s", i.Name, strings.Join(errs, ", "))
+	}
 	// Set the default inventory label if one does not exist.
 	if len(i.InventoryID) == 0 {
 		inventoryID, err := i.defaultInventoryID()
@@ -109,6 +193,99 @@ func (i *InitOptions) Complete(args []string) error {
 	return nil
 }
 
+// completeUpgrade fills in the fields that are not set explicitly
+// from the existing inventory object template, so it can be
+// rewritten in the current format. Returns an error if there isn't
+// exactly one template, or it shares its file with other objects.
+func (i *InitOptions) completeUpgrade(templates []inventoryTemplate) error {
+	if len(templates) == 0 {
+		return fmt.Errorf("no inventory object template to upgrade in %s", i.Dir)
+	}
+	if len(templates) > 1 {
+		var paths []string
+		for _, t := range templates {
+			paths = append(paths, t.path)
+		}
+		return fmt.Errorf("multiple inventory object templates to upgrade: %s",
+			strings.Join(paths, ", "))
+	}
+	t := templates[0]
+	if t.objectsInFile > 1 {
+		return fmt.Errorf("inventory object template shares its file with other objects; "+
+			"move it to a separate file before upgrading: %s", t.path)
+	}
+	inventoryID := t.meta.Labels[prune.GroupingLabel]
+	if len(i.InventoryID) > 0 && i.InventoryID != inventoryID {
+		return fmt.Errorf("inventory-id can not be changed when upgrading (%s)", inventoryID)
+	}
+	i.InventoryID = inventoryID
+	if len(i.Name) == 0 {
+		i.Name = t.meta.Name
+	}
+	if len(i.Namespace) == 0 {
+		i.Namespace = t.meta.Namespace
+	}
+	if !i.ClusterScoped {
+		i.ClusterScoped = t.meta.Kind == prune.ClusterInventoryGroupKind.Kind
+	}
+	i.templatePath = t.path
+	return nil
+}
+
+// inventoryTemplate is an inventory object template found
+// in a package.
+type inventoryTemplate struct {
+	// path is the absolute path of the file with the template.
+	path string
+	// objectsInFile is the number of objects in that file,
+	// including the template.
+	objectsInFile int
+	meta          yaml.ResourceMeta
+}
+
+// findInventoryTemplates returns the inventory object templates
+// anywhere in the directory tree of the package.
+func findInventoryTemplates(packageDir string) ([]inventoryTemplate, error) {
+	nodes, err := readPackage(packageDir)
+	if err != nil {
+		return nil, err
+	}
+	objectsInFile := make(map[string]int)
+	var templates []inventoryTemplate
+	for _, node := range nodes {
+		rm, err := node.GetMeta()
+		if err != nil {
+			continue
+		}
+		path := filepath.Join(packageDir, rm.Annotations[kioutil.PathAnnotation])
+		objectsInFile[path]++
+		if isInventoryTemplate(rm) {
+			templates = append(templates, inventoryTemplate{path: path, meta: rm})
+		}
+	}
+	for i := range templates {
+		templates[i].objectsInFile = objectsInFile[templates[i].path]
+	}
+	return templates, nil
+}
+
+// isInventoryTemplate returns true if the object is a ConfigMap or a
+// ClusterInventory with the inventory-id label.
+func isInventoryTemplate(rm yaml.ResourceMeta) bool {
+	if _, found := rm.Labels[prune.GroupingLabel]; !found {
+		return false
+	}
+	return (rm.Kind == "ConfigMap" && rm.APIVersion == "v1") ||
+		rm.Kind == prune.ClusterInventoryGroupKind.Kind
+}
+
+// readPackage reads all the objects in the directory tree of the
+// package, including subpackages.
+func readPackage(packageDir string) ([]*yaml.RNode, error) {
+	r := kio.LocalPackageReader{PackagePath: packageDir, IncludeSubpackages: true}
+	return r.Read()
+}
+
 // normalizeDir returns full absolute directory path of the
 // passed directory or an error. This function cleans up paths
 // such as current directory (.), relative directories (..), or
@@ -133,28 +310,40 @@ func isDirectory(path string) bool {
 }
 
 // calcPackageNamespace returns the namespace of the package
-// config files. Assumes all namespaced resources are in the
-// same namespace. Returns the default namespace if none of the
-// config files has a namespace.
+// config files. Returns the default namespace if none of the
+// config files has a namespace. Returns an error if the config
+// files are in more than one namespace, since it is ambiguous
+// which one should hold the inventory object.
 func calcPackageNamespace(packageDir string) (string, error) {
-	r := kio.LocalPackageReader{PackagePath: packageDir}
-	nodes, err := r.Read()
+	nodes, err := readPackage(packageDir)
 	if err != nil {
 		return "", err
 	}
-	// Return the first non-empty namespace found. Cluster-scoped
-	// resources do not have namespace set.
+	// Cluster-scoped resources do not have namespace set.
+	var namespaces []string
+	seen := make(map[string]bool)
 	for _, node := range nodes {
 		rm, err := node.GetMeta()
 		if err != nil {
 			continue
 		}
-		if len(rm.ObjectMeta.Namespace) > 0 {
-			return rm.ObjectMeta.Namespace, nil
+		ns := rm.ObjectMeta.Namespace
+		if len(ns) > 0 && !seen[ns] && !isInventoryTemplate(rm) {
+			seen[ns] = true
+			namespaces = append(namespaces, ns)
 		}
 	}
-	// Return the default namespace if none found.
-	return metav1.NamespaceDefault, nil
+	switch len(namespaces) {
+	case 0:
+		// Return the default namespace if none found.
+		return metav1.NamespaceDefault, nil
+	case 1:
+		return namespaces[0], nil
+	default:
+		return "", fmt.Errorf("package has objects in multiple namespaces (%s); "+
+			"use --namespace to choose the inventory object namespace",
+			strings.Join(namespaces, ", "))
+	}
 }
 
 // defaultInventoryID returns a UUID string as a default unique
@@ -201,15 +390,23 @@ func (i *InitOptions) fillInValues() string {
 	now := time.Now()
 	nowStr := now.Format("2006-01-02 15:04:05 MST")
 	manifestStr := configMapTemplate
+	if i.ClusterScoped {
+		manifestStr = clusterInventoryTemplate
+	}
 	manifestStr = strings.ReplaceAll(manifestStr, "<DATETIME>", nowStr)
 	manifestStr = strings.ReplaceAll(manifestStr, "<NAMESPACE>", i.Namespace)
+	manifestStr = strings.ReplaceAll(manifestStr, "<NAME>", i.Name)
 	manifestStr = strings.ReplaceAll(manifestStr, "<INVENTORYID>", i.InventoryID)
 	return manifestStr
 }
 
 func (i *InitOptions) Run() error {
-	manifestFilePath := filepath.Join(i.Dir, manifestFilename)
-	if fileExists(manifestFilePath) {
+	if i.PrintCRD {
+		_, err := fmt.Fprint(i.ioStreams.Out, prune.ClusterInventoryCRD)
+		return err
+	}
+	manifestFilePath := i.templatePath
+	if !i.Upgrade && fileExists(manifestFilePath) {
 		return fmt.Errorf("inventory object template file already exists: %s", manifestFilePath)
 	}
 	f, err := os.Create(manifestFilePath)
@@ -221,6 +418,10 @@ func (i *InitOptions) Run() error {
 	if err != nil {
 		return fmt.Errorf("unable to write inventory object template file: %s", manifestFilePath)
 	}
-	fmt.Fprintf(i.ioStreams.Out, "Initialized: %s\n", manifestFilePath)
+	if i.Upgrade {
+		fmt.Fprintf(i.ioStreams.Out, "Upgraded: %s\n", manifestFilePath)
+	} else {
+		fmt.Fprintf(i.ioStreams.Out, "Initialized: %s\n", manifestFilePath)
+	}
 	return nil
 }
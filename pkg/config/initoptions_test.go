@@ -5,6 +5,9 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -16,8 +19,9 @@ var ioStreams = genericclioptions.IOStreams{}
 
 func TestComplete(t *testing.T) {
 	tests := map[string]struct {
-		args    []string
-		isError bool
+		args     []string
+		printCRD bool
+		isError  bool
 	}{
 		"Empty args returns error": {
 			args:    []string{},
@@ -31,15 +35,29 @@ func TestComplete(t *testing.T) {
 			args:    []string{"foo"},
 			isError: true,
 		},
+		"Print CRD needs no arguments": {
+			args:     []string{},
+			printCRD: true,
+			isError:  false,
+		},
+		"Print CRD with an argument should fail": {
+			args:     []string{"foo"},
+			printCRD: true,
+			isError:  true,
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
 			io := NewInitOptions(ioStreams)
+			io.PrintCRD = tc.printCRD
 			err := io.Complete(tc.args)
 			if tc.isError && err == nil {
 				t.Errorf("Expected error, but did not receive one")
 			}
+			if !tc.isError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
 		})
 	}
 }
@@ -134,8 +152,12 @@ func TestFillInValues(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			io := NewInitOptions(ioStreams)
 			io.Namespace = tc.namespace
+			io.Name = "baz"
 			io.InventoryID = tc.inventoryID
 			actual := io.fillInValues()
+			if !strings.Contains(actual, "name: baz") {
+				t.Errorf("\nExpected name (baz) not found in inventory object: %s\n", actual)
+			}
 			expectedLabel := fmt.Sprintf("cli-utils.sigs.k8s.io/inventory-id: %s", tc.inventoryID)
 			if !strings.Contains(actual, expectedLabel) {
 				t.Errorf("\nExpected label (%s) not found in inventory object: %s\n", expectedLabel, actual)
@@ -150,3 +172,177 @@ func TestFillInValues(t *testing.T) {
 		})
 	}
 }
+
+func TestFillInValuesClusterScoped(t *testing.T) {
+	io := NewInitOptions(ioStreams)
+	io.ClusterScoped = true
+	io.InventoryID = "bar"
+	actual := io.fillInValues()
+	expectedLabel := "cli-utils.sigs.k8s.io/inventory-id: bar"
+	if !strings.Contains(actual, expectedLabel) {
+		t.Errorf("\nExpected label (%s) not found in inventory object: %s\n", expectedLabel, actual)
+	}
+	if !strings.Contains(actual, "kind: ClusterInventory") {
+		t.Errorf("\nExpected `kind: ClusterInventory` not found in inventory object: %s\n", actual)
+	}
+	if strings.Contains(actual, "namespace:") {
+		t.Errorf("\nUnexpected namespace found in cluster-scoped inventory object: %s\n", actual)
+	}
+}
+
+const deploymentInFoo = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: foo
+`
+
+const serviceInBar = `apiVersion: v1
+kind: Service
+metadata:
+  name: bar
+  namespace: bar
+`
+
+const oldTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-inventory
+  namespace: foo
+  labels:
+    cli-utils.sigs.k8s.io/inventory-id: old-id
+`
+
+// writePackage writes the files (relative path to content)
+// to a new temporary directory, and returns its path.
+func writePackage(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "init-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		p := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCompletePackage(t *testing.T) {
+	tests := map[string]struct {
+		files               map[string]string
+		namespace           string
+		name                string
+		inventoryID         string
+		upgrade             bool
+		isError             bool
+		expectedNamespace   string
+		expectedName        string
+		expectedInventoryID string
+		expectedPath        string
+	}{
+		"Namespace derived from the package": {
+			files:             map[string]string{"deployment.yaml": deploymentInFoo},
+			expectedNamespace: "foo",
+			expectedName:      "inventory",
+			expectedPath:      manifestFilename,
+		},
+		"Multiple namespaces in the package is ambiguous": {
+			files: map[string]string{
+				"deployment.yaml":  deploymentInFoo,
+				"sub/service.yaml": serviceInBar,
+			},
+			isError: true,
+		},
+		"Explicit namespace and name": {
+			files: map[string]string{
+				"deployment.yaml":  deploymentInFoo,
+				"sub/service.yaml": serviceInBar,
+			},
+			namespace:         "bar",
+			name:              "platform",
+			expectedNamespace: "bar",
+			expectedName:      "platform",
+			expectedPath:      manifestFilename,
+		},
+		"Invalid name fails": {
+			files:   map[string]string{"deployment.yaml": deploymentInFoo},
+			name:    "Not_Valid",
+			isError: true,
+		},
+		"Existing template somewhere in the tree fails": {
+			files: map[string]string{
+				"deployment.yaml":   deploymentInFoo,
+				"sub/template.yaml": oldTemplate,
+			},
+			isError: true,
+		},
+		"Upgrade keeps the inventory-id, name and namespace": {
+			files: map[string]string{
+				"deployment.yaml":   deploymentInFoo,
+				"sub/template.yaml": oldTemplate,
+			},
+			upgrade:             true,
+			expectedNamespace:   "foo",
+			expectedName:        "old-inventory",
+			expectedInventoryID: "old-id",
+			expectedPath:        "sub/template.yaml",
+		},
+		"Upgrade can not change the inventory-id": {
+			files:       map[string]string{"template.yaml": oldTemplate},
+			upgrade:     true,
+			inventoryID: "new-id",
+			isError:     true,
+		},
+		"Upgrade without a template fails": {
+			files:   map[string]string{"deployment.yaml": deploymentInFoo},
+			upgrade: true,
+			isError: true,
+		},
+		"Upgrade of a template sharing its file fails": {
+			files:   map[string]string{"all.yaml": deploymentInFoo + "---\n" + oldTemplate},
+			upgrade: true,
+			isError: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := writePackage(t, tc.files)
+			defer os.RemoveAll(dir)
+
+			io := NewInitOptions(ioStreams)
+			io.Namespace = tc.namespace
+			io.Name = tc.name
+			io.InventoryID = tc.inventoryID
+			io.Upgrade = tc.upgrade
+			err := io.Complete([]string{dir})
+			if tc.isError {
+				if err == nil {
+					t.Errorf("Expected error, but did not receive one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if io.Namespace != tc.expectedNamespace {
+				t.Errorf("Expected namespace (%s), got (%s)", tc.expectedNamespace, io.Namespace)
+			}
+			if io.Name != tc.expectedName {
+				t.Errorf("Expected name (%s), got (%s)", tc.expectedName, io.Name)
+			}
+			if len(tc.expectedInventoryID) > 0 && io.InventoryID != tc.expectedInventoryID {
+				t.Errorf("Expected inventory-id (%s), got (%s)", tc.expectedInventoryID, io.InventoryID)
+			}
+			expectedPath := filepath.Join(io.Dir, tc.expectedPath)
+			if io.templatePath != expectedPath {
+				t.Errorf("Expected template path (%s), got (%s)", expectedPath, io.templatePath)
+			}
+		})
+	}
+}
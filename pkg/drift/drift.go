@@ -0,0 +1,140 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package drift compares the configuration of resources in local
+// manifests with the live resources in the cluster, and reports the
+// fields where they differ.
+package drift
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FieldDiff is a field that has a different value in the
+// live resource than in the local manifest.
+type FieldDiff struct {
+	// Path is the path to the field, for example
+	// .spec.template.spec.containers[0].image
+	Path string
+	// Local is the value of the field in the local manifest.
+	Local interface{}
+	// Live is the value of the field in the live resource. It
+	// is nil if the field is not set in the live resource.
+	Live interface{}
+}
+
+// String returns a description of the difference.
+func (f FieldDiff) String() string {
+	if f.Live == nil {
+		return fmt.Sprintf("%s: local %v, not set live", f.Path, f.Local)
+	}
+	return fmt.Sprintf("%s: local %v, live %v", f.Path, f.Local, f.Live)
+}
+
+// ignoredFields are top-level fields that are never considered
+// drift, since they are not configuration.
+var ignoredFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"status":     true,
+}
+
+// Compare returns the fields set in the local manifest that have a
+// different value in the live resource. Fields that are only set in
+// the live resource, like defaulted fields or fields managed by
+// controllers, are not reported. The result is sorted by path.
+func Compare(local, live *unstructured.Unstructured) []FieldDiff {
+	var diffs []FieldDiff
+	for _, key := range sortedKeys(local.Object) {
+		if ignoredFields[key] {
+			continue
+		}
+		liveValue, found := live.Object[key]
+		if !found {
+			liveValue = nil
+		}
+		diffs = compareValues(appendPath("", key), local.Object[key], liveValue, diffs)
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
+
+func compareValues(path string, local, live interface{}, diffs []FieldDiff) []FieldDiff {
+	switch l := local.(type) {
+	case map[string]interface{}:
+		liveMap, ok := live.(map[string]interface{})
+		if !ok {
+			return append(diffs, FieldDiff{Path: path, Local: local, Live: live})
+		}
+		for _, key := range sortedKeys(l) {
+			diffs = compareValues(appendPath(path, key), l[key], liveMap[key], diffs)
+		}
+		return diffs
+	case []interface{}:
+		liveList, ok := live.([]interface{})
+		if !ok || len(liveList) != len(l) {
+			return append(diffs, FieldDiff{Path: path, Local: local, Live: live})
+		}
+		for i := range l {
+			diffs = compareValues(fmt.Sprintf("%s[%d]", path, i), l[i], liveList[i], diffs)
+		}
+		return diffs
+	default:
+		if !equalScalars(local, live) {
+			return append(diffs, FieldDiff{Path: path, Local: local, Live: live})
+		}
+		return diffs
+	}
+}
+
+// equalScalars compares two scalar values. Numbers are compared by
+// value, since they can be decoded as different types.
+func equalScalars(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// appendPath adds the key to the path. Keys that are not plain
+// identifiers, like most label and annotation keys, are put in
+// brackets.
+func appendPath(path, key string) string {
+	if strings.ContainsAny(key, "./[] ") {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,368 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCompare(t *testing.T) {
+	testCases := map[string]struct {
+		local    map[string]interface{}
+		live     map[string]interface{}
+		expected []FieldDiff
+	}{
+		"identical objects": {
+			local: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"spec": map[string]interface{}{
+					"type": "ClusterIP",
+				},
+			},
+			live: map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"spec": map[string]interface{}{
+					"type": "ClusterIP",
+				},
+			},
+			expected: nil,
+		},
+		"fields only set live are ignored": {
+			local: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+				},
+			},
+			live: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas":             int64(3),
+					"revisionHistoryLimit": int64(10),
+				},
+				"status": map[string]interface{}{
+					"replicas": int64(1),
+				},
+			},
+			expected: nil,
+		},
+		"numbers are compared by value": {
+			local: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+				},
+			},
+			live: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas": float64(3),
+				},
+			},
+			expected: nil,
+		},
+		"changed and missing fields": {
+			local: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"app.kubernetes.io/name": "foo",
+					},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+					"paused":   true,
+				},
+			},
+			live: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"app.kubernetes.io/name": "bar",
+					},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(5),
+				},
+			},
+			expected: []FieldDiff{
+				{Path: ".metadata.labels[app.kubernetes.io/name]", Local: "foo", Live: "bar"},
+				{Path: ".spec.paused", Local: true, Live: nil},
+				{Path: ".spec.replicas", Local: int64(3), Live: int64(5)},
+			},
+		},
+		"lists are compared by element": {
+			local: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:v2",
+						},
+					},
+				},
+			},
+			live: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":            "app",
+							"image":           "app:v1",
+							"imagePullPolicy": "IfNotPresent",
+						},
+					},
+				},
+			},
+			expected: []FieldDiff{
+				{Path: ".spec.containers[0].image", Local: "app:v2", Live: "app:v1"},
+			},
+		},
+		"lists with different length": {
+			local: map[string]interface{}{
+				"args": []interface{}{"a", "b"},
+			},
+			live: map[string]interface{}{
+				"args": []interface{}{"a"},
+			},
+			expected: []FieldDiff{
+				{Path: ".args", Local: []interface{}{"a", "b"}, Live: []interface{}{"a"}},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			diffs := Compare(&unstructured.Unstructured{Object: tc.local},
+				&unstructured.Unstructured{Object: tc.live})
+			assert.Equal(t, tc.expected, diffs)
+		})
+	}
+}
+
+func TestCompareManaged(t *testing.T) {
+	local := map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"paused":   true,
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:v2",
+						},
+					},
+				},
+			},
+		},
+	}
+	liveSpec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"replicas": int64(5),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":  "app",
+							"image": "app:v1",
+						},
+					},
+				},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		live     *unstructured.Unstructured
+		expected []FieldDiff
+	}{
+		"fields in last-applied-configuration": {
+			live: func() *unstructured.Unstructured {
+				u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": liveSpec()}}
+				u.SetAnnotations(map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"spec":{"replicas":3}}`,
+				})
+				return u
+			}(),
+			expected: []FieldDiff{
+				{Path: ".spec.replicas", Local: int64(3), Live: int64(5)},
+			},
+		},
+		"fields owned by server-side apply": {
+			live: func() *unstructured.Unstructured {
+				u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": liveSpec()}}
+				u.SetManagedFields([]metav1.ManagedFieldsEntry{
+					{
+						Manager:   "kubectl",
+						Operation: metav1.ManagedFieldsOperationApply,
+						FieldsV1: &metav1.FieldsV1{
+							Raw: []byte(`{"f:spec":{"f:template":{"f:spec":{"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}}}}}}}`),
+						},
+					},
+					{
+						Manager:   "kube-controller-manager",
+						Operation: metav1.ManagedFieldsOperationUpdate,
+						FieldsV1: &metav1.FieldsV1{
+							Raw: []byte(`{"f:spec":{"f:replicas":{}}}`),
+						},
+					},
+				})
+				return u
+			}(),
+			expected: []FieldDiff{
+				{Path: ".spec.template.spec.containers[0].image", Local: "app:v2", Live: "app:v1"},
+			},
+		},
+		"no information about managed fields": {
+			live: &unstructured.Unstructured{Object: map[string]interface{}{"spec": liveSpec()}},
+			expected: []FieldDiff{
+				{Path: ".spec.paused", Local: true, Live: nil},
+				{Path: ".spec.replicas", Local: int64(3), Live: int64(5)},
+				{Path: ".spec.template.spec.containers[0].image", Local: "app:v2", Live: "app:v1"},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			diffs, err := CompareManaged(&unstructured.Unstructured{Object: local}, tc.live)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expected, diffs)
+		})
+	}
+}
+
+func TestDefaultedFields(t *testing.T) {
+	spec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"replicas": int64(1),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":            "app",
+							"image":           "app:v1",
+							"imagePullPolicy": "IfNotPresent",
+						},
+					},
+					"restartPolicy": "Always",
+				},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		obj      *unstructured.Unstructured
+		expected []string
+	}{
+		"fields not in last-applied-configuration": {
+			obj: func() *unstructured.Unstructured {
+				u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec()}}
+				u.SetAnnotations(map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","image":"app:v1"}]}}}}`,
+				})
+				return u
+			}(),
+			expected: []string{
+				".spec.template.spec.containers[0].imagePullPolicy",
+				".spec.template.spec.restartPolicy",
+			},
+		},
+		"fields not owned by server-side apply": {
+			obj: func() *unstructured.Unstructured {
+				u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec()}}
+				u.SetManagedFields([]metav1.ManagedFieldsEntry{
+					{
+						Manager:   "kubectl",
+						Operation: metav1.ManagedFieldsOperationApply,
+						FieldsV1: &metav1.FieldsV1{
+							Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{"f:spec":{"f:restartPolicy":{},"f:containers":{"k:{\"name\":\"app\"}":{".":{},"f:image":{},"f:name":{}}}}}}}`),
+						},
+					},
+				})
+				return u
+			}(),
+			expected: []string{
+				".spec.template.spec.containers[0].imagePullPolicy",
+			},
+		},
+		"no information about managed fields": {
+			obj: &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec()}},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			paths, err := DefaultedFields(tc.obj)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expected, paths)
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	local := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "foo",
+		},
+		"data": map[string]interface{}{
+			"a": "1",
+			"b": "2",
+		},
+	}}
+
+	testCases := map[string]struct {
+		live     *unstructured.Unstructured
+		expected []string
+	}{
+		"unchanged": {
+			live: func() *unstructured.Unstructured {
+				u := local.DeepCopy()
+				u.SetAnnotations(map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"name":"foo"},"data":{"a":"1","b":"2"}}`,
+				})
+				_ = unstructured.SetNestedField(u.Object, "defaulted", "data", "c")
+				return u
+			}(),
+		},
+		"changed and removed fields": {
+			live: func() *unstructured.Unstructured {
+				u := local.DeepCopy()
+				u.SetAnnotations(map[string]string{
+					"kubectl.kubernetes.io/last-applied-configuration": `{"metadata":{"name":"foo"},"data":{"a":"0","b":"2","old":"x"}}`,
+				})
+				_ = unstructured.SetNestedField(u.Object, "0", "data", "a")
+				_ = unstructured.SetNestedField(u.Object, "x", "data", "old")
+				return u
+			}(),
+			expected: []string{".data.a", ".data.old"},
+		},
+		"no last-applied-configuration": {
+			live: func() *unstructured.Unstructured {
+				u := local.DeepCopy()
+				_ = unstructured.SetNestedField(u.Object, "3", "data", "b")
+				return u
+			}(),
+			expected: []string{".data.b"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			changes, err := Changes(local, tc.live)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.expected, changes)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// ResourceDrift is the drift found for a single resource.
+type ResourceDrift struct {
+	Identifier object.ObjMetadata
+	// NotFound is true if the resource does not exist in the cluster.
+	NotFound bool
+	// Fields are the managed fields that differ from the manifest.
+	Fields []FieldDiff
+}
+
+// Drifted returns true if the live resource does not match the manifest.
+func (r ResourceDrift) Drifted() bool {
+	return r.NotFound || len(r.Fields) > 0
+}
+
+// CompareManaged is like Compare, but only reports the fields that are
+// managed by apply, so fields that are set in the manifest but have not
+// been applied yet are not reported. The managed fields are the fields in
+// the last-applied-configuration annotation of the live resource or, if
+// it does not have one, the fields owned by server-side apply in its
+// managedFields. If the live resource has neither, all the fields set in
+// the manifest are compared.
+func CompareManaged(local, live *unstructured.Unstructured) ([]FieldDiff, error) {
+	diffs := Compare(local, live)
+	managed, found, err := managedPaths(live)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return diffs, nil
+	}
+	var result []FieldDiff
+	for _, d := range diffs {
+		if isManaged(d.Path, managed) {
+			result = append(result, d)
+		}
+	}
+	return result, nil
+}
+
+// managedPaths returns the paths of the fields managed by apply in the
+// live resource, and whether the resource has any information about
+// which fields are managed.
+func managedPaths(live *unstructured.Unstructured) ([]string, bool, error) {
+	if lastApplied, found := live.GetAnnotations()[v1.LastAppliedConfigAnnotation]; found {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(lastApplied), &obj); err != nil {
+			return nil, false, fmt.Errorf("error parsing %s: %v", v1.LastAppliedConfigAnnotation, err)
+		}
+		var paths []string
+		for _, key := range sortedKeys(obj) {
+			if ignoredFields[key] {
+				continue
+			}
+			paths = leafPaths(appendPath("", key), obj[key], paths)
+		}
+		return paths, true, nil
+	}
+
+	var paths []string
+	found := false
+	for _, entry := range live.GetManagedFields() {
+		if entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return nil, false, fmt.Errorf("error parsing managed fields of %s: %v", entry.Manager, err)
+		}
+		found = true
+		paths = fieldSetPaths("", fields, live.Object, paths)
+	}
+	return paths, found, nil
+}
+
+// leafPaths appends the paths of all the leaf fields in the value.
+func leafPaths(path string, value interface{}, paths []string) []string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return append(paths, path)
+		}
+		for _, key := range sortedKeys(v) {
+			paths = leafPaths(appendPath(path, key), v[key], paths)
+		}
+		return paths
+	case []interface{}:
+		if len(v) == 0 {
+			return append(paths, path)
+		}
+		for i := range v {
+			paths = leafPaths(fmt.Sprintf("%s[%d]", path, i), v[i], paths)
+		}
+		return paths
+	default:
+		return append(paths, path)
+	}
+}
+
+// fieldSetPaths appends the paths of the leaf fields in a set of fields
+// in the FieldsV1 format. List items are identified by keys or values in
+// that format, so the live value is used to find their index.
+func fieldSetPaths(path string, fields map[string]interface{}, value interface{}, paths []string) []string {
+	for key, sub := range fields {
+		if key == "." {
+			continue
+		}
+		childPath, child, found := fieldPath(path, key, value)
+		if !found {
+			continue
+		}
+		subFields, _ := sub.(map[string]interface{})
+		if len(subFields) == 0 {
+			paths = append(paths, childPath)
+			continue
+		}
+		paths = fieldSetPaths(childPath, subFields, child, paths)
+	}
+	return paths
+}
+
+// fieldPath returns the path and the live value of the field or list
+// item identified by a key in the FieldsV1 format.
+func fieldPath(path, key string, value interface{}) (string, interface{}, bool) {
+	if len(key) < 2 || key[1] != ':' {
+		return "", nil, false
+	}
+	prefix, rest := key[0], key[2:]
+	if prefix == 'f' {
+		m, _ := value.(map[string]interface{})
+		return appendPath(path, rest), m[rest], true
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return "", nil, false
+	}
+	index := -1
+	switch prefix {
+	case 'i':
+		i, err := strconv.Atoi(rest)
+		if err == nil && i < len(list) {
+			index = i
+		}
+	case 'v':
+		var v interface{}
+		if err := json.Unmarshal([]byte(rest), &v); err != nil {
+			return "", nil, false
+		}
+		for i := range list {
+			if equalScalars(v, list[i]) {
+				index = i
+				break
+			}
+		}
+	case 'k':
+		var keys map[string]interface{}
+		if err := json.Unmarshal([]byte(rest), &keys); err != nil {
+			return "", nil, false
+		}
+		for i := range list {
+			if matchesKeys(list[i], keys) {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 {
+		return "", nil, false
+	}
+	return fmt.Sprintf("%s[%d]", path, index), list[index], true
+}
+
+func matchesKeys(item interface{}, keys map[string]interface{}) bool {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, v := range keys {
+		if !equalScalars(v, m[k]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isManaged returns true if the path is one of the managed paths, or
+// if either of them is a parent of the other one. A difference can be
+// reported for a parent of managed fields, for example if a list has
+// a different length.
+func isManaged(path string, managed []string) bool {
+	for _, m := range managed {
+		if hasPathPrefix(path, m) || hasPathPrefix(m, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPathPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+	return len(path) == len(prefix) || path[len(prefix)] == '.' || path[len(prefix)] == '['
+}
+
+// DefaultedFields returns the paths of the fields in an object returned
+// by the server after apply that are not managed by apply. These are the
+// fields set by defaulting or by mutating admission webhooks. Metadata is
+// skipped, since the server always sets fields like the uid there. If
+// the object has no information about which fields are managed, no
+// fields are returned.
+func DefaultedFields(obj *unstructured.Unstructured) ([]string, error) {
+	managed, found, err := managedPaths(obj)
+	if err != nil || !found {
+		return nil, err
+	}
+	var result []string
+	for _, key := range sortedKeys(obj.Object) {
+		if ignoredFields[key] || key == "metadata" {
+			continue
+		}
+		for _, path := range leafPaths(appendPath("", key), obj.Object[key], nil) {
+			if !isManaged(path, managed) {
+				result = append(result, path)
+			}
+		}
+	}
+	return result, nil
+}
+
+// Changes returns the paths of the fields that applying the local
+// manifest would change in the live resource. These are the fields set
+// in the manifest that have a different live value, and the fields in
+// the last-applied-configuration of the live resource that are no
+// longer in the manifest, since apply removes them.
+func Changes(local, live *unstructured.Unstructured) ([]string, error) {
+	var result []string
+	for _, d := range Compare(local, live) {
+		result = append(result, d.Path)
+	}
+	lastApplied, found := live.GetAnnotations()[v1.LastAppliedConfigAnnotation]
+	if !found {
+		return result, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(lastApplied), &obj); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", v1.LastAppliedConfigAnnotation, err)
+	}
+	var localPaths, appliedPaths []string
+	for _, key := range sortedKeys(local.Object) {
+		if !ignoredFields[key] {
+			localPaths = leafPaths(appendPath("", key), local.Object[key], localPaths)
+		}
+	}
+	for _, key := range sortedKeys(obj) {
+		if !ignoredFields[key] {
+			appliedPaths = leafPaths(appendPath("", key), obj[key], appliedPaths)
+		}
+	}
+	for _, path := range appliedPaths {
+		if !isManaged(path, localPaths) {
+			result = append(result, path)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregator
+
+import (
+	"sort"
+
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/cli-utils/pkg/object"
+)
+
+// Group is a set of resources that share the same key, such as
+// their namespace or kind, together with their aggregate status.
+type Group struct {
+	// Name is the key shared by the resources in the group.
+	Name string
+	// AggregateStatus is the status of the group, computed with the
+	// same rules as the AggregateStatus of an aggregator.
+	AggregateStatus status.Status
+	// Counts is the number of resources in the group with each status.
+	Counts map[status.Status]int
+	// Resources are the resources in the group, in the order they
+	// were provided.
+	Resources []*event.ResourceStatus
+}
+
+// GroupResources splits the resources into groups by the key returned
+// by keyFn, and computes the aggregate status of each group with
+// respect to the desired status. The groups are sorted by name.
+func GroupResources(resources []*event.ResourceStatus, desiredStatus status.Status,
+	keyFn func(*event.ResourceStatus) string) []Group {
+	groupsByName := make(map[string]*Group)
+	var names []string
+	for _, r := range resources {
+		name := keyFn(r)
+		g, found := groupsByName[name]
+		if !found {
+			g = &Group{
+				Name:   name,
+				Counts: make(map[status.Status]int),
+			}
+			groupsByName[name] = g
+			names = append(names, name)
+		}
+		g.Counts[r.Status]++
+		g.Resources = append(g.Resources, r)
+	}
+	sort.Strings(names)
+
+	groups := make([]Group, 0, len(names))
+	for _, name := range names {
+		g := groupsByName[name]
+		identifiers := make([]object.ObjMetadata, 0, len(g.Resources))
+		for _, r := range g.Resources {
+			identifiers = append(identifiers, r.Identifier)
+		}
+		aggregator := newGenericAggregator(identifiers, desiredStatus)
+		for _, r := range g.Resources {
+			aggregator.ResourceStatus(r)
+		}
+		g.AggregateStatus = aggregator.AggregateStatus()
+		groups = append(groups, *g)
+	}
+	return groups
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package aggregator
+
+import (
+	"testing"
+
+	"gotest.tools/assert"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/event"
+	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
+)
+
+func TestGroupResources(t *testing.T) {
+	deployment := &event.ResourceStatus{
+		Identifier: resourceIdentifiers["deployment"],
+		Status:     status.CurrentStatus,
+	}
+	statefulSet := &event.ResourceStatus{
+		Identifier: resourceIdentifiers["statefulset"],
+		Status:     status.InProgressStatus,
+	}
+	service := &event.ResourceStatus{
+		Identifier: resourceIdentifiers["service"],
+		Status:     status.CurrentStatus,
+	}
+	byKind := func(r *event.ResourceStatus) string {
+		return r.Identifier.GroupKind.String()
+	}
+
+	testCases := map[string]struct {
+		resources     []*event.ResourceStatus
+		desiredStatus status.Status
+		keyFn         func(*event.ResourceStatus) string
+		expected      []Group
+	}{
+		"no resources": {
+			desiredStatus: status.CurrentStatus,
+			keyFn:         byKind,
+			expected:      []Group{},
+		},
+		"groups are sorted and aggregated": {
+			resources:     []*event.ResourceStatus{statefulSet, service, deployment},
+			desiredStatus: status.CurrentStatus,
+			keyFn: func(r *event.ResourceStatus) string {
+				return r.Identifier.GroupKind.Group
+			},
+			expected: []Group{
+				{
+					Name:            "",
+					AggregateStatus: status.CurrentStatus,
+					Counts:          map[status.Status]int{status.CurrentStatus: 1},
+					Resources:       []*event.ResourceStatus{service},
+				},
+				{
+					Name:            "apps",
+					AggregateStatus: status.InProgressStatus,
+					Counts: map[status.Status]int{
+						status.CurrentStatus:    1,
+						status.InProgressStatus: 1,
+					},
+					Resources: []*event.ResourceStatus{statefulSet, deployment},
+				},
+			},
+		},
+		"aggregate status uses the desired status": {
+			resources:     []*event.ResourceStatus{deployment},
+			desiredStatus: status.NotFoundStatus,
+			keyFn:         byKind,
+			expected: []Group{
+				{
+					Name:            "Deployment.apps",
+					AggregateStatus: status.InProgressStatus,
+					Counts:          map[status.Status]int{status.CurrentStatus: 1},
+					Resources:       []*event.ResourceStatus{deployment},
+				},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			groups := GroupResources(tc.resources, tc.desiredStatus, tc.keyFn)
+			assert.DeepEqual(t, tc.expected, groups)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -132,6 +133,16 @@ type CachingClusterReader struct {
 	// of GVK and namespace. Before each polling cycle, the framework will call the
 	// Sync function, which is responsible for repopulating the cache.
 	cache map[gvkNamespace]unstructured.UnstructuredList
+
+	// listErrors contains the errors for the combinations of GVK and namespace
+	// that could not be listed in the last Sync because the client doesn't have
+	// access to them. Any lookups for these will return the error, so only the
+	// status of the affected resources will be degraded.
+	listErrors map[gvkNamespace]error
+
+	// RetryPolicy decides which errors from the LIST calls made in
+	// Sync will be retried. If it is nil, the first error is returned.
+	RetryPolicy *retry.Policy
 }
 
 // gvkNamespace contains information about a GroupVersionKind and a namespace.
@@ -154,6 +165,9 @@ func (c *CachingClusterReader) Get(_ context.Context, key client.ObjectKey, obj
 		GVK:       gvk,
 		Namespace: key.Namespace,
 	}
+	if err, found := c.listErrors[gn]; found {
+		return err
+	}
 	cacheList, found := c.cache[gn]
 	if !found {
 		return fmt.Errorf("GVK %s and Namespace %s not found in cache", gvk.String(), gn.Namespace)
@@ -178,6 +192,9 @@ func (c *CachingClusterReader) ListNamespaceScoped(_ context.Context, list *unst
 		Namespace: namespace,
 	}
 
+	if err, found := c.listErrors[gn]; found {
+		return err
+	}
 	cacheList, found := c.cache[gn]
 	if !found {
 		return fmt.Errorf("GVK %s and Namespace %s not found in cache", gvk.String(), gn.Namespace)
@@ -201,11 +218,14 @@ func (c *CachingClusterReader) ListClusterScoped(ctx context.Context, list *unst
 }
 
 // Sync loops over the list of gvkNamespace we know of, and uses list calls to fetch the resources.
-// This information populates the cache.
+// This information populates the cache. If the client is not allowed to list one of the
+// combinations of GVK and namespace, the error is kept and returned for all lookups of
+// that combination, rather than failing the whole Sync.
 func (c *CachingClusterReader) Sync(ctx context.Context) error {
 	c.Lock()
 	defer c.Unlock()
 	cache := make(map[gvkNamespace]unstructured.UnstructuredList)
+	listErrors := make(map[gvkNamespace]error)
 	for _, gn := range c.gns {
 		mapping, err := c.mapper.RESTMapping(gn.GVK.GroupKind())
 		if err != nil {
@@ -217,12 +237,19 @@ func (c *CachingClusterReader) Sync(ctx context.Context) error {
 		}
 		var list unstructured.UnstructuredList
 		list.SetGroupVersionKind(gn.GVK)
-		err = c.reader.List(ctx, &list, listOptions...)
+		err = c.RetryPolicy.Do(ctx, func() error {
+			return c.reader.List(ctx, &list, listOptions...)
+		}, nil)
 		if err != nil {
+			if errors.IsForbidden(err) {
+				listErrors[gn] = err
+				continue
+			}
 			return err
 		}
 		cache[gn] = list
 	}
 	c.cache = cache
+	c.listErrors = listErrors
 	return nil
 }
@@ -5,15 +5,21 @@ package clusterreader
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"testing"
 
 	"gotest.tools/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/testutil"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -100,6 +106,106 @@ func TestSync(t *testing.T) {
 	}
 }
 
+func TestSyncRetry(t *testing.T) {
+	identifiers := []object.ObjMetadata{
+		{
+			GroupKind: podGVK.GroupKind(),
+			Name:      "pod",
+			Namespace: "Foo",
+		},
+	}
+	tooManyRequestsErr := errors.NewTooManyRequests("slow down", 1)
+
+	testCases := map[string]struct {
+		retryPolicy *retry.Policy
+		listErrors  []error
+		expectedErr error
+	}{
+		"no retry policy": {
+			listErrors:  []error{tooManyRequestsErr},
+			expectedErr: tooManyRequestsErr,
+		},
+		"succeeds after retry": {
+			retryPolicy: &retry.Policy{
+				Attempts:  2,
+				Retryable: retry.AllClasses,
+			},
+			listErrors: []error{tooManyRequestsErr},
+		},
+		"fails after all attempts": {
+			retryPolicy: &retry.Policy{
+				Attempts:  2,
+				Retryable: retry.AllClasses,
+			},
+			listErrors:  []error{tooManyRequestsErr, tooManyRequestsErr},
+			expectedErr: tooManyRequestsErr,
+		},
+	}
+
+	fakeMapper := testutil.NewFakeRESTMapper(podGVK)
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			fakeReader := &fakeReader{
+				listErrors: tc.listErrors,
+			}
+
+			clusterReader, err := NewCachingClusterReader(fakeReader, fakeMapper, identifiers)
+			assert.NilError(t, err)
+			clusterReader.RetryPolicy = tc.retryPolicy
+
+			err = clusterReader.Sync(context.Background())
+			if tc.expectedErr != nil {
+				assert.Equal(t, tc.expectedErr, err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, 1, len(clusterReader.cache))
+		})
+	}
+}
+
+func TestSyncForbidden(t *testing.T) {
+	identifiers := []object.ObjMetadata{
+		{
+			GroupKind: podGVK.GroupKind(),
+			Name:      "pod",
+			Namespace: "Foo",
+		},
+		{
+			GroupKind: podGVK.GroupKind(),
+			Name:      "pod",
+			Namespace: "Bar",
+		},
+	}
+	forbiddenErr := errors.NewForbidden(schema.GroupResource{Resource: "pods"}, "",
+		fmt.Errorf("not allowed"))
+
+	fakeMapper := testutil.NewFakeRESTMapper(podGVK)
+	fakeReader := &fakeReader{
+		listErrors: []error{forbiddenErr},
+	}
+
+	clusterReader, err := NewCachingClusterReader(fakeReader, fakeMapper, identifiers)
+	assert.NilError(t, err)
+
+	err = clusterReader.Sync(context.Background())
+	assert.NilError(t, err)
+
+	var list unstructured.UnstructuredList
+	list.SetGroupVersionKind(podGVK)
+	err = clusterReader.ListNamespaceScoped(context.Background(), &list, "Foo", labels.Everything())
+	assert.Equal(t, forbiddenErr, err)
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(podGVK)
+	err = clusterReader.Get(context.Background(), client.ObjectKey{Namespace: "Foo", Name: "pod"}, obj)
+	assert.Equal(t, forbiddenErr, err)
+
+	err = clusterReader.ListNamespaceScoped(context.Background(), &list, "Bar", labels.Everything())
+	assert.NilError(t, err)
+}
+
 func sortGVKNamespaces(gvkNamespaces []gvkNamespace) {
 	sort.Slice(gvkNamespaces, func(i, j int) bool {
 		if gvkNamespaces[i].GVK.String() != gvkNamespaces[j].GVK.String() {
@@ -111,6 +217,9 @@ func sortGVKNamespaces(gvkNamespaces []gvkNamespace) {
 
 type fakeReader struct {
 	syncedGVKNamespaces []gvkNamespace
+	// listErrors are returned from the List calls, one for each call,
+	// until there are no more errors.
+	listErrors []error
 }
 
 func (f *fakeReader) Get(_ context.Context, _ client.ObjectKey, _ runtime.Object) error {
@@ -127,6 +236,12 @@ func (f *fakeReader) List(_ context.Context, list runtime.Object, opts ...client
 		}
 	}
 
+	if len(f.listErrors) > 0 {
+		err := f.listErrors[0]
+		f.listErrors = f.listErrors[1:]
+		return err
+	}
+
 	gvk := list.GetObjectKind().GroupVersionKind()
 	f.syncedGVKNamespaces = append(f.syncedGVKNamespaces, gvkNamespace{
 		GVK:       gvk,
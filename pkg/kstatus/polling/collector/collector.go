@@ -41,6 +41,8 @@ type ResourceStatusCollector struct {
 	resourceStatuses map[object.ObjMetadata]*event.ResourceStatus
 
 	error error
+
+	warning error
 }
 
 // Listen kicks off the goroutine that will listen for the events on the eventChannel. It is also
@@ -74,6 +76,10 @@ func (o *ResourceStatusCollector) processEvent(e event.Event) {
 		o.error = e.Error
 		return
 	}
+	if e.EventType == event.WarningEvent {
+		o.warning = e.Error
+		return
+	}
 	o.aggregateStatus = e.AggregateStatus
 	if e.EventType == event.ResourceUpdateEvent {
 		resourceStatus := e.Resource
@@ -91,6 +97,10 @@ type Observation struct {
 	ResourceStatuses []*event.ResourceStatus
 
 	Error error
+
+	// Warning is the error from the last WarningEvent. The poller
+	// kept going after this error, so the statuses might be stale.
+	Warning error
 }
 
 // LatestObservation returns an Observation instance, which contains the
@@ -110,5 +120,6 @@ func (o *ResourceStatusCollector) LatestObservation() *Observation {
 		AggregateStatus:  o.aggregateStatus,
 		ResourceStatuses: resourceStatuses,
 		Error:            o.error,
+		Warning:          o.warning,
 	}
 }
@@ -78,6 +78,7 @@ func (s *PollerEngine) Poll(ctx context.Context, identifiers []object.ObjMetadat
 			statusAggregator:         aggregator,
 			pollUntilCancelled:       options.PollUntilCancelled,
 			pollingInterval:          options.PollInterval,
+			errorBudget:              options.ErrorBudget,
 		}
 		runner.Run()
 	}()
@@ -127,6 +128,14 @@ type Options struct {
 	// clusterReader. Each statusPollerRunner has a separate set of statusReaders, so this will be called
 	// for every call to Poll.
 	StatusReadersFactoryFunc StatusReadersFactoryFunc
+
+	// ErrorBudget is the number of consecutive times syncing the ClusterReader can fail
+	// before the PollerEngine gives up and sends an ErrorEvent. Every failure within the
+	// budget is reported with a WarningEvent, and the next attempt is delayed with an
+	// exponential backoff. The last known status of the resources is kept until polling
+	// succeeds again. If it is zero, the first failure will stop the polling. If it is
+	// negative, the PollerEngine never gives up.
+	ErrorBudget int
 }
 
 // statusPollerRunner is responsible for polling of a set of resources. Each call to Poll will create
@@ -179,41 +188,66 @@ type statusPollerRunner struct {
 	// pollingInterval determines how often we should poll the cluster for
 	// the latest state of resources.
 	pollingInterval time.Duration
+
+	// errorBudget is the number of consecutive failures syncing the clusterReader
+	// that are tolerated before the runner gives up.
+	errorBudget int
 }
 
+// maxSyncBackoff is the upper limit for how long the runner will wait
+// before trying again after syncing the clusterReader has failed, unless the
+// pollingInterval is longer.
+const maxSyncBackoff = time.Minute
+
 // Run starts the polling loop of the statusReaders.
 func (r *statusPollerRunner) Run() {
-	// Sets up ticker that will trigger the regular polling loop at a regular interval.
-	ticker := time.NewTicker(r.pollingInterval)
+	// Sets up timer that will trigger the regular polling loop at a regular interval.
+	timer := time.NewTimer(r.pollingInterval)
 	defer func() {
-		ticker.Stop()
+		timer.Stop()
 	}()
 
+	// syncFailures is the number of consecutive times syncing the
+	// clusterReader has failed.
+	syncFailures := 0
+
 	for {
 		select {
 		case <-r.ctx.Done():
 			// If the context has been cancelled, just send an AbortedEvent
 			// and pass along the most up-to-date aggregate status. Then return
-			// from this function, which will stop the ticker and close the event channel.
+			// from this function, which will stop the timer and close the event channel.
 			aggregatedStatus := r.statusAggregator.AggregateStatus()
 			r.eventChannel <- event.Event{
 				EventType:       event.AbortedEvent,
 				AggregateStatus: aggregatedStatus,
 			}
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			// First trigger a sync of the ClusterReader. This may or may not actually
 			// result in calls to the cluster, depending on the implementation.
-			// If this call fails, there is no clean way to recover, so we just return an ErrorEvent
-			// and shut down.
+			// If this call fails, we keep the last known status of all resources and
+			// try again after a backoff, as long as the error budget allows it. Otherwise
+			// we just return an ErrorEvent and shut down.
 			err := r.clusterReader.Sync(r.ctx)
 			if err != nil {
+				syncFailures++
+				if r.errorBudget >= 0 && syncFailures > r.errorBudget {
+					r.eventChannel <- event.Event{
+						EventType: event.ErrorEvent,
+						Error:     err,
+					}
+					return
+				}
 				r.eventChannel <- event.Event{
-					EventType: event.ErrorEvent,
-					Error:     err,
+					EventType:       event.WarningEvent,
+					AggregateStatus: r.statusAggregator.AggregateStatus(),
+					Error:           err,
 				}
-				return
+				timer.Reset(r.syncBackoff(syncFailures))
+				continue
 			}
+			syncFailures = 0
 			// Poll all resources and compute status. If the polling of resources has completed (based
 			// on information from the StatusAggregator and the value of pollUntilCancelled), we send
 			// a CompletedEvent and return.
@@ -226,8 +260,27 @@ func (r *statusPollerRunner) Run() {
 				}
 				return
 			}
+			timer.Reset(r.pollingInterval)
+		}
+	}
+}
+
+// syncBackoff returns how long to wait before the next attempt to sync
+// the clusterReader, given the number of consecutive failures so far. It starts
+// at twice the pollingInterval and doubles for every failure, up to maxSyncBackoff.
+func (r *statusPollerRunner) syncBackoff(failures int) time.Duration {
+	limit := maxSyncBackoff
+	if r.pollingInterval > limit {
+		limit = r.pollingInterval
+	}
+	backoff := r.pollingInterval
+	for i := 0; i < failures; i++ {
+		backoff *= 2
+		if backoff >= limit {
+			return limit
 		}
 	}
+	return backoff
 }
 
 // pollStatusForAllResources iterates over all the resources in the set and delegates
@@ -5,6 +5,7 @@ package engine
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -182,6 +183,125 @@ func TestNewStatusPollerRunnerCancellation(t *testing.T) {
 	}
 }
 
+func TestStatusPollerRunnerErrorBudget(t *testing.T) {
+	identifiers := []object.ObjMetadata{
+		{
+			GroupKind: schema.GroupKind{
+				Group: "apps",
+				Kind:  "Deployment",
+			},
+			Name:      "foo",
+			Namespace: "bar",
+		},
+	}
+	syncErr := fmt.Errorf("sync failed")
+
+	testCases := map[string]struct {
+		errorBudget        int
+		syncErrors         []error
+		expectedEventTypes []event.EventType
+	}{
+		"no error budget": {
+			errorBudget: 0,
+			syncErrors:  []error{syncErr},
+			expectedEventTypes: []event.EventType{
+				event.ErrorEvent,
+			},
+		},
+		"recovers within error budget": {
+			errorBudget: 2,
+			syncErrors:  []error{syncErr, syncErr},
+			expectedEventTypes: []event.EventType{
+				event.WarningEvent,
+				event.WarningEvent,
+				event.ResourceUpdateEvent,
+				event.ResourceUpdateEvent,
+				event.CompletedEvent,
+			},
+		},
+		"error budget is reset after successful sync": {
+			errorBudget: 1,
+			syncErrors:  []error{syncErr, nil, syncErr},
+			expectedEventTypes: []event.EventType{
+				event.WarningEvent,
+				event.ResourceUpdateEvent,
+				event.WarningEvent,
+				event.ResourceUpdateEvent,
+				event.CompletedEvent,
+			},
+		},
+		"gives up when error budget is used": {
+			errorBudget: 1,
+			syncErrors:  []error{syncErr, syncErr},
+			expectedEventTypes: []event.EventType{
+				event.WarningEvent,
+				event.ErrorEvent,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			engine := PollerEngine{}
+
+			options := Options{
+				PollInterval: 10 * time.Millisecond,
+				ErrorBudget:  tc.errorBudget,
+				AggregatorFactoryFunc: func(identifiers []object.ObjMetadata) StatusAggregator {
+					return newFakeAggregator(identifiers)
+				},
+				ClusterReaderFactoryFunc: func(_ client.Reader, _ meta.RESTMapper, _ []object.ObjMetadata) (
+					ClusterReader, error) {
+					return &fakeClusterReader{
+						NoopClusterReader: testutil.NewNoopClusterReader(),
+						syncErrors:        tc.syncErrors,
+					}, nil
+				},
+				StatusReadersFactoryFunc: func(_ ClusterReader, _ meta.RESTMapper) (
+					statusReaders map[schema.GroupKind]StatusReader, defaultStatusReader StatusReader) {
+					return make(map[schema.GroupKind]StatusReader), &fakeStatusReader{
+						resourceStatuses: map[schema.GroupKind][]status.Status{
+							schema.GroupKind{Group: "apps", Kind: "Deployment"}: { //nolint:gofmt
+								status.InProgressStatus,
+								status.CurrentStatus,
+							},
+						},
+						resourceStatusCount: make(map[schema.GroupKind]int),
+					}
+				},
+			}
+
+			eventChannel := engine.Poll(context.Background(), identifiers, options)
+
+			var eventTypes []event.EventType
+			for e := range eventChannel {
+				eventTypes = append(eventTypes, e.EventType)
+				if e.EventType == event.WarningEvent || e.EventType == event.ErrorEvent {
+					assert.Equal(t, syncErr, e.Error)
+				}
+			}
+
+			assert.DeepEqual(t, tc.expectedEventTypes, eventTypes)
+		})
+	}
+}
+
+// fakeClusterReader is a ClusterReader where Sync returns the
+// syncErrors, one for each call, until there are no more errors.
+type fakeClusterReader struct {
+	*testutil.NoopClusterReader
+	syncErrors []error
+}
+
+func (f *fakeClusterReader) Sync(_ context.Context) error {
+	if len(f.syncErrors) == 0 {
+		return nil
+	}
+	err := f.syncErrors[0]
+	f.syncErrors = f.syncErrors[1:]
+	return err
+}
+
 type fakeStatusReader struct {
 	resourceStatuses    map[schema.GroupKind][]status.Status
 	resourceStatusCount map[schema.GroupKind]int
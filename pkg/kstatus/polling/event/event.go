@@ -27,6 +27,10 @@ const (
 	// ErrorEvent signals that the engine has encountered an error that it can not recover from. The engine
 	// is shutting down and the event channel will be closed after this event.
 	ErrorEvent
+	// WarningEvent signals that the engine has encountered an error, but that it will keep polling. The
+	// status of the resources will not be updated until the engine is able to read them from the cluster
+	// again. If the engine runs out of its error budget, it will send an ErrorEvent and shut down.
+	WarningEvent
 )
 
 // Event defines that type that is passed back through the event channel to notify the caller of changes
@@ -43,8 +47,8 @@ type Event struct {
 	// including the resource status, any errors and the resource itself (as an unstructured).
 	Resource *ResourceStatus
 
-	// Error is only available for ErrorEvents. It contains the error that caused the engine to
-	// give up.
+	// Error is only available for ErrorEvents and WarningEvents. It contains the error that caused
+	// the engine to give up, or for WarningEvents, the error that the engine will try to recover from.
 	Error error
 }
 
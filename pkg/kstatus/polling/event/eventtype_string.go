@@ -15,11 +15,12 @@ func _() {
 	_ = x[CompletedEvent-1]
 	_ = x[AbortedEvent-2]
 	_ = x[ErrorEvent-3]
+	_ = x[WarningEvent-4]
 }
 
-const _EventType_name = "ResourceUpdateEventCompletedEventAbortedEventErrorEvent"
+const _EventType_name = "ResourceUpdateEventCompletedEventAbortedEventErrorEventWarningEvent"
 
-var _EventType_index = [...]uint8{0, 19, 33, 45, 55}
+var _EventType_index = [...]uint8{0, 19, 33, 45, 55, 67}
 
 func (i EventType) String() string {
 	if i < 0 || i >= EventType(len(_EventType_index)-1) {
@@ -18,6 +18,7 @@ import (
 	"sigs.k8s.io/cli-utils/pkg/kstatus/polling/statusreaders"
 	"sigs.k8s.io/cli-utils/pkg/kstatus/status"
 	"sigs.k8s.io/cli-utils/pkg/object"
+	"sigs.k8s.io/cli-utils/pkg/retry"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -61,8 +62,9 @@ func (s *StatusPoller) Poll(ctx context.Context, identifiers []object.ObjMetadat
 		PollUntilCancelled:       options.PollUntilCancelled,
 		PollInterval:             options.PollInterval,
 		AggregatorFactoryFunc:    aggregatorFactoryFunc(options.DesiredStatus),
-		ClusterReaderFactoryFunc: clusterReaderFactoryFunc(options.UseCache),
+		ClusterReaderFactoryFunc: clusterReaderFactoryFunc(options.UseCache, options.RetryPolicy),
 		StatusReadersFactoryFunc: createStatusReaders,
+		ErrorBudget:              options.ErrorBudget,
 	})
 }
 
@@ -97,6 +99,19 @@ type Options struct {
 	// PollUntilCancelled is false, then it is also used to determine when
 	// we should stop polling.
 	DesiredStatus status.Status
+
+	// RetryPolicy decides which errors from the LIST calls made by
+	// the ClusterReader before each polling cycle will be retried.
+	// It is only used if UseCache is true. If it is nil, any error
+	// will stop the polling.
+	RetryPolicy *retry.Policy
+
+	// ErrorBudget is the number of consecutive polling cycles that can fail
+	// to read the resources from the cluster before the StatusPoller gives
+	// up. Each failure within the budget is reported with a WarningEvent. If
+	// it is zero, the first failure will stop the polling. If it is negative,
+	// the StatusPoller never gives up.
+	ErrorBudget int
 }
 
 // createStatusReaders creates an instance of all the statusreaders. This includes a set of statusreaders for
@@ -125,10 +140,15 @@ func createStatusReaders(reader engine.ClusterReader, mapper meta.RESTMapper) (m
 // The decision for which implementation of the ClusterReader interface that should be used are
 // decided here rather than based on information passed in to the factory function. Thus, the decision
 // for which implementation is decided when the StatusPoller is created.
-func clusterReaderFactoryFunc(useCache bool) engine.ClusterReaderFactoryFunc {
+func clusterReaderFactoryFunc(useCache bool, retryPolicy *retry.Policy) engine.ClusterReaderFactoryFunc {
 	return func(r client.Reader, mapper meta.RESTMapper, identifiers []object.ObjMetadata) (engine.ClusterReader, error) {
 		if useCache {
-			return clusterreader.NewCachingClusterReader(r, mapper, identifiers)
+			cr, err := clusterreader.NewCachingClusterReader(r, mapper, identifiers)
+			if err != nil {
+				return nil, err
+			}
+			cr.RetryPolicy = retryPolicy
+			return cr, nil
 		}
 		return &clusterreader.DirectClusterReader{Reader: r}, nil
 	}
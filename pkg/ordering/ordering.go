@@ -0,0 +1,53 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package ordering defines the order in which resources of
+// different kinds should be applied to the cluster.
+package ordering
+
+// An attempt to order things to help k8s, e.g.
+// a Service should come before things that refer to it.
+// Namespace should be first.
+// In some cases order just specified to provide determinism.
+var orderFirst = []string{
+	"Namespace",
+	"ResourceQuota",
+	"StorageClass",
+	"CustomResourceDefinition",
+	"MutatingWebhookConfiguration",
+	"ServiceAccount",
+	"PodSecurityPolicy",
+	"Role",
+	"ClusterRole",
+	"RoleBinding",
+	"ClusterRoleBinding",
+	"ConfigMap",
+	"Secret",
+	"Service",
+	"LimitRange",
+	"PriorityClass",
+	"Deployment",
+	"StatefulSet",
+	"CronJob",
+	"PodDisruptionBudget",
+}
+
+var orderLast = []string{
+	"ValidatingWebhookConfiguration",
+}
+
+// IndexByKind returns the index of the kind respecting the order.
+// Kinds that are not explicitly ordered all have index 0, so they
+// are placed after the kinds in orderFirst and before the ones in
+// orderLast. Resources with the same index don't need to be applied
+// in any particular order relative to each other.
+func IndexByKind(kind string) int {
+	m := map[string]int{}
+	for i, n := range orderFirst {
+		m[n] = -len(orderFirst) + i
+	}
+	for i, n := range orderLast {
+		m[n] = 1 + i
+	}
+	return m[kind]
+}
@@ -0,0 +1,18 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package ordering
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexByKind(t *testing.T) {
+	assert.True(t, IndexByKind("Namespace") < IndexByKind("CustomResourceDefinition"))
+	assert.True(t, IndexByKind("CustomResourceDefinition") < IndexByKind("Deployment"))
+	assert.True(t, IndexByKind("Deployment") < IndexByKind("MyCustomKind"))
+	assert.True(t, IndexByKind("MyCustomKind") < IndexByKind("ValidatingWebhookConfiguration"))
+	assert.Equal(t, IndexByKind("MyCustomKind"), IndexByKind("OtherCustomKind"))
+}
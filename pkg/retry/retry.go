@@ -0,0 +1,166 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package retry provides a policy for retrying calls to the
+// cluster that fail with errors that are likely to be transient,
+// with an exponential backoff between the attempts.
+package retry
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// Class identifies a group of errors that can be retried.
+//
+//go:generate stringer -type=Class
+type Class int
+
+const (
+	// TooManyRequests are errors caused by the server or a webhook
+	// rate limiting the client (429).
+	TooManyRequests Class = iota
+	// ServerError are errors with a 5xx status code.
+	ServerError
+	// Conflict are errors caused by a conflicting update of the
+	// same resource (409).
+	Conflict
+	// Timeout are errors caused by a request, or a webhook called
+	// by the server, not completing in time.
+	Timeout
+)
+
+// AllClasses contains all the classes of errors that can be retried.
+var AllClasses = []Class{TooManyRequests, ServerError, Conflict, Timeout}
+
+// DefaultPolicy returns a Policy that makes up to 5 attempts for
+// all the classes of retryable errors, starting with a backoff of
+// half a second.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Attempts:       5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Retryable:      AllClasses,
+	}
+}
+
+// Policy defines when and how often a failed call should be
+// retried. A nil Policy is valid and means that calls are never
+// retried.
+type Policy struct {
+	// Attempts is the total number of times a call will be made,
+	// including the first one. Values less than 2 means the
+	// call will not be retried.
+	Attempts int
+
+	// InitialBackoff is how long to wait before the first retry.
+	// The backoff doubles for every retry after that.
+	InitialBackoff time.Duration
+
+	// MaxBackoff is the upper limit for the backoff between retries.
+	// If it is zero, there is no upper limit.
+	MaxBackoff time.Duration
+
+	// Retryable are the classes of errors that will be retried.
+	// Any other errors are returned right away.
+	Retryable []Class
+}
+
+// IsRetryable returns true if the error belongs to one of the
+// retryable classes of the policy.
+func (p *Policy) IsRetryable(err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+	for _, c := range p.Retryable {
+		if c.Matches(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns how long to wait after the given (1-based)
+// attempt has failed, before making the next attempt.
+func (p *Policy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && backoff >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return backoff
+}
+
+// OnRetryFunc is called by Do before every retry with the attempt
+// that failed, the error from the attempt and how long it will
+// wait before the next attempt.
+type OnRetryFunc func(attempt int, err error, backoff time.Duration)
+
+// Do calls fn until it succeeds, it returns an error that is not
+// retryable, all the attempts have been used or the context is
+// cancelled. The error from the last attempt is returned. The
+// onRetry function can be nil.
+func (p *Policy) Do(ctx context.Context, fn func() error, onRetry OnRetryFunc) error {
+	attempt := 1
+	for {
+		err := fn()
+		if err == nil || p == nil || attempt >= p.Attempts || !p.IsRetryable(err) {
+			return err
+		}
+		backoff := p.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, backoff)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		attempt++
+	}
+}
+
+// Matches returns true if the error belongs to the class.
+func (c Class) Matches(err error) bool {
+	switch c {
+	case TooManyRequests:
+		return apierrors.IsTooManyRequests(err)
+	case ServerError:
+		if s, ok := err.(apierrors.APIStatus); ok {
+			return s.Status().Code >= 500
+		}
+		return false
+	case Conflict:
+		return apierrors.IsConflict(err)
+	case Timeout:
+		if apierrors.IsTimeout(err) || apierrors.IsServerTimeout(err) {
+			return true
+		}
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return true
+		}
+		return isWebhookTimeout(err)
+	default:
+		return false
+	}
+}
+
+// isWebhookTimeout returns true if the error is caused by the server
+// not getting a response from an admission webhook in time.
+func isWebhookTimeout(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "failed calling webhook") &&
+		(strings.Contains(msg, "deadline exceeded") || strings.Contains(msg, "Timeout"))
+}
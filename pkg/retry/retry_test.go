@@ -0,0 +1,162 @@
+// Copyright 2020 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package retry
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	deploymentGR = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tooManyRequestsErr = apierrors.NewTooManyRequests("slow down", 1)
+	internalErr        = apierrors.NewInternalError(fmt.Errorf("boom"))
+	conflictErr        = apierrors.NewConflict(deploymentGR, "foo", fmt.Errorf("changed"))
+	timeoutErr         = apierrors.NewTimeoutError("timed out", 1)
+	webhookErr         = apierrors.NewInternalError(fmt.Errorf(`failed calling webhook "foo": ` +
+		`Post https://foo.svc:443/validate: context deadline exceeded`))
+	notFoundErr = apierrors.NewNotFound(deploymentGR, "foo")
+)
+
+func TestClass_Matches(t *testing.T) {
+	testCases := map[string]struct {
+		err     error
+		classes []Class
+	}{
+		"too many requests": {
+			err:     tooManyRequestsErr,
+			classes: []Class{TooManyRequests},
+		},
+		"internal error": {
+			err:     internalErr,
+			classes: []Class{ServerError},
+		},
+		"conflict": {
+			err:     conflictErr,
+			classes: []Class{Conflict},
+		},
+		"timeout": {
+			err:     timeoutErr,
+			classes: []Class{ServerError, Timeout},
+		},
+		"webhook timeout": {
+			err:     webhookErr,
+			classes: []Class{ServerError, Timeout},
+		},
+		"not found": {
+			err:     notFoundErr,
+			classes: []Class{},
+		},
+		"not an API error": {
+			err:     fmt.Errorf("something else"),
+			classes: []Class{},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var classes []Class
+			for _, c := range AllClasses {
+				if c.Matches(tc.err) {
+					classes = append(classes, c)
+				}
+			}
+			assert.ElementsMatch(t, tc.classes, classes)
+		})
+	}
+}
+
+func TestPolicy_Backoff(t *testing.T) {
+	p := &Policy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+	}
+	assert.Equal(t, time.Second, p.Backoff(1))
+	assert.Equal(t, 2*time.Second, p.Backoff(2))
+	assert.Equal(t, 4*time.Second, p.Backoff(3))
+	assert.Equal(t, 5*time.Second, p.Backoff(4))
+	assert.Equal(t, 5*time.Second, p.Backoff(10))
+}
+
+func TestPolicy_Do(t *testing.T) {
+	testCases := map[string]struct {
+		policy           *Policy
+		errs             []error
+		expectedCalls    int
+		expectedRetries  int
+		expectedErr      error
+		cancelledContext bool
+	}{
+		"succeeds after retries": {
+			policy:          &Policy{Attempts: 3, Retryable: AllClasses},
+			errs:            []error{tooManyRequestsErr, conflictErr, nil},
+			expectedCalls:   3,
+			expectedRetries: 2,
+		},
+		"gives up after all attempts": {
+			policy:          &Policy{Attempts: 3, Retryable: AllClasses},
+			errs:            []error{internalErr, internalErr, internalErr},
+			expectedCalls:   3,
+			expectedRetries: 2,
+			expectedErr:     internalErr,
+		},
+		"does not retry errors that are not retryable": {
+			policy:        &Policy{Attempts: 3, Retryable: AllClasses},
+			errs:          []error{notFoundErr},
+			expectedCalls: 1,
+			expectedErr:   notFoundErr,
+		},
+		"only retries the classes in the policy": {
+			policy:        &Policy{Attempts: 3, Retryable: []Class{TooManyRequests}},
+			errs:          []error{conflictErr},
+			expectedCalls: 1,
+			expectedErr:   conflictErr,
+		},
+		"nil policy does not retry": {
+			errs:          []error{tooManyRequestsErr},
+			expectedCalls: 1,
+			expectedErr:   tooManyRequestsErr,
+		},
+		"stops when the context is cancelled": {
+			policy:           &Policy{Attempts: 3, InitialBackoff: time.Minute, Retryable: AllClasses},
+			errs:             []error{tooManyRequestsErr},
+			expectedCalls:    1,
+			expectedRetries:  1,
+			expectedErr:      tooManyRequestsErr,
+			cancelledContext: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelledContext {
+				cancel()
+			}
+
+			calls := 0
+			retries := 0
+			err := tc.policy.Do(ctx, func() error {
+				err := tc.errs[calls]
+				calls++
+				return err
+			}, func(attempt int, err error, _ time.Duration) {
+				retries++
+				assert.Equal(t, retries, attempt)
+			})
+
+			assert.Equal(t, tc.expectedErr, err)
+			assert.Equal(t, tc.expectedCalls, calls)
+			assert.Equal(t, tc.expectedRetries, retries)
+		})
+	}
+}